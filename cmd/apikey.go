@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiKeyID     string
+	apiKeyScopes []string
+)
+
+var cmdAPIKey = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage API keys for automation clients",
+}
+
+// install apikey create command
+var cmdAPIKeyCreate = &cobra.Command{
+	Use:   "create",
+	Short: "Generate an API key, printing the key once and the api_keys entry to configure",
+	Run: func(_ *cobra.Command, _ []string) {
+		key, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		entry := auth.APIKey{ID: apiKeyID, Hash: hash, Scopes: apiKeyScopes}
+		if err := entry.Validate(); err != nil {
+			log.Fatal(err)
+		}
+
+		// the key goes to stdout so it can be piped into a secret store, the config entry to stderr.
+		fmt.Println(key)
+		fmt.Fprintf(os.Stderr, "\nadd to api_keys in the configuration, the key is not shown again:\n\n"+
+			"api_keys:\n  - id: %s\n    hash: %s\n    scopes: [%s]\n", entry.ID, entry.Hash, strings.Join(entry.Scopes, ", "))
+	},
+}
+
+// install apikey hash command
+var cmdAPIKeyHash = &cobra.Command{
+	Use:   "hash",
+	Short: "Print the hash of an API key read from stdin",
+	Run: func(_ *cobra.Command, _ []string) {
+		key, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(key) == "" {
+			log.Fatal("no api key read from stdin: ", err)
+		}
+
+		fmt.Println(auth.HashAPIKey(strings.TrimSpace(key)))
+	},
+}
+
+// install apikey list command
+var cmdAPIKeyList = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured API keys and their scopes",
+	Run: func(_ *cobra.Command, _ []string) {
+		app, _, err := app.New(model.AppKindCLI, cfgFile, model.LogLevel(logLevel))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, key := range app.Config.APIKeys {
+			fmt.Printf("%s\t%s\n", key.ID, strings.Join(key.Scopes, ","))
+		}
+	},
+}
+
+// install command flags
+func init() {
+	cmdAPIKeyCreate.Flags().StringVar(&apiKeyID, "id", "", "name of the key in logs and metrics")
+	cmdAPIKeyCreate.Flags().StringSliceVar(&apiKeyScopes, "scope", nil, "scope granted to the key, may be repeated")
+
+	for _, flag := range []string{"id", "scope"} {
+		if err := cmdAPIKeyCreate.MarkFlagRequired(flag); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	cmdAPIKey.AddCommand(cmdAPIKeyCreate, cmdAPIKeyHash, cmdAPIKeyList)
+	rootCmd.AddCommand(cmdAPIKey)
+}
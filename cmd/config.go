@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/spf13/cobra"
+)
+
+var cmdConfig = &cobra.Command{
+	Use:   "config",
+	Short: "Configuration commands",
+}
+
+// install config check command
+var cmdConfigCheck = &cobra.Command{
+	Use:   "check",
+	Short: "Validate the configuration file and environment overrides, listing every problem found",
+	Run: func(_ *cobra.Command, _ []string) {
+		_, err := app.Check(model.AppKindServer, cfgFile)
+
+		var verr *app.ValidationError
+
+		switch {
+		case err == nil:
+			fmt.Println("configuration ok:", cfgFile)
+		case errors.As(err, &verr):
+			fmt.Fprintf(os.Stderr, "%d configuration problems found in %s:\n", len(verr.Problems), cfgFile)
+
+			for _, problem := range verr.Problems {
+				fmt.Fprintln(os.Stderr, "  "+problem)
+			}
+
+			os.Exit(1)
+		default:
+			log.Fatal(err)
+		}
+	},
+}
+
+// install command flags
+func init() {
+	cmdConfig.AddCommand(cmdConfigCheck)
+	rootCmd.AddCommand(cmdConfig)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/export/hosts"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportMetro   string
+	exportFormat  string
+	exportOutput  string
+	exportSerials []string
+)
+
+var cmdExport = &cobra.Command{
+	Use:   "export",
+	Short: "Export stored BOMs",
+}
+
+// install export dhcp command
+var cmdExportDHCP = &cobra.Command{
+	Use:   "dhcp",
+	Short: "Export DHCP reservations for the BMC MAC addresses of the enrolled servers",
+	Run: func(cmd *cobra.Command, _ []string) {
+		app, _, err := app.New(model.AppKindCLI, cfgFile, model.LogLevel(logLevel))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		format, err := export.ParseFormat(exportFormat)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		repository, err := store.NewStore(cmd.Context(), app.Config, app.Logger)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		fleetdbClient, err := store.NewFleetDBClient(cmd.Context(), &app.Config.ServerserviceOptions, app.Logger)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		exporter, err := app.Config.ExportOptions.Exporter(
+			export.WithSource(hosts.New(fleetdbClient, repository, app.Config.LookupOptions.Workers)),
+		)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		data, err := exporter.Export(cmd.Context(), exportMetro, exportSerials, format)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		out := os.Stdout
+		if exportOutput != "" && exportOutput != "-" {
+			out, err = os.Create(exportOutput)
+			if err != nil {
+				app.Logger.Fatal(err)
+			}
+
+			defer out.Close()
+		}
+
+		if _, err := out.Write(data); err != nil {
+			app.Logger.Fatal(err)
+		}
+	},
+}
+
+// install command flags
+func init() {
+	cmdExportDHCP.Flags().StringVar(&exportMetro, "metro", "", "metro whose BOMs are exported with addresses from its pool")
+	cmdExportDHCP.Flags().StringVar(&exportFormat, "format", string(export.FormatISC), "reservation format - isc, kea, dnsmasq")
+	cmdExportDHCP.Flags().StringVar(&exportOutput, "output", "-", "file to write the reservations to, - for stdout")
+	cmdExportDHCP.Flags().StringSliceVar(&exportSerials, "serial", nil, "export only these serial numbers")
+
+	if err := cmdExportDHCP.MarkFlagRequired("metro"); err != nil {
+		log.Fatal(err)
+	}
+
+	cmdExport.AddCommand(cmdExportDHCP)
+	rootCmd.AddCommand(cmdExport)
+}
@@ -2,17 +2,35 @@ package cmd
 
 import (
 	"context"
-	"errors"
+	"crypto/tls"
 	"log"
-	"net/http"
+	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/certs"
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/export/hosts"
+	"github.com/metal-toolbox/bomservice/internal/lifecycle"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
 	"github.com/metal-toolbox/bomservice/internal/metrics"
 	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/ratelimit"
+	"github.com/metal-toolbox/bomservice/internal/reconcile"
 	"github.com/metal-toolbox/bomservice/internal/server"
 	"github.com/metal-toolbox/bomservice/internal/store"
+	"github.com/metal-toolbox/bomservice/internal/tracing"
+	"github.com/metal-toolbox/bomservice/pkg/api/grpc/service"
+	"github.com/metal-toolbox/rivets/v2/events"
+	"github.com/metal-toolbox/rivets/v2/ginjwt"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -31,38 +49,284 @@ var cmdServer = &cobra.Command{
 
 		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
+
+		manager := lifecycle.New(app.Logger, lifecycle.WithShutdownTimeout(shutdownTimeout))
+
+		// the stored boms gauge is set by the index refresh, it is only registered with the index.
+		var metricsOptions []metrics.Option
+		if app.Config.IndexOptions.Enabled {
+			metricsOptions = append(metricsOptions, metrics.WithBomsStored())
+		}
+
+		registry := metrics.NewRegistry()
+		metrics.Use(metrics.New(registry, metricsOptions...))
+
+		shutdownTracing, err := tracing.Init(ctx, &app.Config.TracingOptions)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		// registered first so spans from the other components are flushed.
+		manager.AddHook("tracing", lifecycle.Hook(shutdownTracing))
+
 		repository, err := store.NewStore(ctx, app.Config, app.Logger)
 		if err != nil {
 			app.Logger.Fatal(err)
 		}
 
+		fleetdbClient, err := store.NewFleetDBClient(ctx, &app.Config.ServerserviceOptions, app.Logger)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		var index *store.Index
+		if app.Config.IndexOptions.Enabled {
+			// fleetdb does not list boms, the index is loaded with the boms of the enrolled servers.
+			source := lookup.NewServerSource(fleetdbClient, repository, app.Config.LookupOptions.Workers)
+
+			index = store.NewIndex(repository, source, app.Config.IndexOptions.Interval, app.Logger)
+			repository = index
+			manager.AddWorker("index", index.Run)
+
+			if app.Config.IndexOptions.Events.URL != "" {
+				stream, err := events.NewStream(app.Config.IndexOptions.Events)
+				if err != nil {
+					app.Logger.Fatal(err)
+				}
+
+				if err := stream.Open(); err != nil {
+					app.Logger.Fatal(err)
+				}
+
+				manager.AddWorker("index-events", store.NewIndexEvents(index, stream, app.Logger).Run)
+			}
+		}
+
+		// the feed publishes the changes made through the API and, when indexed, those found by index refreshes.
+		feed := store.NewFeed(repository, store.DefaultFeedBuffer)
+		if index != nil {
+			index.OnChange(feed.Publish)
+		}
+
+		repository = feed
+
+		var pusher *enroll.CredentialPusher
+		if app.Config.EnrollOptions.Credentials.Enabled {
+			pusher = enroll.NewCredentialPusher(fleetdbClient, app.Logger)
+		}
+
+		// on-demand reconciliation only reads, the worker pushes credentials to the servers enrolled since the upload.
+		reconciler := reconcile.New(
+			fleetdbClient,
+			repository,
+			app.Logger,
+			reconcile.WithWorkers(app.Config.LookupOptions.Workers),
+		)
+		if app.Config.ReconcileOptions.Enabled {
+			reconcileOptions := []reconcile.Option{reconcile.WithWorkers(app.Config.LookupOptions.Workers)}
+			if pusher != nil {
+				reconcileOptions = append(reconcileOptions, reconcile.WithCredentialPusher(pusher))
+			}
+
+			worker := reconcile.NewWorker(
+				reconcile.New(fleetdbClient, repository, app.Logger, reconcileOptions...),
+				app.Config.ReconcileOptions.Interval,
+				app.Logger,
+			)
+			manager.AddWorker("reconcile", worker.Run)
+		}
+
+		mapping, err := app.Config.ParserOptions.Mapping()
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
+		var currentMapping atomic.Pointer[parse.Mapping]
+		currentMapping.Store(mapping)
+
+		var apiKeys *auth.APIKeyAuthenticator
+
+		// API key authentication is enabled with the keys configured at startup, reloads replace the keys.
+		if len(app.Config.APIKeys) > 0 {
+			apiKeys, err = auth.NewAPIKeyAuthenticator(app.Config.APIKeys)
+			if err != nil {
+				app.Logger.Fatal(err)
+			}
+		}
+
+		rateLimiter := ratelimit.NewLimiter(&app.Config.RateLimitOptions)
+		uploadLimiter := ratelimit.NewUploadLimiter(&app.Config.RateLimitOptions)
+
+		if app.Config.ReloadOptions.Enabled {
+			app.WatchConfig(reloadFunc(&currentMapping, repository, apiKeys, rateLimiter, uploadLimiter, app.Logger))
+		}
+
+		// allocations are recorded on the fleetdb servers so exported addresses don't move.
+		exporter, err := app.Config.ExportOptions.Exporter(
+			export.WithSource(hosts.New(fleetdbClient, repository, app.Config.LookupOptions.Workers)),
+		)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+
 		options := []server.Option{
 			server.WithLogger(app.Logger),
 			server.WithListenAddress(app.Config.ListenAddress),
 			server.WithStore(repository),
+			server.WithMappingFunc(currentMapping.Load),
+			server.WithReconciler(reconciler),
+			server.WithExporter(exporter),
+			server.WithLookupWorkers(app.Config.LookupOptions.Workers),
 			server.WithAuthMiddlewareConfig(app.Config.APIServerJWTAuth),
+			server.WithRateLimiter(rateLimiter),
+			server.WithUploadLimiter(uploadLimiter),
 		}
 
-		srv := server.New(options...)
-		go func() {
-			if err := srv.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
+		if index != nil {
+			options = append(options, server.WithReadiness(index.Ready))
+		}
+
+		var authenticators []auth.Authenticator
+		if apiKeys != nil {
+			authenticators = append(authenticators, apiKeys)
+		}
+
+		var tlsConfig *tls.Config
+
+		if app.Config.TLSOptions.Enabled {
+			reloader, err := certs.NewReloader(&app.Config.TLSOptions, app.Logger)
+			if err != nil {
 				app.Logger.Fatal(err)
 			}
-		}()
-		metrics.ListenAndServe()
+
+			manager.AddWorker("certificates", reloader.Run)
+
+			tlsConfig = reloader.TLSConfig()
+			options = append(options, server.WithTLSConfig(tlsConfig))
+
+			if len(app.Config.TLSOptions.ClientScopes) > 0 {
+				authenticators = append(authenticators, auth.NewClientCertAuthenticator(app.Config.TLSOptions.Scopes()))
+			}
+		}
+
+		if len(authenticators) > 0 {
+			options = append(options, server.WithAuthenticators(authenticators...))
+		}
+
+		var enroller *enroll.Enroller
+		if app.Config.EnrollOptions.Enabled {
+			enroller = enroll.New(fleetdbClient, app.Config.ServerserviceOptions.FacilityCode, app.Logger)
+			options = append(options, server.WithEnroller(enroller))
+		}
+
+		if pusher != nil {
+			options = append(options, server.WithCredentialPusher(pusher))
+		}
+
+		manager.AddServer("api", server.New(options...))
+
+		if app.Config.GRPCOptions.Enabled {
+			grpcOptions := []service.Option{
+				service.WithStore(repository),
+				service.WithFeed(feed),
+				service.WithLogger(app.Logger),
+				service.WithMappingFunc(currentMapping.Load),
+				service.WithLookupWorkers(app.Config.LookupOptions.Workers),
+				service.WithAuthenticators(authenticators...),
+				service.WithRateLimiter(rateLimiter),
+				service.WithUploadLimiter(uploadLimiter),
+			}
+
+			if enroller != nil {
+				grpcOptions = append(grpcOptions, service.WithEnroller(enroller))
+			}
+
+			if pusher != nil {
+				grpcOptions = append(grpcOptions, service.WithCredentialPusher(pusher))
+			}
+
+			if jwtAuth := app.Config.APIServerJWTAuth; jwtAuth != nil && jwtAuth.Enabled {
+				authMW, err := ginjwt.NewAuthMiddleware(*jwtAuth)
+				if err != nil {
+					app.Logger.Fatal(err)
+				}
+
+				grpcOptions = append(grpcOptions, service.WithAuthMiddleware(authMW))
+			}
+
+			grpcService, err := service.New(grpcOptions...)
+			if err != nil {
+				app.Logger.Fatal(err)
+			}
+
+			manager.AddWorker("grpc", grpcWorker(grpcService, app.Config.GRPCOptions.ListenAddress, tlsConfig))
+		}
+
+		manager.AddServer("metrics", metrics.NewServer(app.Config.MetricsListenAddress, registry))
 
 		// sit around for term signal
-		<-termCh
-		app.Logger.Info("got TERM signal, shutting down server...")
+		go func() {
+			<-termCh
+			app.Logger.Info("got TERM signal, shutting down server...")
+			cancel()
+		}()
 
-		sCtx, sCancel := context.WithTimeout(ctx, shutdownTimeout)
-		defer sCancel()
-		if err := srv.Shutdown(sCtx); err != nil {
-			app.Logger.Fatal("server shutdown error:", err)
+		if err := manager.Run(ctx); err != nil {
+			app.Logger.Fatal(err)
 		}
 	},
 }
 
+// grpcWorker serves the gRPC API on the address until the context is done.
+func grpcWorker(grpcService *service.Service, address string, tlsConfig *tls.Config) lifecycle.Worker {
+	return func(ctx context.Context) error {
+		var opts []grpc.ServerOption
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return err
+		}
+
+		return grpcService.Serve(ctx, listener, opts...)
+	}
+}
+
+// reloadFunc applies the reloaded parser profile, store settings, rate limits and API keys.
+func reloadFunc(
+	mapping *atomic.Pointer[parse.Mapping],
+	repository store.Repository,
+	apiKeys *auth.APIKeyAuthenticator,
+	rateLimiter *ratelimit.Limiter,
+	uploadLimiter *ratelimit.UploadLimiter,
+	logger *logrus.Logger,
+) app.ReloadFunc {
+	return func(config *app.Configuration) {
+		// the parser profile and API keys were validated with the reloaded configuration.
+		if m, err := config.ParserOptions.Mapping(); err == nil {
+			mapping.Store(m)
+		}
+
+		store.Configure(repository, config)
+		rateLimiter.Configure(&config.RateLimitOptions)
+		uploadLimiter.Configure(&config.RateLimitOptions)
+
+		if apiKeys == nil {
+			if len(config.APIKeys) > 0 {
+				logger.Warn("api keys added to the configuration require a restart to enable api key authentication")
+			}
+
+			return
+		}
+
+		if err := apiKeys.SetKeys(config.APIKeys); err != nil {
+			logger.WithError(err).Error("api keys not reloaded")
+		}
+	}
+}
+
 // install command flags
 func init() {
 	rootCmd.AddCommand(cmdServer)
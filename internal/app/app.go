@@ -7,6 +7,7 @@ import (
 
 	runtime "github.com/banzaicloud/logrus-runtime-formatter"
 	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -22,6 +23,8 @@ type App struct {
 	Config *Configuration
 	// Logger is the app logger.
 	Logger *logrus.Logger
+	// applied is the last configuration applied on reload, nil until the first reload.
+	applied *Configuration
 }
 
 // New returns returns a new instance of the bomservice app
@@ -35,6 +38,9 @@ func New(appKind model.AppKind, cfgFile string, loglevel model.LogLevel) (*App,
 		Logger: logrus.New(),
 	}
 
+	// log entries made with an API request context carry its request ID.
+	app.Logger.AddHook(requestid.Hook{})
+
 	termCh := make(chan os.Signal, 1)
 
 	if err := app.LoadConfiguration(); err != nil {
@@ -4,14 +4,51 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/export"
 	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/rivets/v2/events"
 	"github.com/metal-toolbox/rivets/v2/ginjwt"
 	"github.com/pkg/errors"
 )
 
+const (
+	// ClientAuthNone, ClientAuthOptional and ClientAuthRequire are the TLS client certificate policies.
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
 var (
 	ErrConfig = errors.New("configuration error")
+
+	// defaultReconcileInterval is the reconciliation worker interval when none is configured.
+	defaultReconcileInterval = 1 * time.Hour
+
+	// defaultCacheTTL, defaultCacheNegativeTTL and defaultCacheSize apply when the store cache is enabled without them.
+	defaultCacheTTL         = 5 * time.Minute
+	defaultCacheNegativeTTL = 30 * time.Second
+	defaultCacheSize        = 10000
+
+	// defaultIndexInterval is the in-memory bom index refresh interval when none is configured.
+	defaultIndexInterval = 15 * time.Minute
+
+	// defaultTracingExporter and defaultTracingSampleRatio apply when tracing is enabled without them.
+	defaultTracingExporter    = "otlp"
+	defaultTracingSampleRatio = 1.0
+
+	// defaultRateLimitRequestsPerSecond and defaultRateLimitBurst apply when rate limiting is enabled without them.
+	defaultRateLimitRequestsPerSecond = 10.0
+	defaultRateLimitBurst             = 20
+
+	// defaultUploadRetryAfter is the Retry-After for uploads over the concurrency limit when none is configured.
+	defaultUploadRetryAfter = 5 * time.Second
+
+	// defaultGRPCListenAddress is the gRPC API listen address when it is enabled without one.
+	defaultGRPCListenAddress = "0.0.0.0:9091"
 )
 
 // Configuration holds application configuration read from a YAML or set by env variables.
@@ -28,6 +65,12 @@ type Configuration struct {
 	// ListenAddress is the server listen address
 	ListenAddress string `mapstructure:"listen_address"`
 
+	// StoreKind is the bom storage repository, only serverservice is supported.
+	StoreKind string `mapstructure:"store_kind"`
+
+	// MetricsListenAddress is the prometheus metrics listen address.
+	MetricsListenAddress string `mapstructure:"metrics_listen_address"`
+
 	// APIServerJWTAuth sets the JWT verification configuration for the bomservice API service.
 	APIServerJWTAuth *ginjwt.AuthConfig `mapstructure:"ginjwt_auth"`
 
@@ -36,6 +79,224 @@ type Configuration struct {
 
 	// ServerserviceOptions defines the serverservice client configuration parameters
 	ServerserviceOptions ServerserviceOptions `mapstructure:"serverservice"`
+
+	// ParserOptions defines the column mappings used to parse uploaded BOM files.
+	ParserOptions ParserOptions `mapstructure:"parser"`
+
+	// ReconcileOptions defines the BOM and fleetdb inventory reconciliation parameters.
+	ReconcileOptions ReconcileOptions `mapstructure:"reconcile"`
+
+	// EnrollOptions defines the fleetdb server record creation parameters.
+	EnrollOptions EnrollOptions `mapstructure:"enroll"`
+
+	// ExportOptions defines the DHCP reservation export parameters.
+	ExportOptions ExportOptions `mapstructure:"export"`
+
+	// LookupOptions defines the bulk lookup parameters.
+	LookupOptions LookupOptions `mapstructure:"lookup"`
+
+	// CacheOptions defines the store MAC address lookup cache parameters.
+	CacheOptions CacheOptions `mapstructure:"cache"`
+
+	// IndexOptions defines the in-memory bom index parameters.
+	IndexOptions IndexOptions `mapstructure:"index"`
+
+	// TracingOptions defines the OpenTelemetry trace exporter parameters.
+	TracingOptions TracingOptions `mapstructure:"tracing"`
+
+	// ReloadOptions defines the configuration file reload parameters.
+	ReloadOptions ReloadOptions `mapstructure:"reload"`
+
+	// TLSOptions defines the API listener TLS and client certificate authentication parameters.
+	TLSOptions TLSOptions `mapstructure:"tls"`
+
+	// RateLimitOptions defines the per-client request rate and upload concurrency limits.
+	RateLimitOptions RateLimitOptions `mapstructure:"rate_limit"`
+
+	// GRPCOptions defines the gRPC API listener parameters.
+	GRPCOptions GRPCOptions `mapstructure:"grpc"`
+
+	// APIKeys are the hashed API keys accepted in the X-API-Key header and the scopes they grant.
+	APIKeys []auth.APIKey `mapstructure:"api_keys"`
+}
+
+// GRPCOptions defines the gRPC API listener parameters.
+type GRPCOptions struct {
+	// Enabled serves the gRPC API, with the HTTP API TLS, authentication and rate limits.
+	Enabled bool `mapstructure:"enabled"`
+	// ListenAddress is the gRPC API listen address.
+	ListenAddress string `mapstructure:"listen_address"`
+}
+
+// TLSOptions defines the API listener TLS and client certificate authentication parameters.
+type TLSOptions struct {
+	// Enabled serves the API over TLS, the certificate and key files are reloaded when they change.
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientCAFile is the CA bundle client certificates are verified against.
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// ClientAuth is one of none, optional, require, defaults to require when a client CA file is set.
+	ClientAuth string `mapstructure:"client_auth"`
+	// ClientScopes grant scopes to client certificates by subject.
+	ClientScopes []ClientScopes `mapstructure:"client_scopes"`
+}
+
+// ClientScopes grants scopes to the client certificates with the subject.
+type ClientScopes struct {
+	// Subject is the certificate subject in RFC 2253 form, such as CN=automation,O=Example.
+	Subject string   `mapstructure:"subject"`
+	Scopes  []string `mapstructure:"scopes"`
+}
+
+// Scopes returns the granted scopes keyed by client certificate subject.
+func (t *TLSOptions) Scopes() map[string][]string {
+	scopes := make(map[string][]string, len(t.ClientScopes))
+	for _, cs := range t.ClientScopes {
+		scopes[cs.Subject] = append(scopes[cs.Subject], cs.Scopes...)
+	}
+
+	return scopes
+}
+
+// TracingOptions defines the OpenTelemetry trace exporter parameters.
+type TracingOptions struct {
+	// Enabled exports spans for API requests, file parsing and store calls.
+	Enabled bool `mapstructure:"enabled"`
+	// Exporter is one of otlp, stdout.
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint is the OTLP HTTP collector host:port.
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure disables TLS to the OTLP collector.
+	Insecure bool `mapstructure:"insecure"`
+	// SampleRatio is the fraction of traces started by bomservice that are sampled.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// IndexOptions defines the in-memory bom index parameters.
+type IndexOptions struct {
+	// Enabled loads the boms of the servers enrolled in fleetdb at startup and answers lookups from memory,
+	// lookups missing the index go to fleetdb. API requests are held until the first load completes,
+	// a failed load is retried. The bomservice_boms_stored metric is only reported when the index is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the time between refreshes indexing the boms of the servers updated since the last one.
+	Interval time.Duration `mapstructure:"interval"`
+	// Events publishes the uploaded serial numbers on a NATS JetStream and refreshes the index with those
+	// uploaded through the other replicas, disabled when the URL is unset. The app_name is the durable
+	// consumer name, it must be unique per replica for each to receive every event.
+	Events events.NatsOptions `mapstructure:"events"`
+}
+
+// CacheOptions defines the store MAC address lookup cache parameters.
+type CacheOptions struct {
+	// Enabled caches MAC address lookups in front of fleetdb.
+	Enabled bool `mapstructure:"enabled"`
+	// TTL is how long a found bom is cached.
+	TTL time.Duration `mapstructure:"ttl"`
+	// NegativeTTL is how long an unknown MAC address is cached, zero disables negative caching.
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+	// Size is the maximum number of cached lookups, the least recently used is evicted beyond it.
+	Size int `mapstructure:"size"`
+}
+
+// RateLimitOptions defines the per-client request rate and upload concurrency limits.
+type RateLimitOptions struct {
+	// Enabled limits the request rate of each client, identified by the authenticated subject or the client IP.
+	Enabled bool `mapstructure:"enabled"`
+	// RequestsPerSecond is the sustained request rate allowed per client.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	// Burst is the number of requests a client may make at once before the rate applies.
+	Burst int `mapstructure:"burst"`
+	// UploadConcurrency is the maximum number of uploads read into memory at once, zero is unlimited.
+	UploadConcurrency int `mapstructure:"upload_concurrency"`
+	// UploadRetryAfter is the Retry-After in the responses to uploads over the concurrency limit.
+	UploadRetryAfter time.Duration `mapstructure:"upload_retry_after"`
+}
+
+// LookupOptions defines the bulk lookup parameters.
+type LookupOptions struct {
+	// Workers is the number of concurrent store lookups for a bulk request or a reconciliation.
+	Workers int `mapstructure:"workers"`
+}
+
+// ExportOptions defines the DHCP reservation export parameters.
+type ExportOptions struct {
+	// HostnamePrefix is prepended to the serial number in reservation host names.
+	HostnamePrefix string `mapstructure:"hostname_prefix"`
+	// Pools are the BMC address ranges keyed by metro.
+	Pools map[string]PoolOptions `mapstructure:"pools"`
+}
+
+// PoolOptions defines an inclusive IPv4 address range.
+type PoolOptions struct {
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+}
+
+// Exporter returns a DHCP reservation exporter with the configured metro pools.
+func (e *ExportOptions) Exporter(options ...export.Option) (*export.Exporter, error) {
+	pools := make(map[string]*export.Pool, len(e.Pools))
+
+	for metro, opts := range e.Pools {
+		pool, err := export.NewPool(metro, opts.Start, opts.End)
+		if err != nil {
+			return nil, err
+		}
+
+		pools[metro] = pool
+	}
+
+	return export.New(pools, e.HostnamePrefix, options...), nil
+}
+
+// EnrollOptions defines the fleetdb server record creation parameters.
+type EnrollOptions struct {
+	// Enabled creates a fleetdb server record for each new serial number in an uploaded BOM.
+	Enabled bool `mapstructure:"enabled"`
+	// Credentials defines the BOM default BMC credential push parameters.
+	Credentials CredentialOptions `mapstructure:"credentials"`
+}
+
+// CredentialOptions defines the BOM default BMC credential push parameters.
+type CredentialOptions struct {
+	// Enabled writes the BOM default IPMI user and password as the fleetdb server BMC credential on upload,
+	// and on each reconciliation worker run for the servers enrolled after their BOM was uploaded.
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ReconcileOptions defines the BOM and fleetdb inventory reconciliation parameters.
+type ReconcileOptions struct {
+	// Enabled runs the reconciliation worker, on-demand reconciliation is always available.
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the time between reconciliation worker runs.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// ParserOptions defines the column mapping profiles for vendor BOM files.
+type ParserOptions struct {
+	// Profile is the name of the active mapping profile, the default mapping is used when unset.
+	Profile string `mapstructure:"profile"`
+	// Profiles are the named column mappings, unset fields fall back to the default mapping.
+	Profiles map[string]*parse.Mapping `mapstructure:"profiles"`
+}
+
+// Mapping returns the column mapping for the active parser profile.
+func (p *ParserOptions) Mapping() (*parse.Mapping, error) {
+	if p.Profile == "" {
+		return parse.DefaultMapping(), nil
+	}
+
+	profile, exists := p.Profiles[p.Profile]
+	if !exists {
+		return nil, errors.New("parser profile not defined: " + p.Profile)
+	}
+
+	mapping := profile.WithDefaults()
+	if err := mapping.Validate(); err != nil {
+		return nil, errors.Wrap(err, "parser profile "+p.Profile)
+	}
+
+	return mapping, nil
 }
 
 // APIOIDCOptions defines configuration to handle OIDC authn/authz for bomservice API clients.
@@ -52,13 +313,17 @@ type APIOIDCOptions struct {
 // https://github.com/metal-toolbox/hollow-serverservice
 type ServerserviceOptions struct {
 	EndpointURL          *url.URL
-	Endpoint             string   `mapstructure:"endpoint"`
-	OidcIssuerEndpoint   string   `mapstructure:"oidc_issuer_endpoint"`
-	OidcAudienceEndpoint string   `mapstructure:"oidc_audience_endpoint"`
-	OidcClientSecret     string   `mapstructure:"oidc_client_secret"`
+	Endpoint             string `mapstructure:"endpoint"`
+	OidcIssuerEndpoint   string `mapstructure:"oidc_issuer_endpoint"`
+	OidcAudienceEndpoint string `mapstructure:"oidc_audience_endpoint"`
+	OidcClientSecret     string `mapstructure:"oidc_client_secret"`
+	// OidcClientSecretFile is a file holding the client secret, such as a mounted kubernetes secret.
+	OidcClientSecretFile string   `mapstructure:"oidc_client_secret_file"`
 	OidcClientID         string   `mapstructure:"oidc_client_id"`
 	OidcClientScopes     []string `mapstructure:"oidc_client_scopes"`
 	DisableOAuth         bool     `mapstructure:"disable_oauth"`
+	// FacilityCode is the facility fleetdb server records are created in.
+	FacilityCode string `mapstructure:"facility_code"`
 }
 
 func (a *App) LoadConfiguration() error {
@@ -80,8 +345,17 @@ func (a *App) LoadConfiguration() error {
 		return errors.Wrap(err, a.Config.file)
 	}
 
+	var problems []string
+
 	if err := a.envVarOverrides(); err != nil {
-		return errors.Wrap(err, ErrConfig.Error())
+		problems = append(problems, err.Error())
+	}
+
+	problems = append(problems, a.Config.readSecretFiles()...)
+	problems = append(problems, a.Config.validate()...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
 	}
 
 	return nil
@@ -100,18 +374,160 @@ func (a *App) envVarOverrides() error {
 		a.Config.ListenAddress = a.v.GetString("listen.address")
 	}
 
+	if a.v.GetString("metrics.listen.address") != "" {
+		a.Config.MetricsListenAddress = a.v.GetString("metrics.listen.address")
+	}
+
 	if a.v.GetString("store.kind") != "" {
-		a.Config.ListenAddress = a.v.GetString("store.kind")
+		a.Config.StoreKind = a.v.GetString("store.kind")
+	}
+
+	if a.Config.StoreKind == "" {
+		a.Config.StoreKind = model.StoreKindServerservice
 	}
-	if err := a.envVarServerserviceOverrides(); err != nil {
-		return err
+
+	a.envVarServerserviceOverrides()
+
+	if a.v.GetString("parser.profile") != "" {
+		a.Config.ParserOptions.Profile = a.v.GetString("parser.profile")
 	}
 
-	if err := a.apiServerJWTAuthParams(); err != nil {
-		return errors.Wrap(ErrConfig, err.Error())
+	if a.v.GetString("reconcile.enabled") != "" {
+		a.Config.ReconcileOptions.Enabled = a.v.GetBool("reconcile.enabled")
 	}
 
-	return nil
+	if a.Config.ReconcileOptions.Interval == 0 {
+		a.Config.ReconcileOptions.Interval = defaultReconcileInterval
+	}
+
+	if a.v.GetString("enroll.enabled") != "" {
+		a.Config.EnrollOptions.Enabled = a.v.GetBool("enroll.enabled")
+	}
+
+	if a.v.GetString("enroll.credentials.enabled") != "" {
+		a.Config.EnrollOptions.Credentials.Enabled = a.v.GetBool("enroll.credentials.enabled")
+	}
+
+	a.cacheOverrides()
+
+	if a.v.GetString("index.enabled") != "" {
+		a.Config.IndexOptions.Enabled = a.v.GetBool("index.enabled")
+	}
+
+	if a.Config.IndexOptions.Interval == 0 {
+		a.Config.IndexOptions.Interval = defaultIndexInterval
+	}
+
+	a.tracingOverrides()
+
+	if a.v.GetString("reload.enabled") != "" {
+		a.Config.ReloadOptions.Enabled = a.v.GetBool("reload.enabled")
+	}
+
+	a.tlsOverrides()
+	a.rateLimitOverrides()
+	a.grpcOverrides()
+
+	return a.apiServerJWTAuthParams()
+}
+
+func (a *App) cacheOverrides() {
+	if a.v.GetString("cache.enabled") != "" {
+		a.Config.CacheOptions.Enabled = a.v.GetBool("cache.enabled")
+	}
+
+	// the negative TTL is left as configured, zero disables negative caching.
+	if !a.v.IsSet("cache.negative_ttl") {
+		a.Config.CacheOptions.NegativeTTL = defaultCacheNegativeTTL
+	}
+
+	if a.Config.CacheOptions.TTL == 0 {
+		a.Config.CacheOptions.TTL = defaultCacheTTL
+	}
+
+	if a.Config.CacheOptions.Size == 0 {
+		a.Config.CacheOptions.Size = defaultCacheSize
+	}
+}
+
+func (a *App) tracingOverrides() {
+	if a.v.GetString("tracing.enabled") != "" {
+		a.Config.TracingOptions.Enabled = a.v.GetBool("tracing.enabled")
+	}
+
+	if a.v.GetString("tracing.endpoint") != "" {
+		a.Config.TracingOptions.Endpoint = a.v.GetString("tracing.endpoint")
+	}
+
+	if a.Config.TracingOptions.Exporter == "" {
+		a.Config.TracingOptions.Exporter = defaultTracingExporter
+	}
+
+	if !a.v.IsSet("tracing.sample_ratio") {
+		a.Config.TracingOptions.SampleRatio = defaultTracingSampleRatio
+	}
+}
+
+func (a *App) tlsOverrides() {
+	if a.v.GetString("tls.enabled") != "" {
+		a.Config.TLSOptions.Enabled = a.v.GetBool("tls.enabled")
+	}
+
+	overrides := map[string]*string{
+		"tls.cert.file":      &a.Config.TLSOptions.CertFile,
+		"tls.key.file":       &a.Config.TLSOptions.KeyFile,
+		"tls.client.ca.file": &a.Config.TLSOptions.ClientCAFile,
+		"tls.client.auth":    &a.Config.TLSOptions.ClientAuth,
+	}
+
+	for key, field := range overrides {
+		if a.v.GetString(key) != "" {
+			*field = a.v.GetString(key)
+		}
+	}
+
+	if a.Config.TLSOptions.ClientAuth == "" {
+		a.Config.TLSOptions.ClientAuth = ClientAuthNone
+		if a.Config.TLSOptions.ClientCAFile != "" {
+			a.Config.TLSOptions.ClientAuth = ClientAuthRequire
+		}
+	}
+}
+
+func (a *App) rateLimitOverrides() {
+	if a.v.GetString("rate.limit.enabled") != "" {
+		a.Config.RateLimitOptions.Enabled = a.v.GetBool("rate.limit.enabled")
+	}
+
+	if a.v.GetString("rate.limit.upload.concurrency") != "" {
+		a.Config.RateLimitOptions.UploadConcurrency = a.v.GetInt("rate.limit.upload.concurrency")
+	}
+
+	if a.Config.RateLimitOptions.RequestsPerSecond == 0 {
+		a.Config.RateLimitOptions.RequestsPerSecond = defaultRateLimitRequestsPerSecond
+	}
+
+	if a.Config.RateLimitOptions.Burst == 0 {
+		a.Config.RateLimitOptions.Burst = defaultRateLimitBurst
+	}
+
+	if a.Config.RateLimitOptions.UploadRetryAfter == 0 {
+		a.Config.RateLimitOptions.UploadRetryAfter = defaultUploadRetryAfter
+	}
+}
+
+func (a *App) grpcOverrides() {
+	if a.v.GetString("grpc.enabled") != "" {
+		a.Config.GRPCOptions.Enabled = a.v.GetBool("grpc.enabled")
+	}
+
+	if a.v.GetString("grpc.listen.address") != "" {
+		a.Config.GRPCOptions.ListenAddress = a.v.GetString("grpc.listen.address")
+	}
+
+	if a.Config.GRPCOptions.ListenAddress == "" {
+		a.Config.GRPCOptions.ListenAddress = defaultGRPCListenAddress
+	}
 }
 
 func (a *App) apiServerJWTAuthParams() error {
@@ -154,68 +570,33 @@ func (a *App) apiServerJWTAuthParams() error {
 	return nil
 }
 
-// Server service configuration options
-
-// nolint:gocyclo // parameter validation is cyclomatic
-func (a *App) envVarServerserviceOverrides() error {
-	if a.v.GetString("serverservice.endpoint") != "" {
-		a.Config.ServerserviceOptions.Endpoint = a.v.GetString("serverservice.endpoint")
+// Server service configuration options, required parameters are checked by validate.
+func (a *App) envVarServerserviceOverrides() {
+	overrides := map[string]*string{
+		"serverservice.endpoint":                &a.Config.ServerserviceOptions.Endpoint,
+		"serverservice.facility.code":           &a.Config.ServerserviceOptions.FacilityCode,
+		"serverservice.oidc.issuer.endpoint":    &a.Config.ServerserviceOptions.OidcIssuerEndpoint,
+		"serverservice.oidc.audience.endpoint":  &a.Config.ServerserviceOptions.OidcAudienceEndpoint,
+		"serverservice.oidc.client.secret":      &a.Config.ServerserviceOptions.OidcClientSecret,
+		"serverservice.oidc.client.secret.file": &a.Config.ServerserviceOptions.OidcClientSecretFile,
+		"serverservice.oidc.client.id":          &a.Config.ServerserviceOptions.OidcClientID,
 	}
 
-	endpointURL, err := url.Parse(a.Config.ServerserviceOptions.Endpoint)
-	if err != nil {
-		return errors.New("serverservice endpoint URL error: " + err.Error())
+	for key, field := range overrides {
+		if a.v.GetString(key) != "" {
+			*field = a.v.GetString(key)
+		}
 	}
 
-	a.Config.ServerserviceOptions.EndpointURL = endpointURL
-
 	if a.v.GetString("serverservice.disable.oauth") != "" {
 		a.Config.ServerserviceOptions.DisableOAuth = a.v.GetBool("serverservice.disable.oauth")
 	}
 
-	if a.Config.ServerserviceOptions.DisableOAuth {
-		return nil
-	}
-
-	if a.v.GetString("serverservice.oidc.issuer.endpoint") != "" {
-		a.Config.ServerserviceOptions.OidcIssuerEndpoint = a.v.GetString("serverservice.oidc.issuer.endpoint")
-	}
-
-	if a.Config.ServerserviceOptions.OidcIssuerEndpoint == "" {
-		return errors.New("serverservice oidc.issuer.endpoint not defined")
-	}
-
-	if a.v.GetString("serverservice.oidc.audience.endpoint") != "" {
-		a.Config.ServerserviceOptions.OidcAudienceEndpoint = a.v.GetString("serverservice.oidc.audience.endpoint")
-	}
-
-	if a.Config.ServerserviceOptions.OidcAudienceEndpoint == "" {
-		return errors.New("serverservice oidc.audience.endpoint not defined")
-	}
-
-	if a.v.GetString("serverservice.oidc.client.secret") != "" {
-		a.Config.ServerserviceOptions.OidcClientSecret = a.v.GetString("serverservice.oidc.client.secret")
-	}
-
-	if a.Config.ServerserviceOptions.OidcClientSecret == "" {
-		return errors.New("serverservice oidc.client.secret not defined")
-	}
-
-	if a.v.GetString("serverservice.oidc.client.id") != "" {
-		a.Config.ServerserviceOptions.OidcClientID = a.v.GetString("serverservice.oidc.client.id")
-	}
-
-	if a.Config.ServerserviceOptions.OidcClientID == "" {
-		return errors.New("serverservice oidc.client.id not defined")
-	}
-
 	if a.v.GetString("serverservice.oidc.client.scopes") != "" {
 		a.Config.ServerserviceOptions.OidcClientScopes = a.v.GetStringSlice("serverservice.oidc.client.scopes")
 	}
 
-	if len(a.Config.ServerserviceOptions.OidcClientScopes) == 0 {
-		return errors.New("serverservice oidc.client.scopes not defined")
+	if endpointURL, err := url.Parse(a.Config.ServerserviceOptions.Endpoint); err == nil {
+		a.Config.ServerserviceOptions.EndpointURL = endpointURL
 	}
-
-	return nil
 }
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// reloadableFields are the Configuration fields applied on reload, changes to other fields require a restart.
+var reloadableFields = map[string]bool{
+	"LogLevel":         true,
+	"ParserOptions":    true,
+	"CacheOptions":     true,
+	"RateLimitOptions": true,
+	"APIKeys":          true,
+}
+
+// ReloadOptions defines the configuration file reload parameters.
+type ReloadOptions struct {
+	// Enabled watches the configuration file and applies changes to the log level,
+	// parser profiles, cache TTLs, rate limits and API keys without a restart.
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ReloadFunc applies the reloadable settings of a validated configuration.
+type ReloadFunc func(config *Configuration)
+
+// Reload reads and validates the configuration file, the current configuration is left unchanged.
+func (a *App) Reload() (*Configuration, error) {
+	reloaded := &App{
+		v:       viper.New(),
+		AppKind: a.AppKind,
+		Config:  &Configuration{file: a.Config.file},
+		Logger:  a.Logger,
+	}
+
+	if err := reloaded.LoadConfiguration(); err != nil {
+		return nil, err
+	}
+
+	return reloaded.Config, nil
+}
+
+// WatchConfig reloads the configuration file when it changes, applying the log level and
+// calling the reload funcs with each configuration that passes validation.
+func (a *App) WatchConfig(fns ...ReloadFunc) {
+	a.v.SetConfigFile(a.Config.file)
+	a.v.OnConfigChange(func(event fsnotify.Event) {
+		a.reload(event.Name, fns)
+	})
+	a.v.WatchConfig()
+}
+
+func (a *App) reload(file string, fns []ReloadFunc) {
+	logger := a.Logger.WithField("file", file)
+
+	config, err := a.Reload()
+	if err != nil {
+		metrics.ConfigReload(metrics.ConfigReloadFailure)
+		logger.WithError(err).Error("configuration reload rejected, keeping the current configuration")
+
+		return
+	}
+
+	if config.LogLevel != "" {
+		a.Logger.SetLevel(logrusLevel(model.LogLevel(config.LogLevel)))
+	}
+
+	for _, fn := range fns {
+		fn(config)
+	}
+
+	// changes are reported once, against the last applied configuration.
+	previous := a.applied
+	if previous == nil {
+		previous = a.Config
+	}
+
+	if changed := restartRequired(previous, config); len(changed) > 0 {
+		logger.WithField("settings", changed).Warn("configuration changes require a restart to apply")
+	}
+
+	a.applied = config
+
+	metrics.ConfigReload(metrics.ConfigReloadSuccess)
+	logger.Info("configuration reloaded")
+}
+
+// restartRequired returns the names of the changed configuration fields not applied on reload.
+func restartRequired(current, reloaded *Configuration) []string {
+	var changed []string
+
+	cv := reflect.ValueOf(current).Elem()
+	rv := reflect.ValueOf(reloaded).Elem()
+
+	for i := 0; i < cv.NumField(); i++ {
+		field := cv.Type().Field(i)
+		if !field.IsExported() || reloadableFields[field.Name] {
+			continue
+		}
+
+		if !reflect.DeepEqual(cv.Field(i).Interface(), rv.Field(i).Interface()) {
+			changed = append(changed, field.Name)
+		}
+	}
+
+	return changed
+}
+
+func logrusLevel(level model.LogLevel) logrus.Level {
+	switch level {
+	case model.LogLevelDebug:
+		return logrus.DebugLevel
+	case model.LogLevelTrace:
+		return logrus.TraceLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
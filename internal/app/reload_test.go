@@ -0,0 +1,229 @@
+package app
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+log_level: info
+listen_address: 0.0.0.0:9003
+serverservice:
+  endpoint: http://localhost:8000
+  disable_oauth: true
+parser:
+  profile: vendor
+  profiles:
+    vendor:
+      serial_num_column: SERIALNUM
+cache:
+  ttl: 5m
+`
+
+func newTestApp(t *testing.T, config string) *App {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	a := &App{v: viper.New(), Config: &Configuration{file: file}, Logger: logger}
+	if err := a.LoadConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+
+	return a
+}
+
+func TestReload(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics.Use(metrics.New(registry))
+
+	a := newTestApp(t, testConfig)
+
+	testcases := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			"reloadable changes",
+			strings.NewReplacer("info", "debug", "5m", "1m", "SERIALNUM", "SERIAL").Replace(testConfig),
+			"",
+		},
+		{
+			"unknown log level",
+			strings.Replace(testConfig, "info", "verbose", 1),
+			"log_level: unknown log level verbose",
+		},
+		{
+			"undefined parser profile",
+			strings.Replace(testConfig, "profile: vendor", "profile: other", 1),
+			"parser profile not defined: other",
+		},
+		{
+			"negative cache ttl",
+			strings.Replace(testConfig, "5m", "-5m", 1),
+			"cache.ttl: must not be negative",
+		},
+		{
+			"invalid yaml",
+			testConfig + "\n\t-",
+			"configuration error",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(a.Config.file, []byte(tc.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := a.Reload()
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "debug", config.LogLevel)
+			assert.Equal(t, time.Minute, config.CacheOptions.TTL)
+
+			mapping, err := config.ParserOptions.Mapping()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "SERIAL", mapping.SerialNumColumn)
+
+			// the current configuration is not changed.
+			assert.Equal(t, "info", a.Config.LogLevel)
+		})
+	}
+
+	// a rejected configuration keeps the current log level and is counted as a failure.
+	var applied int
+
+	a.reload(a.Config.file, []ReloadFunc{func(*Configuration) { applied++ }})
+
+	assert.Equal(t, 0, applied)
+	assert.Equal(t, logrus.InfoLevel, a.Logger.GetLevel())
+	assert.Equal(t, 1.0, reloads(t, registry, metrics.ConfigReloadFailure))
+}
+
+func TestWatchConfig(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics.Use(metrics.New(registry))
+
+	a := newTestApp(t, testConfig)
+
+	reloaded := make(chan *Configuration, 10)
+	a.WatchConfig(func(config *Configuration) { reloaded <- config })
+
+	changed := strings.NewReplacer("info", "trace", "9003", "9004").Replace(testConfig)
+	if err := os.WriteFile(a.Config.file, []byte(changed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case config := <-reloaded:
+		assert.Equal(t, "trace", config.LogLevel)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration change not reloaded")
+	}
+
+	assert.Eventually(t, func() bool {
+		return a.Logger.GetLevel() == logrus.TraceLevel &&
+			reloads(t, registry, metrics.ConfigReloadSuccess) >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestReloadRestartRequired(t *testing.T) {
+	metrics.Use(metrics.New(prometheus.NewRegistry()))
+
+	a := newTestApp(t, testConfig)
+	hook := test.NewLocal(a.Logger)
+
+	warnings := func() int {
+		n := 0
+
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == "configuration changes require a restart to apply" {
+				n++
+			}
+		}
+
+		hook.Reset()
+
+		return n
+	}
+
+	changed := strings.Replace(testConfig, "9003", "9004", 1)
+	if err := os.WriteFile(a.Config.file, []byte(changed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a.reload(a.Config.file, nil)
+	assert.Equal(t, 1, warnings())
+
+	// the same change is not reported again.
+	a.reload(a.Config.file, nil)
+	assert.Equal(t, 0, warnings())
+
+	// reverting it is a change from the last applied configuration.
+	if err := os.WriteFile(a.Config.file, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a.reload(a.Config.file, nil)
+	assert.Equal(t, 1, warnings())
+}
+
+func TestRestartRequired(t *testing.T) {
+	current := &Configuration{LogLevel: "info", ListenAddress: ":9003"}
+	reloaded := &Configuration{LogLevel: "debug", ListenAddress: ":9004", CacheOptions: CacheOptions{TTL: time.Minute}}
+
+	assert.Equal(t, []string{"ListenAddress"}, restartRequired(current, reloaded))
+}
+
+// reloads returns the config reloads counter value with the result.
+func reloads(t *testing.T, registry *prometheus.Registry, result string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "bomservice_config_reloads_total" {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			if m.GetLabel()[0].GetValue() == result {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,390 @@
+package app
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// redacted replaces URLs that cannot be parsed in problem messages, they may hold a password.
+const redacted = "[REDACTED]"
+
+var errNotDefined = errors.New("not defined")
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return ErrConfig.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Unwrap returns ErrConfig.
+func (e *ValidationError) Unwrap() error {
+	return ErrConfig
+}
+
+// secret is a configuration secret and the path of the file it can be read from instead.
+type secret struct {
+	key   string
+	value *string
+	file  *string
+}
+
+// secrets returns the configuration secrets, each has a _file variant for secrets mounted as files.
+func (c *Configuration) secrets() []secret {
+	return []secret{
+		{
+			key:   "serverservice.oidc_client_secret",
+			value: &c.ServerserviceOptions.OidcClientSecret,
+			file:  &c.ServerserviceOptions.OidcClientSecretFile,
+		},
+	}
+}
+
+// readSecretFiles sets the secrets configured as files from the file contents, returning the problems found.
+func (c *Configuration) readSecretFiles() []string {
+	var problems []string
+
+	for _, s := range c.secrets() {
+		if *s.file == "" {
+			continue
+		}
+
+		if *s.value != "" {
+			problems = append(problems, s.key+": set along with "+s.key+"_file, only one may be set")
+			continue
+		}
+
+		data, err := os.ReadFile(*s.file)
+		if err != nil {
+			problems = append(problems, s.key+"_file: "+err.Error())
+			continue
+		}
+
+		*s.value = strings.TrimSpace(string(data))
+		if *s.value == "" {
+			problems = append(problems, s.key+"_file: file is empty")
+		}
+	}
+
+	return problems
+}
+
+// Check loads the configuration file, returning it along with a ValidationError listing every problem found,
+// problems do not include secret values. The configuration is nil when the file cannot be read or decoded.
+func Check(appKind model.AppKind, cfgFile string) (*Configuration, error) {
+	a := &App{
+		v:       viper.New(),
+		AppKind: appKind,
+		Config:  &Configuration{file: cfgFile},
+	}
+
+	err := a.LoadConfiguration()
+	if _, ok := err.(*ValidationError); err != nil && !ok {
+		return nil, err
+	}
+
+	return a.Config, err
+}
+
+// validate returns the problems found in the configuration.
+//
+// nolint:gocyclo // parameter validation is cyclomatic
+func (c *Configuration) validate() []string {
+	var problems []string
+
+	add := func(key, problem string) {
+		problems = append(problems, key+": "+problem)
+	}
+
+	switch model.LogLevel(c.LogLevel) {
+	case "", model.LogLevelInfo, model.LogLevelDebug, model.LogLevelTrace:
+	default:
+		add("log_level", "unknown log level "+c.LogLevel)
+	}
+
+	if c.ListenAddress == "" {
+		add("listen_address", "not defined")
+	} else if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		add("listen_address", err.Error())
+	}
+
+	metricsListenAddress := metrics.DefaultListenAddress
+	if c.MetricsListenAddress != "" {
+		metricsListenAddress = c.MetricsListenAddress
+
+		if _, _, err := net.SplitHostPort(c.MetricsListenAddress); err != nil {
+			add("metrics_listen_address", err.Error())
+		}
+	}
+
+	if c.GRPCOptions.Enabled {
+		if _, _, err := net.SplitHostPort(c.GRPCOptions.ListenAddress); err != nil {
+			add("grpc.listen_address", err.Error())
+		} else if c.GRPCOptions.ListenAddress == c.ListenAddress {
+			add("grpc.listen_address", "must differ from listen_address")
+		} else if c.GRPCOptions.ListenAddress == metricsListenAddress {
+			add("grpc.listen_address", "must differ from metrics_listen_address")
+		}
+	}
+
+	if c.StoreKind != model.StoreKindServerservice {
+		add("store_kind", "unsupported store kind "+c.StoreKind)
+	}
+
+	problems = append(problems, c.ServerserviceOptions.validate()...)
+
+	if c.EnrollOptions.Enabled && c.ServerserviceOptions.FacilityCode == "" {
+		add("serverservice.facility_code", "required to enroll servers")
+	}
+
+	if c.APIServerJWTAuth != nil && c.APIServerJWTAuth.Enabled {
+		auth := c.APIServerJWTAuth
+
+		if auth.Audience == "" {
+			add("ginjwt_auth.audience", "not defined")
+		}
+
+		if auth.Issuer == "" {
+			add("ginjwt_auth.issuer", "not defined")
+		}
+
+		if err := validateURL(auth.JWKSURI); err != nil {
+			add("ginjwt_auth.jwksuri", err.Error())
+		}
+	}
+
+	if _, err := c.ParserOptions.Mapping(); err != nil {
+		add("parser.profile", err.Error())
+	}
+
+	profiles := make([]string, 0, len(c.ParserOptions.Profiles))
+	for name := range c.ParserOptions.Profiles {
+		profiles = append(profiles, name)
+	}
+
+	sort.Strings(profiles)
+
+	for _, name := range profiles {
+		if err := c.ParserOptions.Profiles[name].WithDefaults().Validate(); err != nil {
+			add("parser.profiles."+name, err.Error())
+		}
+	}
+
+	if _, err := c.ExportOptions.Exporter(); err != nil {
+		add("export.pools", err.Error())
+	}
+
+	// zero durations are set to their defaults on load.
+	durations := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"reconcile.interval", c.ReconcileOptions.Interval},
+		{"index.interval", c.IndexOptions.Interval},
+		{"cache.ttl", c.CacheOptions.TTL},
+		{"cache.negative_ttl", c.CacheOptions.NegativeTTL},
+		{"rate_limit.upload_retry_after", c.RateLimitOptions.UploadRetryAfter},
+	}
+
+	for _, d := range durations {
+		if d.value < 0 {
+			add(d.key, "must not be negative")
+		}
+	}
+
+	if c.CacheOptions.Size < 0 {
+		add("cache.size", "must not be negative")
+	}
+
+	if c.RateLimitOptions.RequestsPerSecond < 0 {
+		add("rate_limit.requests_per_second", "must not be negative")
+	}
+
+	if c.RateLimitOptions.Burst < 0 {
+		add("rate_limit.burst", "must not be negative")
+	}
+
+	if c.RateLimitOptions.UploadConcurrency < 0 {
+		add("rate_limit.upload_concurrency", "must not be negative")
+	}
+
+	if c.LookupOptions.Workers < 0 {
+		add("lookup.workers", "must not be negative")
+	}
+
+	if c.IndexOptions.Events.URL != "" {
+		if !c.IndexOptions.Enabled {
+			add("index.events.url", "requires index.enabled")
+		}
+
+		if len(c.IndexOptions.Events.SubscribeSubjects) == 0 {
+			add("index.events.subscribe_subjects", "not defined")
+		}
+	}
+
+	if c.TracingOptions.Enabled {
+		switch c.TracingOptions.Exporter {
+		case "otlp", "stdout":
+		default:
+			add("tracing.exporter", "unknown exporter "+c.TracingOptions.Exporter)
+		}
+	}
+
+	problems = append(problems, c.TLSOptions.validate()...)
+
+	ids := map[string]struct{}{}
+	hashes := map[string]struct{}{}
+
+	for i := range c.APIKeys {
+		key := &c.APIKeys[i]
+		if err := key.Validate(); err != nil {
+			add("api_keys."+strconv.Itoa(i), err.Error())
+			continue
+		}
+
+		if _, ok := ids[key.ID]; ok {
+			add("api_keys."+strconv.Itoa(i), "duplicate id "+key.ID)
+		}
+
+		if _, ok := hashes[strings.ToLower(key.Hash)]; ok {
+			add("api_keys."+strconv.Itoa(i), "duplicate hash for id "+key.ID)
+		}
+
+		ids[key.ID] = struct{}{}
+		hashes[strings.ToLower(key.Hash)] = struct{}{}
+	}
+
+	if c.TracingOptions.SampleRatio < 0 || c.TracingOptions.SampleRatio > 1 {
+		add("tracing.sample_ratio", "must be between 0 and 1, got "+strconv.FormatFloat(c.TracingOptions.SampleRatio, 'f', -1, 64))
+	}
+
+	return problems
+}
+
+// validate returns the problems found in the serverservice client configuration.
+func (s *ServerserviceOptions) validate() []string {
+	var problems []string
+
+	add := func(key, problem string) {
+		problems = append(problems, "serverservice."+key+": "+problem)
+	}
+
+	if err := validateURL(s.Endpoint); err != nil {
+		add("endpoint", err.Error())
+	}
+
+	if s.DisableOAuth {
+		return problems
+	}
+
+	if err := validateURL(s.OidcIssuerEndpoint); err != nil {
+		add("oidc_issuer_endpoint", err.Error())
+	}
+
+	required := []struct {
+		key   string
+		value string
+	}{
+		{"oidc_audience_endpoint", s.OidcAudienceEndpoint},
+		{"oidc_client_secret", s.OidcClientSecret},
+		{"oidc_client_id", s.OidcClientID},
+	}
+
+	for _, r := range required {
+		if r.value == "" {
+			add(r.key, "not defined")
+		}
+	}
+
+	if len(s.OidcClientScopes) == 0 {
+		add("oidc_client_scopes", "not defined")
+	}
+
+	return problems
+}
+
+// validate returns the problems found in the API listener TLS configuration.
+func (t *TLSOptions) validate() []string {
+	if !t.Enabled {
+		return nil
+	}
+
+	var problems []string
+
+	add := func(key, problem string) {
+		problems = append(problems, "tls."+key+": "+problem)
+	}
+
+	if t.CertFile == "" {
+		add("cert_file", "not defined")
+	}
+
+	if t.KeyFile == "" {
+		add("key_file", "not defined")
+	}
+
+	switch t.ClientAuth {
+	case ClientAuthNone:
+		if len(t.ClientScopes) > 0 {
+			add("client_scopes", "client certificates are not requested with client_auth none")
+		}
+	case ClientAuthOptional, ClientAuthRequire:
+		if t.ClientCAFile == "" {
+			add("client_ca_file", "required to verify client certificates")
+		}
+	default:
+		add("client_auth", "unknown client auth "+t.ClientAuth)
+	}
+
+	for i, cs := range t.ClientScopes {
+		if cs.Subject == "" || len(cs.Scopes) == 0 {
+			add("client_scopes."+strconv.Itoa(i), "subject and scopes required")
+		}
+	}
+
+	return problems
+}
+
+// validateURL checks the value is an absolute http or https URL, the error does not include URL passwords.
+func validateURL(value string) error {
+	if value == "" {
+		return errNotDefined
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.New("invalid URL " + redactURL(value))
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("not an http or https URL " + u.Redacted())
+	}
+
+	return nil
+}
+
+// redactURL replaces the password in the URL, values that cannot be parsed are replaced entirely.
+func redactURL(value string) string {
+	if value == "" {
+		return value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return redacted
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MethodAPIKey identifies principals authenticated by an API key.
+	MethodAPIKey = "api-key"
+
+	// APIKeyHeader is the request header carrying an API key.
+	APIKeyHeader = "X-API-Key"
+
+	// apiKeyPrefix marks generated keys so they are recognizable to secret scanners.
+	apiKeyPrefix = "bsk_"
+
+	// hashPrefix is the API key hash algorithm prefix, keys are random so a fast hash is sufficient.
+	hashPrefix = "sha256:"
+)
+
+var (
+	ErrAPIKey = errors.New("invalid api key configuration")
+)
+
+// APIKey is a hashed API key and the scopes it grants.
+type APIKey struct {
+	// ID names the key in logs and metrics.
+	ID string `mapstructure:"id"`
+	// Hash is the sha256:<hex> hash of the key, as printed by the apikey create command.
+	Hash   string   `mapstructure:"hash"`
+	Scopes []string `mapstructure:"scopes"`
+}
+
+// Validate checks the key has an ID, a well formed hash and scopes.
+func (k *APIKey) Validate() error {
+	if k.ID == "" {
+		return errors.Wrap(ErrAPIKey, "id not defined")
+	}
+
+	digest, ok := strings.CutPrefix(k.Hash, hashPrefix)
+	if !ok {
+		return errors.Wrap(ErrAPIKey, "hash of key "+k.ID+" is not prefixed with "+hashPrefix)
+	}
+
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+		return errors.Wrap(ErrAPIKey, "hash of key "+k.ID+" is not a hex encoded sha256 digest")
+	}
+
+	if len(k.Scopes) == 0 {
+		return errors.Wrap(ErrAPIKey, "no scopes granted to key "+k.ID)
+	}
+
+	return nil
+}
+
+// GenerateAPIKey returns a new random API key and its hash.
+func GenerateAPIKey() (key, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash of the API key as set in the configuration.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator grants scopes to requests carrying a configured API key in the X-API-Key header.
+type APIKeyAuthenticator struct {
+	// keys are keyed by hash.
+	keys atomic.Pointer[map[string]APIKey]
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+}
+
+// NewAPIKeyAuthenticator returns an authenticator for the keys.
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{lastUsed: map[string]time.Time{}}
+	if err := a.SetKeys(keys); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// SetKeys replaces the accepted keys, the current keys are kept when one is invalid.
+func (a *APIKeyAuthenticator) SetKeys(keys []APIKey) error {
+	byHash := make(map[string]APIKey, len(keys))
+
+	for i := range keys {
+		if err := keys[i].Validate(); err != nil {
+			return err
+		}
+
+		byHash[strings.ToLower(keys[i].Hash)] = keys[i]
+	}
+
+	a.keys.Store(&byHash)
+
+	return nil
+}
+
+// Authenticate returns the principal for the request API key.
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+
+	found, ok := (*a.keys.Load())[HashAPIKey(key)]
+	if !ok {
+		return nil, errors.Wrap(ErrUnauthorized, "invalid api key")
+	}
+
+	a.mu.Lock()
+	a.lastUsed[found.ID] = time.Now()
+	a.mu.Unlock()
+
+	metrics.APIKeyUsed(found.ID)
+
+	return &Principal{Subject: found.ID, Scopes: found.Scopes, Method: MethodAPIKey}, nil
+}
+
+// LastUsed returns the time the key with the ID last authenticated a request since startup.
+func (a *APIKeyAuthenticator) LastUsed(id string) (time.Time, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	t, ok := a.lastUsed[id]
+
+	return t, ok
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics.Use(metrics.New(registry))
+
+	key, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := NewAPIKeyAuthenticator([]APIKey{{ID: "automation", Hash: hash, Scopes: []string{"read"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(key string) *http.Request {
+		r := &http.Request{Header: http.Header{}}
+		if key != "" {
+			r.Header.Set(APIKeyHeader, key)
+		}
+
+		return r
+	}
+
+	principal, err := authenticator.Authenticate(request(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &Principal{Subject: "automation", Scopes: []string{"read"}, Method: MethodAPIKey}, principal)
+
+	_, used := authenticator.LastUsed("automation")
+	assert.True(t, used)
+	assert.Equal(t, 1, testutil.CollectAndCount(registry, "bomservice_api_key_requests_total"))
+
+	principal, err = authenticator.Authenticate(request(""))
+	assert.Nil(t, err)
+	assert.Nil(t, principal)
+
+	_, err = authenticator.Authenticate(request(key + "x"))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// invalid keys are rejected and the current keys kept.
+	err = authenticator.SetKeys([]APIKey{{ID: "other", Hash: "md5:abc", Scopes: []string{"read"}}})
+	assert.ErrorIs(t, err, ErrAPIKey)
+
+	_, err = authenticator.Authenticate(request(key))
+	assert.Nil(t, err)
+
+	// revoked keys are no longer accepted.
+	if err := authenticator.SetKeys(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = authenticator.Authenticate(request(key))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestAPIKeyValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		key     APIKey
+		wantErr string
+	}{
+		{"valid", APIKey{ID: "a", Hash: HashAPIKey("key"), Scopes: []string{"read"}}, ""},
+		{"no id", APIKey{Hash: HashAPIKey("key"), Scopes: []string{"read"}}, "id not defined"},
+		{"unprefixed hash", APIKey{ID: "a", Hash: "abc", Scopes: []string{"read"}}, "not prefixed with sha256:"},
+		{"short hash", APIKey{ID: "a", Hash: "sha256:abc", Scopes: []string{"read"}}, "not a hex encoded sha256 digest"},
+		{"no scopes", APIKey{ID: "a", Hash: HashAPIKey("key")}, "no scopes granted to key a"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.key.Validate()
+			if tc.wantErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/rivets/v2/ginauth"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ContextKeySubject, ContextKeyUser and ContextKeyRoles match the ginjwt context keys,
+	// so the scopes of principals authenticated without a JWT are verified by ginjwt RequiredScopes.
+	ContextKeySubject = "jwt.subject"
+	ContextKeyUser    = "jwt.user"
+	ContextKeyRoles   = "jwt.roles"
+
+	// MethodClientCert identifies principals authenticated by a TLS client certificate.
+	MethodClientCert = "client-certificate"
+)
+
+var (
+	ErrUnauthorized = errors.New("not authorized")
+)
+
+// Principal is an API client authenticated without a JWT.
+type Principal struct {
+	// Subject identifies the client, the certificate subject for client certificates.
+	Subject string
+	// Scopes are the scopes granted to the client.
+	Scopes []string
+	// Method is the authentication method.
+	Method string
+}
+
+// Authenticator authenticates API requests from credentials other than a JWT.
+type Authenticator interface {
+	// Authenticate returns the principal for the request credentials, or nil when the request carries none.
+	// An error is returned for credentials that are not accepted.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// SetPrincipal sets the principal on the request context for scope verification.
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(ContextKeySubject, p.Subject)
+	c.Set(ContextKeyUser, p.Method+":"+p.Subject)
+	c.Set(ContextKeyRoles, p.Scopes)
+}
+
+// RequiredScopes aborts requests whose principal has none of the scopes, for use when no JWT middleware is configured.
+func RequiredScopes(scopes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasAnyScope(c.GetStringSlice(ContextKeyRoles), scopes) {
+			ginauth.AbortBecauseOfError(c, ginauth.NewAuthorizationError("not authorized, missing required scope"))
+		}
+	}
+}
+
+// HasAnyScope returns true when one of the granted scopes is one of the required scopes, as ginjwt matches them.
+func HasAnyScope(granted, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+
+	for _, g := range granted {
+		if slices.Contains(required, g) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ClientCertAuthenticator grants scopes to clients presenting a verified TLS client certificate,
+// by the certificate subject.
+type ClientCertAuthenticator struct {
+	scopes map[string][]string
+}
+
+// NewClientCertAuthenticator returns an authenticator granting the scopes keyed by certificate subject,
+// subjects are in the RFC 2253 form, such as CN=automation,O=Example.
+func NewClientCertAuthenticator(scopes map[string][]string) *ClientCertAuthenticator {
+	return &ClientCertAuthenticator{scopes: scopes}
+}
+
+// Authenticate returns the principal for the verified client certificate, certificates with a subject not
+// granted any scopes are not accepted.
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	subject := r.TLS.VerifiedChains[0][0].Subject.String()
+
+	scopes, ok := a.scopes[subject]
+	if !ok {
+		return nil, errors.Wrap(ErrUnauthorized, "client certificate subject "+subject)
+	}
+
+	return &Principal{Subject: subject, Scopes: scopes, Method: MethodClientCert}, nil
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCertAuthenticator(t *testing.T) {
+	authenticator := NewClientCertAuthenticator(map[string][]string{
+		"CN=automation,O=Example": {"read", "create:upload-xlsx-file"},
+	})
+
+	request := func(cn string) *http.Request {
+		r := &http.Request{}
+		if cn == "" {
+			return r
+		}
+
+		leaf := &x509.Certificate{Subject: pkix.Name{CommonName: cn, Organization: []string{"Example"}}}
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+
+		return r
+	}
+
+	principal, err := authenticator.Authenticate(request("automation"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &Principal{
+		Subject: "CN=automation,O=Example",
+		Scopes:  []string{"read", "create:upload-xlsx-file"},
+		Method:  MethodClientCert,
+	}, principal)
+
+	// requests without a verified certificate carry no credentials.
+	principal, err = authenticator.Authenticate(request(""))
+	assert.Nil(t, err)
+	assert.Nil(t, principal)
+
+	_, err = authenticator.Authenticate(request("other"))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+// metroScopeInfix separates the action and the metro in a metro scope.
+const metroScopeInfix = ":bom:metro:"
+
+// MetroScope returns the scope granting the action on the BOMs tagged with the metro, e.g. read:bom:metro:dc13.
+func MetroScope(action, metro string) string {
+	return action + metroScopeInfix + metro
+}
+
+// ScopedMetros returns the sorted metros the granted scopes allow one of the actions on.
+func ScopedMetros(granted []string, actions ...string) []string {
+	var metros []string
+
+	for _, scope := range granted {
+		action, metro, ok := strings.Cut(scope, metroScopeInfix)
+		if !ok || metro == "" || !slices.Contains(actions, action) {
+			continue
+		}
+
+		if !slices.Contains(metros, metro) {
+			metros = append(metros, metro)
+		}
+	}
+
+	slices.Sort(metros)
+
+	return metros
+}
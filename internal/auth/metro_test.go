@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopedMetros(t *testing.T) {
+	granted := []string{
+		"read",
+		MetroScope("read", "dc13"),
+		MetroScope("read", "am6"),
+		MetroScope("write", "dc13"),
+		MetroScope("create", "sv15"),
+		MetroScope("read", ""),
+		"read:bom:facility:dc13",
+	}
+
+	assert.Equal(t, []string{"am6", "dc13"}, ScopedMetros(granted, "read"))
+	assert.Equal(t, []string{"dc13", "sv15"}, ScopedMetros(granted, "write", "create"))
+	assert.Nil(t, ScopedMetros(granted, "delete"))
+	assert.Nil(t, ScopedMetros(nil, "read"))
+}
@@ -0,0 +1,162 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrCertificate = errors.New("tls certificate error")
+)
+
+// Reloader holds the server certificate and client CA pool read from the configured files,
+// Run reloads them when the files change, keeping the current ones when the new files are invalid.
+type Reloader struct {
+	options *app.TLSOptions
+	logger  *logrus.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// NewReloader returns a Reloader with the certificate and client CA pool loaded.
+func NewReloader(options *app.TLSOptions, logger *logrus.Logger) (*Reloader, error) {
+	r := &Reloader{options: options, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload reads the certificate, key and client CA files.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.options.CertFile, r.options.KeyFile)
+	if err != nil {
+		return errors.Wrap(ErrCertificate, err.Error())
+	}
+
+	var clientCAs *x509.CertPool
+
+	if r.options.ClientCAFile != "" {
+		data, err := os.ReadFile(r.options.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(ErrCertificate, err.Error())
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return errors.Wrap(ErrCertificate, "no certificates in client CA file "+r.options.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.mu.Unlock()
+
+	return nil
+}
+
+// TLSConfig returns a server TLS configuration using the current certificate and client CA pool on each handshake.
+func (r *Reloader) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+
+	clientAuth := clientAuthType(r.options.ClientAuth)
+	if clientAuth == tls.NoClientCert {
+		return config
+	}
+
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		return &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: r.getCertificate,
+			ClientAuth:     clientAuth,
+			ClientCAs:      r.clientCAs,
+			NextProtos:     []string{"h2", "http/1.1"},
+		}, nil
+	}
+
+	return config
+}
+
+// Run reloads the files when they change until the context is canceled.
+//
+// The directories holding the files are watched, so files replaced by a symlink swap,
+// as kubernetes updates mounted secrets, are reloaded.
+func (r *Reloader) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(ErrCertificate, err.Error())
+	}
+
+	defer watcher.Close()
+
+	dirs := map[string]struct{}{}
+
+	for _, file := range []string{r.options.CertFile, r.options.KeyFile, r.options.ClientCAFile} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = struct{}{}
+		}
+	}
+
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return errors.Wrap(ErrCertificate, err.Error())
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-watcher.Errors:
+			r.logger.WithError(err).Warn("tls certificate watch error")
+		case event := <-watcher.Events:
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+
+			if err := r.Reload(); err != nil {
+				r.logger.WithError(err).WithField("file", event.Name).Warn("tls certificate reload failed, keeping the current certificate")
+				continue
+			}
+
+			r.logger.WithField("file", event.Name).Info("tls certificate reloaded")
+		}
+	}
+}
+
+func (r *Reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+func clientAuthType(clientAuth string) tls.ClientAuthType {
+	switch clientAuth {
+	case app.ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	case app.ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
@@ -0,0 +1,204 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCert returns a certificate signed by the parent, self-signed when parent is nil.
+func newTestCert(t *testing.T, serial int64, cn string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Example"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(c.pem, c.keyPEM(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func writeFile(t *testing.T, file string, data []byte) {
+	t.Helper()
+
+	// written and renamed so the watcher never reads a partial file.
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, 1, "ca", nil)
+	serverCert := newTestCert(t, 2, "localhost", ca)
+	clientCert := newTestCert(t, 3, "automation", ca)
+
+	options := &app.TLSOptions{
+		Enabled:      true,
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:   app.ClientAuthRequire,
+	}
+
+	writeFile(t, options.CertFile, serverCert.pem)
+	writeFile(t, options.KeyFile, serverCert.keyPEM(t))
+	writeFile(t, options.ClientCAFile, ca.pem)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	reloader, err := NewReloader(options, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = reloader.Run(ctx)
+	}()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.String())
+	}))
+	srv.TLS = reloader.TLSConfig()
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	// get returns the client certificate subject seen by the server and the server certificate serial number.
+	get := func(certs ...tls.Certificate) (string, int64, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12},
+		}}
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return "", 0, err
+		}
+
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return string(body), resp.TLS.PeerCertificates[0].SerialNumber.Int64(), nil
+	}
+
+	subject, serial, err := get(clientCert.tlsCertificate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CN=automation,O=Example", subject)
+	assert.Equal(t, int64(2), serial)
+
+	// client certificates are required.
+	_, _, err = get()
+	assert.NotNil(t, err)
+
+	// an invalid certificate file is not loaded.
+	writeFile(t, options.CertFile, []byte("invalid"))
+	assert.NotNil(t, reloader.Reload())
+
+	_, serial, err = get(clientCert.tlsCertificate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, int64(2), serial)
+
+	// a rotated certificate is served once the files change.
+	rotated := newTestCert(t, 4, "localhost", ca)
+	writeFile(t, options.KeyFile, rotated.keyPEM(t))
+	writeFile(t, options.CertFile, rotated.pem)
+
+	assert.Eventually(t, func() bool {
+		_, serial, err := get(clientCert.tlsCertificate(t))
+		return err == nil && serial == 4
+	}, 5*time.Second, 20*time.Millisecond)
+}
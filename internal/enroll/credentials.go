@@ -0,0 +1,208 @@
+package enroll
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CredentialAttributesNamespace is the fleetdb server attribute namespace recording
+	// whether the BMC credential is still the factory default from the BOM.
+	CredentialAttributesNamespace = "sh.hollow.bomservice.bmc_credential"
+)
+
+var (
+	ErrCredential = errors.New("bmc credential error")
+)
+
+// CredentialFleetDB is the subset of the fleetdb client the credential pusher reads and writes
+// server credentials and attributes with.
+type CredentialFleetDB interface {
+	inventory.ServerLister
+	GetCredential(ctx context.Context, srvUUID uuid.UUID, secretSlug string) (*fleetdbapi.ServerCredential, *fleetdbapi.ServerResponse, error)
+	SetCredential(ctx context.Context, srvUUID uuid.UUID, secretSlug, username, password string) (*fleetdbapi.ServerResponse, error)
+	CreateAttributes(ctx context.Context, srvUUID uuid.UUID, attr fleetdbapi.Attributes) (*fleetdbapi.ServerResponse, error)
+	UpdateAttributes(ctx context.Context, srvUUID uuid.UUID, ns string, data json.RawMessage) (*fleetdbapi.ServerResponse, error)
+}
+
+// Credential is the BMC credential state of a fleetdb server record.
+type Credential struct {
+	SerialNum      string `json:"serial_num"`
+	ServerUUID     string `json:"server_uuid,omitempty"`
+	FactoryDefault bool   `json:"factory_default"`
+	Error          string `json:"error,omitempty"`
+	// AttributeError is set when the credential is checked or written but the factory default
+	// attribute could not be recorded.
+	AttributeError string `json:"attribute_error,omitempty"`
+}
+
+// CredentialResult lists the outcome of pushing the BMC credential for each BOM serial number.
+type CredentialResult struct {
+	// Written are the servers the BOM default credential was written to.
+	Written []Credential `json:"written"`
+	// Existing are the servers that already had a BMC credential, which is left as is.
+	Existing []Credential `json:"existing"`
+	// NotEnrolled are the serial numbers without a fleetdb server record yet.
+	NotEnrolled []Credential `json:"not_enrolled"`
+	// Failed are the servers the credential could not be checked or written for.
+	Failed []Credential `json:"failed"`
+}
+
+// NewCredentialResult returns an empty CredentialResult.
+func NewCredentialResult() *CredentialResult {
+	return &CredentialResult{
+		Written:     []Credential{},
+		Existing:    []Credential{},
+		NotEnrolled: []Credential{},
+		Failed:      []Credential{},
+	}
+}
+
+// Add records the outcome of a PushServer call.
+func (r *CredentialResult) Add(cred Credential, written bool, err error) {
+	switch {
+	case err != nil:
+		cred.Error = err.Error()
+		r.Failed = append(r.Failed, cred)
+	case written:
+		r.Written = append(r.Written, cred)
+	default:
+		r.Existing = append(r.Existing, cred)
+	}
+}
+
+// FactoryDefault returns the servers still using the factory default password from the BOM.
+func (r *CredentialResult) FactoryDefault() []Credential {
+	var creds []Credential
+
+	for _, list := range [][]Credential{r.Written, r.Existing} {
+		for _, cred := range list {
+			if cred.FactoryDefault {
+				creds = append(creds, cred)
+			}
+		}
+	}
+
+	return creds
+}
+
+// CredentialPusher writes the BOM default IPMI user and password as the fleetdb server BMC credential.
+type CredentialPusher struct {
+	fleetdb CredentialFleetDB
+	logger  *logrus.Logger
+}
+
+// NewCredentialPusher returns a CredentialPusher that writes credentials to the given fleetdb client.
+func NewCredentialPusher(fleetdb CredentialFleetDB, logger *logrus.Logger) *CredentialPusher {
+	return &CredentialPusher{
+		fleetdb: fleetdb,
+		logger:  logger,
+	}
+}
+
+// Push writes the BOM default credential for each enrolled server that has no BMC credential, see PushServer.
+func (p *CredentialPusher) Push(ctx context.Context, boms []fleetdbapi.Bom) *CredentialResult {
+	result := NewCredentialResult()
+
+	for i := range boms {
+		bom := &boms[i]
+
+		// nothing to push without a default password.
+		if bom.NumDefPWD == "" {
+			continue
+		}
+
+		server, err := inventory.ServerBySerial(ctx, p.fleetdb, bom.SerialNum)
+		if err != nil {
+			result.Failed = append(result.Failed, Credential{SerialNum: bom.SerialNum, Error: err.Error()})
+			continue
+		}
+
+		if server == nil {
+			result.NotEnrolled = append(result.NotEnrolled, Credential{SerialNum: bom.SerialNum})
+			continue
+		}
+
+		result.Add(p.PushServer(ctx, server.UUID, bom))
+	}
+
+	p.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"written":         len(result.Written),
+		"existing":        len(result.Existing),
+		"not_enrolled":    len(result.NotEnrolled),
+		"failed":          len(result.Failed),
+		"factory_default": len(result.FactoryDefault()),
+	}).Info("bmc credential push completed")
+
+	return result
+}
+
+// PushServer writes the BOM default credential to the enrolled server when it has no BMC credential,
+// returning true if it was written.
+//
+// Existing credentials are never overwritten, they are compared with the BOM default password
+// to record whether the server still uses it in the CredentialAttributesNamespace attribute.
+// Failing to record the attribute sets the credential AttributeError, not the returned error.
+func (p *CredentialPusher) PushServer(ctx context.Context, id uuid.UUID, bom *fleetdbapi.Bom) (Credential, bool, error) {
+	cred := Credential{SerialNum: bom.SerialNum, ServerUUID: id.String()}
+
+	var written bool
+
+	existing, _, err := p.fleetdb.GetCredential(ctx, id, fleetdbapi.ServerCredentialTypeBMC)
+
+	switch {
+	case err == nil:
+		cred.FactoryDefault = existing.Password == bom.NumDefPWD
+	case inventory.IsNotFound(err):
+		if _, err := p.fleetdb.SetCredential(ctx, id, fleetdbapi.ServerCredentialTypeBMC, bom.NumDefiPmi, bom.NumDefPWD); err != nil {
+			err = errors.Wrap(ErrCredential, err.Error())
+			p.logger.WithContext(ctx).WithError(err).WithField("serial", bom.SerialNum).Warn("failed to push bmc credential")
+
+			return cred, false, err
+		}
+
+		written = true
+		cred.FactoryDefault = true
+	default:
+		err = errors.Wrap(ErrCredential, err.Error())
+		p.logger.WithContext(ctx).WithError(err).WithField("serial", bom.SerialNum).Warn("failed to push bmc credential")
+
+		return cred, false, err
+	}
+
+	if err := p.recordFactoryDefault(ctx, id, cred.FactoryDefault); err != nil {
+		p.logger.WithContext(ctx).WithError(err).WithField("serial", bom.SerialNum).Warn("failed to record bmc credential factory default")
+
+		cred.AttributeError = err.Error()
+	}
+
+	return cred, written, nil
+}
+
+func (p *CredentialPusher) recordFactoryDefault(ctx context.Context, id uuid.UUID, factoryDefault bool) error {
+	data, err := json.Marshal(map[string]bool{"factory_default": factoryDefault})
+	if err != nil {
+		return errors.Wrap(ErrCredential, err.Error())
+	}
+
+	_, err = p.fleetdb.UpdateAttributes(ctx, id, CredentialAttributesNamespace, data)
+	if err == nil {
+		return nil
+	}
+
+	if !inventory.IsNotFound(err) {
+		return errors.Wrap(ErrCredential, err.Error())
+	}
+
+	if _, err := p.fleetdb.CreateAttributes(ctx, id, fleetdbapi.Attributes{Namespace: CredentialAttributesNamespace, Data: data}); err != nil {
+		return errors.Wrap(ErrCredential, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package enroll creates fleetdb server records for the serial numbers in uploaded BOMs.
+package enroll
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrEnroll = errors.New("enroll error")
+)
+
+// FleetDB is the subset of the fleetdb client the enroller creates server records with.
+type FleetDB interface {
+	inventory.ServerLister
+	Create(ctx context.Context, srv fleetdbapi.Server) (*uuid.UUID, *fleetdbapi.ServerResponse, error)
+	CreateAttributes(ctx context.Context, srvUUID uuid.UUID, attr fleetdbapi.Attributes) (*fleetdbapi.ServerResponse, error)
+	Delete(ctx context.Context, srv fleetdbapi.Server) (*fleetdbapi.ServerResponse, error)
+}
+
+// Server identifies a fleetdb server record by the BOM serial number.
+type Server struct {
+	SerialNum  string `json:"serial_num"`
+	ServerUUID string `json:"server_uuid,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Result lists the outcome of enrolling each BOM serial number.
+type Result struct {
+	// Created are the server records created by this run.
+	Created []Server `json:"created"`
+	// Existing are the serial numbers that already had a server record.
+	Existing []Server `json:"existing"`
+	// Failed are the serial numbers a server record could not be created for.
+	Failed []Server `json:"failed"`
+}
+
+// Enroller creates stub fleetdb server records for BOM serial numbers.
+type Enroller struct {
+	fleetdb      FleetDB
+	facilityCode string
+	logger       *logrus.Logger
+}
+
+// New returns an Enroller that creates server records in the given facility.
+func New(fleetdb FleetDB, facilityCode string, logger *logrus.Logger) *Enroller {
+	return &Enroller{
+		fleetdb:      fleetdb,
+		facilityCode: facilityCode,
+		logger:       logger,
+	}
+}
+
+// Enroll creates a server record for each BOM serial number that doesn't have one.
+//
+// Servers are looked up by the serial in their vendor attributes, which are set on the created
+// records, so enrolling the same BOMs again creates nothing.
+func (e *Enroller) Enroll(ctx context.Context, boms []fleetdbapi.Bom) *Result {
+	result := &Result{
+		Created:  []Server{},
+		Existing: []Server{},
+		Failed:   []Server{},
+	}
+
+	for i := range boms {
+		serial := boms[i].SerialNum
+
+		existing, err := inventory.ServerBySerial(ctx, e.fleetdb, serial)
+		if err != nil {
+			result.Failed = append(result.Failed, Server{SerialNum: serial, Error: err.Error()})
+			continue
+		}
+
+		if existing != nil {
+			result.Existing = append(result.Existing, Server{SerialNum: serial, ServerUUID: existing.UUID.String()})
+			continue
+		}
+
+		id, err := e.create(ctx, &boms[i])
+		if err != nil {
+			e.logger.WithContext(ctx).WithError(err).WithField("serial", serial).Warn("failed to create fleetdb server")
+
+			result.Failed = append(result.Failed, Server{SerialNum: serial, Error: err.Error()})
+
+			continue
+		}
+
+		e.logger.WithContext(ctx).WithFields(logrus.Fields{"serial": serial, "server": id}).Info("created fleetdb server")
+
+		result.Created = append(result.Created, Server{SerialNum: serial, ServerUUID: id.String()})
+	}
+
+	return result
+}
+
+// create creates the server record and its attributes, the record is deleted when its attributes can't be created
+// so the serial is not left enrolled without the vendor attributes it is looked up by.
+func (e *Enroller) create(ctx context.Context, bom *fleetdbapi.Bom) (*uuid.UUID, error) {
+	id, _, err := e.fleetdb.Create(ctx, fleetdbapi.Server{Name: bom.SerialNum, FacilityCode: e.facilityCode})
+	if err != nil {
+		return nil, errors.Wrap(ErrEnroll, err.Error())
+	}
+
+	if id == nil {
+		return nil, errors.Wrap(ErrEnroll, "fleetdb returned no server id")
+	}
+
+	vendorData, err := json.Marshal(map[string]string{"serial": bom.SerialNum})
+	if err != nil {
+		return nil, errors.Wrap(ErrEnroll, err.Error())
+	}
+
+	bomData, err := json.Marshal(map[string][]string{
+		"bmc_mac_address": model.SplitMacAddrs(bom.BmcMacAddress),
+		"aoc_mac_address": model.SplitMacAddrs(bom.AocMacAddress),
+	})
+	if err != nil {
+		return nil, errors.Wrap(ErrEnroll, err.Error())
+	}
+
+	attrs := []fleetdbapi.Attributes{
+		{Namespace: inventory.VendorAttributesNamespace, Data: vendorData},
+		{Namespace: inventory.BomAttributesNamespace, Data: bomData},
+	}
+
+	for _, attr := range attrs {
+		if _, err := e.fleetdb.CreateAttributes(ctx, *id, attr); err != nil {
+			msg := "server " + id.String() + ": " + err.Error()
+
+			if _, derr := e.fleetdb.Delete(ctx, fleetdbapi.Server{UUID: *id}); derr != nil {
+				msg += ", server not deleted: " + derr.Error()
+			}
+
+			return nil, errors.Wrap(ErrEnroll, msg)
+		}
+	}
+
+	return id, nil
+}
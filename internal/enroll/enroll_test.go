@@ -0,0 +1,353 @@
+package enroll
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFleetDB serves the fleetdb server, attribute and credential endpoints used by the enroller and credential pusher.
+type fakeFleetDB struct {
+	mu          sync.Mutex
+	servers     map[uuid.UUID]*fleetdbapi.Server
+	credentials map[uuid.UUID]*fleetdbapi.ServerCredential
+	// failNamespace is an attribute namespace the fake fails to create.
+	failNamespace string
+}
+
+func (f *fakeFleetDB) bySerial(serial string) *fleetdbapi.Server {
+	for _, srv := range f.servers {
+		for _, attr := range srv.Attributes {
+			if attr.Namespace != inventory.VendorAttributesNamespace {
+				continue
+			}
+
+			data := map[string]string{}
+			if err := json.Unmarshal(attr.Data, &data); err == nil && data["serial"] == serial {
+				return srv
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeFleetDB) handler(t *testing.T) http.Handler {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	encode := func(w http.ResponseWriter, resp *fleetdbapi.ServerResponse) {
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}
+
+	mux.HandleFunc("GET /api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		records := []fleetdbapi.Server{}
+
+		// attribute filters are encoded as namespace~key~operator~value
+		for _, attr := range r.URL.Query()["attr"] {
+			parts := strings.Split(attr, "~")
+			if len(parts) != 4 {
+				continue
+			}
+
+			if srv := f.bySerial(parts[3]); srv != nil {
+				records = append(records, *srv)
+			}
+		}
+
+		encode(w, &fleetdbapi.ServerResponse{Records: records})
+	})
+
+	mux.HandleFunc("POST /api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		srv := &fleetdbapi.Server{}
+		if err := json.NewDecoder(r.Body).Decode(srv); err != nil {
+			t.Error(err)
+		}
+
+		srv.UUID = uuid.New()
+		f.servers[srv.UUID] = srv
+
+		w.WriteHeader(http.StatusCreated)
+		encode(w, &fleetdbapi.ServerResponse{Slug: srv.UUID.String()})
+	})
+
+	mux.HandleFunc("POST /api/v1/servers/{id}/attributes", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		srv, ok := f.servers[uuid.MustParse(r.PathValue("id"))]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		attr := fleetdbapi.Attributes{}
+		if err := json.NewDecoder(r.Body).Decode(&attr); err != nil {
+			t.Error(err)
+		}
+
+		if attr.Namespace == f.failNamespace {
+			w.WriteHeader(http.StatusInternalServerError)
+			encode(w, &fleetdbapi.ServerResponse{Error: "attributes not created"})
+
+			return
+		}
+
+		srv.Attributes = append(srv.Attributes, attr)
+
+		w.WriteHeader(http.StatusCreated)
+		encode(w, &fleetdbapi.ServerResponse{Slug: attr.Namespace})
+	})
+
+	mux.HandleFunc("DELETE /api/v1/servers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		delete(f.servers, uuid.MustParse(r.PathValue("id")))
+		encode(w, &fleetdbapi.ServerResponse{})
+	})
+
+	mux.HandleFunc("PUT /api/v1/servers/{id}/attributes/{ns}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		attr := fleetdbapi.Attributes{}
+		if err := json.NewDecoder(r.Body).Decode(&attr); err != nil {
+			t.Error(err)
+		}
+
+		srv, ok := f.servers[uuid.MustParse(r.PathValue("id"))]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		for i := range srv.Attributes {
+			if srv.Attributes[i].Namespace == r.PathValue("ns") {
+				srv.Attributes[i].Data = attr.Data
+				encode(w, &fleetdbapi.ServerResponse{})
+
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		encode(w, &fleetdbapi.ServerResponse{Error: "attributes not found"})
+	})
+
+	mux.HandleFunc("GET /api/v1/servers/{id}/credentials/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		cred, ok := f.credentials[uuid.MustParse(r.PathValue("id"))]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			encode(w, &fleetdbapi.ServerResponse{Error: "credential not found"})
+
+			return
+		}
+
+		encode(w, &fleetdbapi.ServerResponse{Record: cred})
+	})
+
+	mux.HandleFunc("PUT /api/v1/servers/{id}/credentials/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		cred := &fleetdbapi.ServerCredential{}
+		if err := json.NewDecoder(r.Body).Decode(cred); err != nil {
+			t.Error(err)
+		}
+
+		cred.SecretType = r.PathValue("slug")
+		f.credentials[uuid.MustParse(r.PathValue("id"))] = cred
+
+		encode(w, &fleetdbapi.ServerResponse{})
+	})
+
+	return mux
+}
+
+func newFakeFleetDBClient(t *testing.T, fake *fakeFleetDB) *fleetdbapi.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(fake.handler(t))
+	t.Cleanup(srv.Close)
+
+	client, err := fleetdbapi.NewClientWithToken("fake", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func testServer(serial string) *fleetdbapi.Server {
+	data, _ := json.Marshal(map[string]string{"serial": serial})
+
+	return &fleetdbapi.Server{
+		UUID:       uuid.New(),
+		Attributes: []fleetdbapi.Attributes{{Namespace: inventory.VendorAttributesNamespace, Data: data}},
+	}
+}
+
+func TestEnroll(t *testing.T) {
+	existing := testServer("serial-existing")
+	existingID := existing.UUID
+
+	fake := &fakeFleetDB{
+		servers: map[uuid.UUID]*fleetdbapi.Server{existingID: existing},
+	}
+
+	client := newFakeFleetDBClient(t, fake)
+
+	boms := []fleetdbapi.Bom{
+		{SerialNum: "serial-existing", BmcMacAddress: "aa:aa:aa:aa:aa:01"},
+		{SerialNum: "serial-new", BmcMacAddress: "BB:BB:BB:BB:BB:01", AocMacAddress: "bb:bb:bb:bb:bb:02"},
+	}
+
+	enroller := New(client, "dc13", logrus.New())
+
+	result := enroller.Enroll(context.TODO(), boms)
+	assert.Empty(t, result.Failed)
+	assert.Equal(t, []Server{{SerialNum: "serial-existing", ServerUUID: existingID.String()}}, result.Existing)
+	assert.Len(t, result.Created, 1)
+	assert.Equal(t, "serial-new", result.Created[0].SerialNum)
+
+	created := fake.servers[uuid.MustParse(result.Created[0].ServerUUID)]
+	assert.Equal(t, "dc13", created.FacilityCode)
+	assert.Equal(t, "serial-new", created.Name)
+	assert.Len(t, created.Attributes, 2)
+	assert.Equal(t, inventory.BomAttributesNamespace, created.Attributes[1].Namespace)
+	assert.JSONEq(t,
+		`{"bmc_mac_address":["bb:bb:bb:bb:bb:01"],"aoc_mac_address":["bb:bb:bb:bb:bb:02"]}`,
+		string(created.Attributes[1].Data),
+	)
+
+	// enrolling the same boms again creates nothing.
+	result = enroller.Enroll(context.TODO(), boms)
+	assert.Empty(t, result.Failed)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Existing, 2)
+	assert.Len(t, fake.servers, 2)
+}
+
+func TestEnrollAttributesFailure(t *testing.T) {
+	fake := &fakeFleetDB{
+		servers:       map[uuid.UUID]*fleetdbapi.Server{},
+		failNamespace: inventory.BomAttributesNamespace,
+	}
+
+	enroller := New(newFakeFleetDBClient(t, fake), "dc13", logrus.New())
+	boms := []fleetdbapi.Bom{{SerialNum: "serial-new", BmcMacAddress: "aa:aa:aa:aa:aa:01"}}
+
+	result := enroller.Enroll(context.TODO(), boms)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Failed, 1)
+	assert.Contains(t, result.Failed[0].Error, "attributes not created")
+
+	// the server created without its attributes was deleted.
+	assert.Empty(t, fake.servers)
+
+	// enrolling again retries the serial once fleetdb accepts the attributes.
+	fake.failNamespace = ""
+
+	result = enroller.Enroll(context.TODO(), boms)
+	assert.Empty(t, result.Failed)
+	assert.Len(t, result.Created, 1)
+	assert.Len(t, fake.servers, 1)
+}
+
+func TestCredentialPush(t *testing.T) {
+	unset := testServer("serial-unset")
+	rotated := testServer("serial-rotated")
+	factory := testServer("serial-factory")
+
+	fake := &fakeFleetDB{
+		servers: map[uuid.UUID]*fleetdbapi.Server{
+			unset.UUID:   unset,
+			rotated.UUID: rotated,
+			factory.UUID: factory,
+		},
+		credentials: map[uuid.UUID]*fleetdbapi.ServerCredential{
+			rotated.UUID: {Username: "ADMIN", Password: "rotated"},
+			factory.UUID: {Username: "ADMIN", Password: "factory-factory"},
+		},
+	}
+
+	boms := []fleetdbapi.Bom{
+		{SerialNum: "serial-unset", NumDefiPmi: "ADMIN", NumDefPWD: "factory-unset"},
+		{SerialNum: "serial-rotated", NumDefiPmi: "ADMIN", NumDefPWD: "factory-rotated"},
+		{SerialNum: "serial-factory", NumDefiPmi: "ADMIN", NumDefPWD: "factory-factory"},
+		{SerialNum: "serial-not-enrolled", NumDefiPmi: "ADMIN", NumDefPWD: "factory-not-enrolled"},
+		{SerialNum: "serial-no-password"},
+	}
+
+	pusher := NewCredentialPusher(newFakeFleetDBClient(t, fake), logrus.New())
+
+	result := pusher.Push(context.TODO(), boms)
+	assert.Empty(t, result.Failed)
+	assert.Equal(t, []Credential{{SerialNum: "serial-unset", ServerUUID: unset.UUID.String(), FactoryDefault: true}}, result.Written)
+	assert.Equal(t, []Credential{
+		{SerialNum: "serial-rotated", ServerUUID: rotated.UUID.String()},
+		{SerialNum: "serial-factory", ServerUUID: factory.UUID.String(), FactoryDefault: true},
+	}, result.Existing)
+	assert.Equal(t, []Credential{{SerialNum: "serial-not-enrolled"}}, result.NotEnrolled)
+	assert.Len(t, result.FactoryDefault(), 2)
+
+	// the rotated credential is left as is.
+	assert.Equal(t, "factory-unset", fake.credentials[unset.UUID].Password)
+	assert.Equal(t, "rotated", fake.credentials[rotated.UUID].Password)
+
+	// the factory default state is recorded, and updated on the next run.
+	assert.JSONEq(t, `{"factory_default":false}`, string(rotated.Attributes[1].Data))
+
+	fake.credentials[unset.UUID].Password = "rotated"
+
+	result = pusher.Push(context.TODO(), boms)
+	assert.Empty(t, result.Failed)
+	assert.Empty(t, result.Written)
+	assert.Len(t, unset.Attributes, 2)
+	assert.JSONEq(t, `{"factory_default":false}`, string(unset.Attributes[1].Data))
+}
+
+func TestCredentialPushAttributeFailure(t *testing.T) {
+	server := testServer("serial-unset")
+
+	fake := &fakeFleetDB{
+		servers:       map[uuid.UUID]*fleetdbapi.Server{server.UUID: server},
+		credentials:   map[uuid.UUID]*fleetdbapi.ServerCredential{},
+		failNamespace: CredentialAttributesNamespace,
+	}
+
+	pusher := NewCredentialPusher(newFakeFleetDBClient(t, fake), logrus.New())
+
+	// the credential is written, the attribute failure is reported with it.
+	result := pusher.Push(context.TODO(), []fleetdbapi.Bom{{SerialNum: "serial-unset", NumDefiPmi: "ADMIN", NumDefPWD: "factory"}})
+	assert.Empty(t, result.Failed)
+
+	if assert.Len(t, result.Written, 1) {
+		assert.Contains(t, result.Written[0].AttributeError, "attributes not created")
+	}
+
+	assert.Equal(t, "factory", fake.credentials[server.UUID].Password)
+}
@@ -0,0 +1,356 @@
+// Package export renders the BOMs of the enrolled servers as DHCP reservations for the BMC MAC addresses.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+// Format is a DHCP server reservation format.
+type Format string
+
+const (
+	// FormatISC renders ISC dhcpd host blocks.
+	FormatISC Format = "isc"
+	// FormatKea renders Kea DHCPv4 reservations JSON.
+	FormatKea Format = "kea"
+	// FormatDnsmasq renders dnsmasq dhcp-host lines.
+	FormatDnsmasq Format = "dnsmasq"
+
+	// DefaultHostnamePrefix is prepended to the serial number in reservation host names.
+	DefaultHostnamePrefix = "bmc"
+
+	// maxHostnameLen is the DNS label length limit.
+	maxHostnameLen = 63
+)
+
+var (
+	ErrExport = errors.New("error exporting DHCP reservations")
+	ErrFormat = errors.New("unsupported DHCP reservation format")
+)
+
+// ParseFormat returns the Format named by s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatISC, FormatKea, FormatDnsmasq:
+		return f, nil
+	default:
+		return "", errors.Wrap(ErrFormat, s)
+	}
+}
+
+// ContentType returns the HTTP content type of the rendered format.
+func (f Format) ContentType() string {
+	if f == FormatKea {
+		return "application/json"
+	}
+
+	return "text/plain; charset=utf-8"
+}
+
+// Reservation is a DHCP reservation for a BMC MAC address.
+type Reservation struct {
+	SerialNum string     `json:"serial_num"`
+	Hostname  string     `json:"hostname"`
+	MacAddr   string     `json:"mac_address"`
+	IPAddr    netip.Addr `json:"ip_address"`
+}
+
+// Host is an enrolled server with its BOM and the addresses allocated to its BMC MAC addresses.
+type Host struct {
+	ServerUUID uuid.UUID
+	Bom        fleetdbapi.Bom
+	// Addrs are the allocated addresses keyed by BMC MAC address.
+	Addrs map[string]netip.Addr
+}
+
+// Source lists the hosts to export and records the addresses allocated to them.
+type Source interface {
+	Hosts(ctx context.Context) ([]Host, error)
+	Record(ctx context.Context, host *Host) error
+}
+
+// Exporter builds DHCP reservations with the address pool of each metro.
+type Exporter struct {
+	pools          map[string]*Pool
+	hostnamePrefix string
+	source         Source
+
+	// mu serializes exports so concurrent allocations don't hand out the same address.
+	mu sync.Mutex
+}
+
+// Option sets an Exporter parameter.
+type Option func(*Exporter)
+
+// WithSource sets the source of the exported hosts and their recorded allocations.
+func WithSource(source Source) Option {
+	return func(e *Exporter) {
+		e.source = source
+	}
+}
+
+// New returns an Exporter allocating addresses from the given pools, keyed by metro.
+func New(pools map[string]*Pool, hostnamePrefix string, options ...Option) *Exporter {
+	if hostnamePrefix == "" {
+		hostnamePrefix = DefaultHostnamePrefix
+	}
+
+	e := &Exporter{pools: pools, hostnamePrefix: hostnamePrefix}
+
+	for _, opt := range options {
+		opt(e)
+	}
+
+	return e
+}
+
+// Metros returns the metros with an address pool.
+func (e *Exporter) Metros() []string {
+	metros := make([]string, 0, len(e.pools))
+	for metro := range e.pools {
+		metros = append(metros, metro)
+	}
+
+	sort.Strings(metros)
+
+	return metros
+}
+
+// Reservations returns a reservation for each BMC MAC address of the hosts in the metro, with addresses from its pool.
+//
+// Hosts without a BMC MAC address are skipped, a host listing more than one gets a reservation for each
+// with the host names suffixed by their position. Addresses recorded on the hosts are kept and the others are
+// allocated over all the hosts of the metro, the hosts whose addresses changed are returned to be recorded.
+// When serials are given only their reservations are returned.
+func (e *Exporter) Reservations(hosts []Host, metro string, serials []string) ([]Reservation, []*Host, error) {
+	pool, ok := e.pools[metro]
+	if !ok {
+		return nil, nil, errors.Wrapf(ErrExport, "no address pool for metro %q, configured metros: %s", metro, strings.Join(e.Metros(), ","))
+	}
+
+	var (
+		reservations []Reservation
+		owners       []int
+		keys         []string
+	)
+
+	reserved := map[string]netip.Addr{}
+
+	for i := range hosts {
+		if hosts[i].Bom.Metro != metro {
+			continue
+		}
+
+		macs := model.SplitMacAddrs(hosts[i].Bom.BmcMacAddress)
+		for j, mac := range macs {
+			hostname := Hostname(e.hostnamePrefix, hosts[i].Bom.SerialNum)
+			if len(macs) > 1 {
+				hostname = truncateHostname(fmt.Sprintf("%s-%d", hostname, j))
+			}
+
+			reservations = append(reservations, Reservation{
+				SerialNum: hosts[i].Bom.SerialNum,
+				Hostname:  hostname,
+				MacAddr:   mac,
+			})
+			owners = append(owners, i)
+
+			// addresses are keyed by MAC so they follow the hardware.
+			keys = append(keys, mac)
+			if addr, ok := hosts[i].Addrs[mac]; ok {
+				reserved[mac] = addr
+			}
+		}
+	}
+
+	addrs, err := pool.Allocate(keys, reserved)
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrExport, err.Error())
+	}
+
+	allocated := map[int]map[string]netip.Addr{}
+
+	for i := range reservations {
+		reservations[i].IPAddr = addrs[reservations[i].MacAddr]
+
+		if allocated[owners[i]] == nil {
+			allocated[owners[i]] = map[string]netip.Addr{}
+		}
+
+		allocated[owners[i]][reservations[i].MacAddr] = reservations[i].IPAddr
+	}
+
+	var changed []*Host
+
+	for i := range hosts {
+		if addrs, ok := allocated[i]; ok && !maps.Equal(addrs, hosts[i].Addrs) {
+			hosts[i].Addrs = addrs
+			changed = append(changed, &hosts[i])
+		}
+	}
+
+	if len(serials) > 0 {
+		reservations = selectSerials(reservations, serials)
+	}
+
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].Hostname < reservations[j].Hostname
+	})
+
+	return reservations, changed, nil
+}
+
+// Export renders the reservations for the metro hosts of the source in the given format, limited to the
+// serials when given. The addresses allocated to hosts without one are recorded before they are rendered,
+// so later exports keep them.
+func (e *Exporter) Export(ctx context.Context, metro string, serials []string, format Format) ([]byte, error) {
+	if e.source == nil {
+		return nil, errors.Wrap(ErrExport, "no host source configured")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	hosts, err := e.source.Hosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, changed, err := e.Reservations(hosts, metro, serials)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range changed {
+		if err := e.source.Record(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+
+	return Render(format, reservations)
+}
+
+// selectSerials returns the reservations for the given serial numbers.
+func selectSerials(reservations []Reservation, serials []string) []Reservation {
+	want := make(map[string]struct{}, len(serials))
+	for _, serial := range serials {
+		want[serial] = struct{}{}
+	}
+
+	selected := []Reservation{}
+
+	for i := range reservations {
+		if _, ok := want[reservations[i].SerialNum]; ok {
+			selected = append(selected, reservations[i])
+		}
+	}
+
+	return selected
+}
+
+// Hostname returns the reservation host name for the serial number,
+// the serial is lower cased and characters not allowed in a DNS label are replaced with a hyphen.
+func Hostname(prefix, serial string) string {
+	var b strings.Builder
+
+	b.WriteString(prefix)
+	b.WriteByte('-')
+
+	for _, r := range strings.ToLower(serial) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			continue
+		}
+
+		b.WriteByte('-')
+	}
+
+	return truncateHostname(b.String())
+}
+
+func truncateHostname(s string) string {
+	if len(s) > maxHostnameLen {
+		s = s[:maxHostnameLen]
+	}
+
+	return strings.Trim(s, "-")
+}
+
+// Render writes the reservations in the given format.
+func Render(format Format, reservations []Reservation) ([]byte, error) {
+	switch format {
+	case FormatISC:
+		return renderISC(reservations), nil
+	case FormatKea:
+		return renderKea(reservations)
+	case FormatDnsmasq:
+		return renderDnsmasq(reservations), nil
+	default:
+		return nil, errors.Wrap(ErrFormat, string(format))
+	}
+}
+
+func renderISC(reservations []Reservation) []byte {
+	buf := &bytes.Buffer{}
+
+	for _, r := range reservations {
+		fmt.Fprintf(buf, "host %s {\n", r.Hostname)
+		fmt.Fprintf(buf, "  hardware ethernet %s;\n", r.MacAddr)
+		fmt.Fprintf(buf, "  fixed-address %s;\n", r.IPAddr)
+		fmt.Fprintf(buf, "  option host-name %q;\n", r.Hostname)
+		buf.WriteString("}\n")
+	}
+
+	return buf.Bytes()
+}
+
+type keaReservation struct {
+	HWAddress string `json:"hw-address"`
+	IPAddress string `json:"ip-address"`
+	Hostname  string `json:"hostname"`
+}
+
+// renderKea writes the reservations list to be included in a Kea subnet4 definition.
+func renderKea(reservations []Reservation) ([]byte, error) {
+	doc := struct {
+		Reservations []keaReservation `json:"reservations"`
+	}{Reservations: make([]keaReservation, 0, len(reservations))}
+
+	for _, r := range reservations {
+		doc.Reservations = append(doc.Reservations, keaReservation{
+			HWAddress: r.MacAddr,
+			IPAddress: r.IPAddr.String(),
+			Hostname:  r.Hostname,
+		})
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(ErrExport, err.Error())
+	}
+
+	return append(b, '\n'), nil
+}
+
+func renderDnsmasq(reservations []Reservation) []byte {
+	buf := &bytes.Buffer{}
+
+	for _, r := range reservations {
+		fmt.Fprintf(buf, "dhcp-host=%s,%s,%s\n", r.MacAddr, r.IPAddr, r.Hostname)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,263 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostname(t *testing.T) {
+	var testCases = []struct {
+		serial   string
+		expected string
+	}{
+		{"ABC123", "bmc-abc123"},
+		{"S/N 42_X", "bmc-s-n-42-x"},
+		{"trailing.", "bmc-trailing"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.serial, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Hostname(DefaultHostnamePrefix, tt.serial))
+		})
+	}
+}
+
+func TestPoolAllocate(t *testing.T) {
+	pool, err := NewPool("dc13", "10.0.0.10", "10.0.0.19")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 10, pool.Size())
+
+	keys := []string{"aa:aa:aa:aa:aa:01", "aa:aa:aa:aa:aa:02", "aa:aa:aa:aa:aa:03"}
+
+	first, err := pool.Allocate(keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[netip.Addr]bool{}
+	for _, addr := range first {
+		assert.False(t, seen[addr], "address allocated twice")
+		assert.True(t, addr.Compare(netip.MustParseAddr("10.0.0.10")) >= 0 && addr.Compare(netip.MustParseAddr("10.0.0.19")) <= 0)
+		seen[addr] = true
+	}
+
+	// the same keys in another order get the same addresses.
+	second, err := pool.Allocate([]string{keys[2], keys[0], keys[1]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, first, second)
+
+	// reserved addresses are kept whatever the new keys probe, addresses outside the pool are reallocated.
+	reserved := map[string]netip.Addr{
+		keys[0]: netip.MustParseAddr("10.0.0.19"),
+		keys[1]: netip.MustParseAddr("10.0.0.19"),
+		keys[2]: netip.MustParseAddr("10.0.1.10"),
+	}
+
+	for i := 0; i < 5; i++ {
+		withNew, err := pool.Allocate(append(keys, fmt.Sprintf("new-%d", i)), reserved)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, netip.MustParseAddr("10.0.0.19"), withNew[keys[0]])
+		assert.NotEqual(t, netip.MustParseAddr("10.0.0.19"), withNew[keys[1]])
+		assert.NotEqual(t, netip.MustParseAddr("10.0.1.10"), withNew[keys[2]])
+		assert.Len(t, withNew, len(keys)+1)
+	}
+
+	tooMany := make([]string, 11)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	_, err = pool.Allocate(tooMany, nil)
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+
+	_, err = NewPool("dc13", "10.0.0.19", "10.0.0.10")
+	assert.ErrorIs(t, err, ErrPool)
+
+	_, err = NewPool("dc13", "0.0.0.0", "255.255.255.255")
+	assert.ErrorIs(t, err, ErrPool)
+}
+
+func TestExport(t *testing.T) {
+	pool, err := NewPool("dc13", "10.0.0.10", "10.0.0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := &fakeSource{hosts: testHosts(
+		fleetdbapi.Bom{SerialNum: "SN1", BmcMacAddress: "AA:AA:AA:AA:AA:01", Metro: "dc13"},
+		fleetdbapi.Bom{SerialNum: "SN2", Metro: "dc13"},
+		fleetdbapi.Bom{SerialNum: "SN3", BmcMacAddress: "AA:AA:AA:AA:AA:03", Metro: "am6"},
+	)}
+
+	exporter := New(map[string]*Pool{"dc13": pool}, "", WithSource(source))
+
+	var testCases = []struct {
+		format   Format
+		expected string
+	}{
+		{
+			FormatISC,
+			"host bmc-sn1 {\n  hardware ethernet aa:aa:aa:aa:aa:01;\n  fixed-address 10.0.0.10;\n  option host-name \"bmc-sn1\";\n}\n",
+		},
+		{
+			FormatKea,
+			`{
+  "reservations": [
+    {
+      "hw-address": "aa:aa:aa:aa:aa:01",
+      "ip-address": "10.0.0.10",
+      "hostname": "bmc-sn1"
+    }
+  ]
+}
+`,
+		},
+		{
+			FormatDnsmasq,
+			"dhcp-host=aa:aa:aa:aa:aa:01,10.0.0.10,bmc-sn1\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := exporter.Export(context.TODO(), "dc13", nil, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.expected, string(got))
+		})
+	}
+
+	// the allocation is recorded by the first export and kept by the others.
+	assert.Equal(t, 1, source.recorded)
+
+	_, err = exporter.Export(context.TODO(), "unknown", nil, FormatISC)
+	assert.ErrorIs(t, err, ErrExport)
+
+	_, err = New(map[string]*Pool{"dc13": pool}, "").Export(context.TODO(), "dc13", nil, FormatISC)
+	assert.ErrorIs(t, err, ErrExport)
+
+	_, err = ParseFormat("bind")
+	assert.ErrorIs(t, err, ErrFormat)
+}
+
+func TestReservationsSelectSerials(t *testing.T) {
+	pool, err := NewPool("dc13", "10.0.0.10", "10.0.0.19")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := New(map[string]*Pool{"dc13": pool}, "")
+
+	var boms []fleetdbapi.Bom
+	for i := 0; i < 8; i++ {
+		boms = append(boms, fleetdbapi.Bom{
+			SerialNum:     fmt.Sprintf("SN%d", i),
+			BmcMacAddress: fmt.Sprintf("aa:aa:aa:aa:aa:%02d", i),
+			Metro:         "dc13",
+		})
+	}
+
+	all, _, err := exporter.Reservations(testHosts(boms...), "dc13", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, all, len(boms))
+
+	// a selected serial keeps the address it has in the full export.
+	for i := range all {
+		selected, _, err := exporter.Reservations(testHosts(boms...), "dc13", []string{all[i].SerialNum})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []Reservation{all[i]}, selected)
+	}
+}
+
+func TestReservationsRecorded(t *testing.T) {
+	pool, err := NewPool("dc13", "10.0.0.10", "10.0.0.19")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := New(map[string]*Pool{"dc13": pool}, "")
+
+	hosts := testHosts(
+		fleetdbapi.Bom{SerialNum: "SN1", BmcMacAddress: "aa:aa:aa:aa:aa:01", Metro: "dc13"},
+		fleetdbapi.Bom{SerialNum: "SN2", BmcMacAddress: "aa:aa:aa:aa:aa:02", Metro: "dc13"},
+	)
+
+	first, changed, err := exporter.Reservations(hosts, "dc13", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, changed, 2)
+
+	// hosts added later get new addresses and the recorded ones don't move.
+	for i := 3; i < 10; i++ {
+		hosts = append(hosts, testHosts(fleetdbapi.Bom{
+			SerialNum:     fmt.Sprintf("SN%d", i),
+			BmcMacAddress: fmt.Sprintf("aa:aa:aa:aa:aa:%02d", i),
+			Metro:         "dc13",
+		})...)
+
+		got, changed, err := exporter.Reservations(hosts, "dc13", []string{"SN1", "SN2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, first, got)
+		assert.Equal(t, []*Host{&hosts[len(hosts)-1]}, changed)
+	}
+}
+
+// fakeSource is an in-memory Source.
+type fakeSource struct {
+	hosts    []Host
+	recorded int
+}
+
+func (s *fakeSource) Hosts(context.Context) ([]Host, error) {
+	return slices.Clone(s.hosts), nil
+}
+
+func (s *fakeSource) Record(_ context.Context, host *Host) error {
+	for i := range s.hosts {
+		if s.hosts[i].ServerUUID == host.ServerUUID {
+			s.hosts[i].Addrs = host.Addrs
+		}
+	}
+
+	s.recorded++
+
+	return nil
+}
+
+func testHosts(boms ...fleetdbapi.Bom) []Host {
+	hosts := make([]Host, 0, len(boms))
+	for i := range boms {
+		hosts = append(hosts, Host{ServerUUID: uuid.New(), Bom: boms[i]})
+	}
+
+	return hosts
+}
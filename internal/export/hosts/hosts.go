@@ -0,0 +1,155 @@
+// Package hosts lists the fleetdb servers with a stored BOM as DHCP export hosts
+// and records the addresses allocated to them as fleetdb server attributes.
+package hosts
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AllocationsNamespace is the fleetdb server attribute namespace holding the DHCP reservation addresses
+	// allocated to the server BMC MAC addresses.
+	AllocationsNamespace = "sh.hollow.bomservice.dhcp_reservations"
+
+	// serverPageSize is the number of servers requested per page.
+	serverPageSize = 100
+)
+
+var (
+	ErrHosts = errors.New("error listing DHCP export hosts")
+)
+
+// FleetDB is the subset of the fleetdb client the source lists servers and records their allocations with.
+type FleetDB interface {
+	inventory.ServerLister
+	CreateAttributes(ctx context.Context, srvUUID uuid.UUID, attr fleetdbapi.Attributes) (*fleetdbapi.ServerResponse, error)
+	UpdateAttributes(ctx context.Context, srvUUID uuid.UUID, ns string, data json.RawMessage) (*fleetdbapi.ServerResponse, error)
+}
+
+// allocations is the AllocationsNamespace attribute data.
+type allocations struct {
+	Addrs map[string]netip.Addr `json:"addresses"`
+}
+
+// Source lists the enrolled servers with their BOM, it implements export.Source.
+//
+// fleetdb does not list the stored BOMs, so the servers are listed and each BOM is looked up by the serial
+// in the server vendor attributes. BOMs of serials that are not enrolled are not exported.
+type Source struct {
+	fleetdb    FleetDB
+	repository store.Repository
+	workers    int
+}
+
+var _ export.Source = (*Source)(nil)
+
+// New returns a Source looking up the BOMs of up to workers servers concurrently,
+// lookup.DefaultWorkers when workers is not positive.
+func New(fleetdb FleetDB, repository store.Repository, workers int) *Source {
+	return &Source{fleetdb: fleetdb, repository: repository, workers: workers}
+}
+
+// Hosts returns the enrolled servers with a stored BOM and their recorded allocations.
+func (s *Source) Hosts(ctx context.Context) ([]export.Host, error) {
+	var hosts []export.Host
+
+	for page := 1; ; page++ {
+		params := &fleetdbapi.ServerListParams{
+			PaginationParams: &fleetdbapi.PaginationParams{Limit: serverPageSize, Page: page, Preload: true},
+		}
+
+		servers, _, err := s.fleetdb.List(ctx, params)
+		if err != nil {
+			return nil, errors.Wrap(ErrHosts, err.Error())
+		}
+
+		found := make([]*export.Host, len(servers))
+
+		err = lookup.ForEach(ctx, len(servers), s.workers, func(ctx context.Context, i int) error {
+			host, err := s.host(ctx, &servers[i])
+			found[i] = host
+
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(ErrHosts, err.Error())
+		}
+
+		for _, host := range found {
+			if host != nil {
+				hosts = append(hosts, *host)
+			}
+		}
+
+		if len(servers) < serverPageSize {
+			break
+		}
+	}
+
+	return hosts, nil
+}
+
+// host returns the server with its BOM, nil is returned for servers without a serial or a stored BOM.
+func (s *Source) host(ctx context.Context, server *fleetdbapi.Server) (*export.Host, error) {
+	serial := inventory.SerialNum(server)
+	if serial == "" {
+		return nil, nil
+	}
+
+	bom, _, err := s.repository.GetBomInfoBySerial(ctx, serial)
+	if err != nil {
+		if errors.Is(err, store.ErrBomNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	host := &export.Host{ServerUUID: server.UUID, Bom: *bom}
+
+	for _, attr := range server.Attributes {
+		if attr.Namespace != AllocationsNamespace {
+			continue
+		}
+
+		var data allocations
+		if err := json.Unmarshal(attr.Data, &data); err == nil {
+			host.Addrs = data.Addrs
+		}
+	}
+
+	return host, nil
+}
+
+// Record writes the host allocations to its AllocationsNamespace attribute.
+func (s *Source) Record(ctx context.Context, host *export.Host) error {
+	data, err := json.Marshal(allocations{Addrs: host.Addrs})
+	if err != nil {
+		return errors.Wrap(ErrHosts, err.Error())
+	}
+
+	_, err = s.fleetdb.UpdateAttributes(ctx, host.ServerUUID, AllocationsNamespace, data)
+	if err == nil {
+		return nil
+	}
+
+	if !inventory.IsNotFound(err) {
+		return errors.Wrap(ErrHosts, "server "+host.ServerUUID.String()+": "+err.Error())
+	}
+
+	if _, err := s.fleetdb.CreateAttributes(ctx, host.ServerUUID, fleetdbapi.Attributes{Namespace: AllocationsNamespace, Data: data}); err != nil {
+		return errors.Wrap(ErrHosts, "server "+host.ServerUUID.String()+": "+err.Error())
+	}
+
+	return nil
+}
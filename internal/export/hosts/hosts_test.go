@@ -0,0 +1,146 @@
+package hosts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFleetDB serves a single page of servers and keeps the attributes written to them.
+type fakeFleetDB struct {
+	servers []fleetdbapi.Server
+	writes  int
+}
+
+func (f *fakeFleetDB) List(_ context.Context, params *fleetdbapi.ServerListParams) ([]fleetdbapi.Server, *fleetdbapi.ServerResponse, error) {
+	if params.PaginationParams != nil && params.PaginationParams.Page > 1 {
+		return nil, &fleetdbapi.ServerResponse{}, nil
+	}
+
+	return f.servers, &fleetdbapi.ServerResponse{}, nil
+}
+
+func (f *fakeFleetDB) CreateAttributes(_ context.Context, id uuid.UUID, attr fleetdbapi.Attributes) (*fleetdbapi.ServerResponse, error) {
+	f.writes++
+
+	for i := range f.servers {
+		if f.servers[i].UUID == id {
+			f.servers[i].Attributes = append(f.servers[i].Attributes, attr)
+		}
+	}
+
+	return &fleetdbapi.ServerResponse{}, nil
+}
+
+func (f *fakeFleetDB) UpdateAttributes(_ context.Context, id uuid.UUID, ns string, data json.RawMessage) (*fleetdbapi.ServerResponse, error) {
+	for i := range f.servers {
+		if f.servers[i].UUID != id {
+			continue
+		}
+
+		for j := range f.servers[i].Attributes {
+			if f.servers[i].Attributes[j].Namespace == ns {
+				f.writes++
+				f.servers[i].Attributes[j].Data = data
+
+				return &fleetdbapi.ServerResponse{}, nil
+			}
+		}
+	}
+
+	return nil, fleetdbapi.ServerError{StatusCode: http.StatusNotFound}
+}
+
+func testServer(serial string, attrs ...fleetdbapi.Attributes) fleetdbapi.Server {
+	if serial != "" {
+		attrs = append(attrs, fleetdbapi.Attributes{
+			Namespace: inventory.VendorAttributesNamespace,
+			Data:      json.RawMessage(`{"serial":"` + serial + `"}`),
+		})
+	}
+
+	return fleetdbapi.Server{UUID: uuid.New(), Attributes: attrs}
+}
+
+func TestSourceExport(t *testing.T) {
+	fleetdb := &fakeFleetDB{
+		servers: []fleetdbapi.Server{
+			testServer("SN1", fleetdbapi.Attributes{
+				Namespace: AllocationsNamespace,
+				Data:      json.RawMessage(`{"addresses":{"aa:aa:aa:aa:aa:01":"10.0.0.12"}}`),
+			}),
+			testServer("SN2"),
+			testServer(""),
+			testServer("SN-NO-BOM"),
+		},
+	}
+
+	boms := map[string]fleetdbapi.Bom{
+		"SN1": {SerialNum: "SN1", BmcMacAddress: "aa:aa:aa:aa:aa:01", Metro: "dc13"},
+		"SN2": {SerialNum: "SN2", BmcMacAddress: "aa:aa:aa:aa:aa:02", Metro: "dc13"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			bom, ok := boms[serial]
+			if !ok {
+				return nil, nil, store.ErrBomNotFound
+			}
+
+			return &bom, &fleetdbapi.ServerResponse{Record: &bom}, nil
+		}).
+		AnyTimes()
+
+	source := New(fleetdb, repository, 2)
+
+	hosts, err := source.Hosts(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// servers without a serial or a stored bom are not exported.
+	assert.Len(t, hosts, 2)
+	assert.Equal(t, map[string]netip.Addr{"aa:aa:aa:aa:aa:01": netip.MustParseAddr("10.0.0.12")}, hosts[0].Addrs)
+	assert.Nil(t, hosts[1].Addrs)
+
+	pool, err := export.NewPool("dc13", "10.0.0.10", "10.0.0.19")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := export.New(map[string]*export.Pool{"dc13": pool}, "", export.WithSource(source))
+
+	first, err := exporter.Export(context.TODO(), "dc13", nil, export.FormatDnsmasq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the recorded address is kept and only the new allocation is written.
+	assert.True(t, strings.HasPrefix(string(first), "dhcp-host=aa:aa:aa:aa:aa:01,10.0.0.12,bmc-sn1\n"))
+	assert.Equal(t, 1, fleetdb.writes)
+
+	second, err := exporter.Export(context.TODO(), "dc13", nil, export.FormatDnsmasq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, fleetdb.writes)
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"net/netip"
+	"slices"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrPool          = errors.New("invalid address pool")
+	ErrPoolExhausted = errors.New("address pool exhausted")
+)
+
+// Pool is the range of IPv4 addresses BMC reservations are allocated from in a metro.
+type Pool struct {
+	Metro string
+	start uint32
+	size  uint32
+}
+
+// NewPool returns the pool of addresses from start to end inclusive.
+func NewPool(metro, start, end string) (*Pool, error) {
+	startAddr, err := netip.ParseAddr(start)
+	if err != nil {
+		return nil, errors.Wrap(ErrPool, metro+": "+err.Error())
+	}
+
+	endAddr, err := netip.ParseAddr(end)
+	if err != nil {
+		return nil, errors.Wrap(ErrPool, metro+": "+err.Error())
+	}
+
+	if !startAddr.Is4() || !endAddr.Is4() {
+		return nil, errors.Wrap(ErrPool, metro+": only IPv4 ranges are supported")
+	}
+
+	if endAddr.Less(startAddr) {
+		return nil, errors.Wrap(ErrPool, metro+": range end is before the start")
+	}
+
+	s, e := addrToUint32(startAddr), addrToUint32(endAddr)
+
+	// the size of a range spanning the whole address space does not fit the pool size.
+	if e-s == math.MaxUint32 {
+		return nil, errors.Wrap(ErrPool, metro+": range spans the whole IPv4 address space")
+	}
+
+	return &Pool{Metro: metro, start: s, size: e - s + 1}, nil
+}
+
+// Size returns the number of addresses in the pool.
+func (p *Pool) Size() int {
+	return int(p.size)
+}
+
+// Allocate assigns an address in the pool to each key.
+//
+// Keys with a reserved address in the pool keep it unless an earlier key in sorted order holds it, the others
+// hash to a starting offset in the pool and take the next free address from there. Addresses are only stable
+// when the allocations are recorded and passed back as reserved, a new key shifts the probes of the others.
+func (p *Pool) Allocate(keys []string, reserved map[string]netip.Addr) (map[string]netip.Addr, error) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	sorted = slices.Compact(sorted)
+
+	if uint64(len(sorted)) > uint64(p.size) {
+		return nil, errors.Wrapf(ErrPoolExhausted, "%s: %d addresses for %d reservations", p.Metro, p.size, len(sorted))
+	}
+
+	used := make(map[uint32]struct{}, len(sorted))
+	allocated := make(map[string]netip.Addr, len(sorted))
+
+	for _, key := range sorted {
+		offset, ok := p.offset(reserved[key])
+		if !ok {
+			continue
+		}
+
+		if _, ok := used[offset]; ok {
+			continue
+		}
+
+		used[offset] = struct{}{}
+		allocated[key] = reserved[key]
+	}
+
+	for _, key := range sorted {
+		if _, ok := allocated[key]; ok {
+			continue
+		}
+
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+
+		offset := h.Sum32() % p.size
+		for {
+			if _, ok := used[offset]; !ok {
+				break
+			}
+
+			offset = (offset + 1) % p.size
+		}
+
+		used[offset] = struct{}{}
+		allocated[key] = uint32ToAddr(p.start + offset)
+	}
+
+	return allocated, nil
+}
+
+// offset returns the offset of the address in the pool, ok is false for addresses outside the pool.
+func (p *Pool) offset(addr netip.Addr) (uint32, bool) {
+	if !addr.Is4() {
+		return 0, false
+	}
+
+	v := addrToUint32(addr)
+	if v < p.start || v-p.start >= p.size {
+		return 0, false
+	}
+
+	return v - p.start, true
+}
+
+func addrToUint32(addr netip.Addr) uint32 {
+	b := addr.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func uint32ToAddr(v uint32) netip.Addr {
+	var b [4]byte
+
+	binary.BigEndian.PutUint32(b[:], v)
+
+	return netip.AddrFrom4(b)
+}
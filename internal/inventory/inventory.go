@@ -0,0 +1,145 @@
+// Package inventory provides helpers to look up fleetdb server records and the MAC addresses in their inventory.
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	common "github.com/metal-toolbox/bmc-common"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	rivets "github.com/metal-toolbox/rivets/v2/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// VendorAttributesNamespace is the fleetdb server attribute namespace holding the server serial.
+	VendorAttributesNamespace = "sh.hollow.alloy.server_vendor_attributes"
+
+	// BomAttributesNamespace is the fleetdb server attribute namespace holding the BOM MAC addresses.
+	BomAttributesNamespace = "sh.hollow.bomservice.bom"
+
+	serialKey = "serial"
+)
+
+var (
+	ErrInventory = errors.New("fleetdb inventory error")
+)
+
+// ServerLister lists fleetdb server records.
+//
+// The fleetdb client implements this interface.
+type ServerLister interface {
+	List(ctx context.Context, params *fleetdbapi.ServerListParams) ([]fleetdbapi.Server, *fleetdbapi.ServerResponse, error)
+}
+
+// InventoryGetter returns the inventory of a fleetdb server.
+//
+// The fleetdb client implements this interface.
+//
+//nolint:revive // the name stutters, but reads better at the call sites.
+type InventoryGetter interface {
+	GetServerInventory(ctx context.Context, srvID uuid.UUID, inband bool) (*rivets.Server, *fleetdbapi.ServerResponse, error)
+}
+
+// ServerBySerial returns the fleetdb server with the serial in its vendor attributes,
+// nil is returned when no such server exists.
+func ServerBySerial(ctx context.Context, lister ServerLister, serial string) (*fleetdbapi.Server, error) {
+	params := &fleetdbapi.ServerListParams{
+		AttributeListParams: []fleetdbapi.AttributeListParams{
+			{
+				Namespace: VendorAttributesNamespace,
+				Keys:      []string{serialKey},
+				Operator:  fleetdbapi.OperatorComparitorEqual,
+				Value:     serial,
+			},
+		},
+	}
+
+	servers, _, err := lister.List(ctx, params)
+	if err != nil {
+		return nil, errors.Wrap(ErrInventory, err.Error())
+	}
+
+	switch len(servers) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &servers[0], nil
+	default:
+		return nil, errors.Wrap(ErrInventory, "multiple servers with serial "+serial)
+	}
+}
+
+// SerialNum returns the serial in the vendor attributes of a server listed with its attributes preloaded.
+func SerialNum(server *fleetdbapi.Server) string {
+	var data map[string]string
+
+	for _, attr := range server.Attributes {
+		if attr.Namespace != VendorAttributesNamespace {
+			continue
+		}
+
+		if err := json.Unmarshal(attr.Data, &data); err == nil {
+			return data[serialKey]
+		}
+	}
+
+	return ""
+}
+
+// BomMacAddrs returns the BMC and AOC MAC addresses recorded in the BOM attributes of a server
+// listed with its attributes preloaded.
+func BomMacAddrs(server *fleetdbapi.Server) (bmc, aoc []string) {
+	var data map[string][]string
+
+	for _, attr := range server.Attributes {
+		if attr.Namespace != BomAttributesNamespace {
+			continue
+		}
+
+		if err := json.Unmarshal(attr.Data, &data); err == nil {
+			return data["bmc_mac_address"], data["aoc_mac_address"]
+		}
+	}
+
+	return nil, nil
+}
+
+// BMCMacAddr returns the normalized MAC address of the BMC in the server inventory.
+func BMCMacAddr(inv *rivets.Server) string {
+	for _, c := range inv.Components {
+		if c != nil && c.Name == common.SlugBMC && c.Attributes != nil {
+			return model.NormalizeMacAddr(c.Attributes.MacAddress)
+		}
+	}
+
+	return ""
+}
+
+// NICMacAddrs returns the normalized MAC addresses of the NICs in the server inventory.
+func NICMacAddrs(inv *rivets.Server) []string {
+	var addrs []string
+
+	for _, c := range inv.Components {
+		if c == nil || c.Name != common.SlugNIC || c.Attributes == nil || c.Attributes.MacAddress == "" {
+			continue
+		}
+
+		addrs = append(addrs, model.NormalizeMacAddr(c.Attributes.MacAddress))
+	}
+
+	return addrs
+}
+
+// IsNotFound returns true when the error is a fleetdb 404 response.
+func IsNotFound(err error) bool {
+	var serverErr fleetdbapi.ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.StatusCode == http.StatusNotFound
+	}
+
+	return false
+}
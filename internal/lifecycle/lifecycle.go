@@ -0,0 +1,231 @@
+package lifecycle
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultShutdownTimeout is the time allowed for servers to drain and workers to stop.
+	DefaultShutdownTimeout = 10 * time.Second
+
+	ErrShutdownTimeout = errors.New("shutdown timeout exceeded")
+)
+
+// Worker runs until the context is canceled, a returned error other than the context error is fatal.
+type Worker func(ctx context.Context) error
+
+// Hook releases a resource once the servers and workers have stopped.
+type Hook func(ctx context.Context) error
+
+// Manager runs the servers and workers of the service under one context.
+//
+// Run returns when the context is canceled or a component fails, the servers are then shut down
+// letting in-flight requests complete, the workers are canceled, and the hooks run in reverse order
+// of registration, all within the shutdown timeout.
+type Manager struct {
+	logger          *logrus.Logger
+	shutdownTimeout time.Duration
+	servers         []server
+	workers         []worker
+	hooks           []hook
+}
+
+type server struct {
+	name     string
+	srv      *http.Server
+	listener net.Listener
+}
+
+type worker struct {
+	name string
+	run  Worker
+}
+
+type hook struct {
+	name string
+	fn   Hook
+}
+
+// Option type sets a parameter on the Manager type.
+type Option func(*Manager)
+
+// WithShutdownTimeout sets the time allowed for servers to drain and workers to stop.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.shutdownTimeout = timeout
+	}
+}
+
+// New returns a Manager with no components.
+func New(logger *logrus.Logger, opts ...Option) *Manager {
+	m := &Manager{
+		logger:          logger,
+		shutdownTimeout: DefaultShutdownTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// AddServer registers a server to listen on its address.
+func (m *Manager) AddServer(name string, srv *http.Server) {
+	m.servers = append(m.servers, server{name: name, srv: srv})
+}
+
+// AddServerListener registers a server to serve on the listener.
+func (m *Manager) AddServerListener(name string, srv *http.Server, listener net.Listener) {
+	m.servers = append(m.servers, server{name: name, srv: srv, listener: listener})
+}
+
+// AddWorker registers a background worker.
+func (m *Manager) AddWorker(name string, run Worker) {
+	m.workers = append(m.workers, worker{name: name, run: run})
+}
+
+// AddHook registers a hook to run on shutdown, after the servers and workers have stopped.
+func (m *Manager) AddHook(name string, fn Hook) {
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Run starts the registered components and blocks until they are stopped,
+// returning the first fatal component error or shutdown error.
+func (m *Manager) Run(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	fail := func(name string, err error) {
+		once.Do(func() {
+			firstErr = errors.Wrap(err, name)
+		})
+
+		cancel()
+	}
+
+	for _, s := range m.servers {
+		wg.Add(1)
+
+		go func(s server) {
+			defer wg.Done()
+
+			m.logger.WithFields(logrus.Fields{"component": s.name, "address": s.srv.Addr}).Info("server listening")
+
+			if err := s.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fail(s.name, err)
+			}
+		}(s)
+	}
+
+	for _, w := range m.workers {
+		wg.Add(1)
+
+		go func(w worker) {
+			defer wg.Done()
+
+			err := w.run(runCtx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				fail(w.name, err)
+			}
+		}(w)
+	}
+
+	<-runCtx.Done()
+
+	m.logger.Info("shutting down")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer shutdownCancel()
+
+	shutdownErr := m.shutdown(shutdownCtx, &wg)
+
+	once.Do(func() {
+		firstErr = shutdownErr
+	})
+
+	return firstErr
+}
+
+// serve serves over TLS when the server has a TLS configuration, its certificates are provided by the configuration.
+func (s server) serve() error {
+	useTLS := s.srv.TLSConfig != nil
+
+	switch {
+	case s.listener != nil && useTLS:
+		return s.srv.ServeTLS(s.listener, "", "")
+	case s.listener != nil:
+		return s.srv.Serve(s.listener)
+	case useTLS:
+		return s.srv.ListenAndServeTLS("", "")
+	default:
+		return s.srv.ListenAndServe()
+	}
+}
+
+// shutdown drains the servers, waits for the workers and runs the hooks, returning the first error.
+func (m *Manager) shutdown(ctx context.Context, wg *sync.WaitGroup) error {
+	var (
+		mu       sync.Mutex
+		firstErr error
+		drained  sync.WaitGroup
+	)
+
+	record := func(name string, err error) {
+		m.logger.WithError(err).WithField("component", name).Warn("shutdown error")
+
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = errors.Wrap(err, name)
+		}
+		mu.Unlock()
+	}
+
+	for _, s := range m.servers {
+		drained.Add(1)
+
+		go func(s server) {
+			defer drained.Done()
+
+			if err := s.srv.Shutdown(ctx); err != nil {
+				record(s.name, err)
+			}
+		}(s)
+	}
+
+	drained.Wait()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		record("workers", ErrShutdownTimeout)
+	}
+
+	for i := len(m.hooks) - 1; i >= 0; i-- {
+		if err := m.hooks[i].fn(ctx); err != nil {
+			record(m.hooks[i].name, err)
+		}
+	}
+
+	return firstErr
+}
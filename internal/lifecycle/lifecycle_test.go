@@ -0,0 +1,140 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return logger
+}
+
+func TestRunDrainsRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	srv := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		}),
+	}
+
+	var hooks []string
+
+	manager := New(newTestLogger())
+	manager.AddServerListener("api", srv, listener)
+	manager.AddWorker("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	manager.AddHook("first", func(context.Context) error { hooks = append(hooks, "first"); return nil })
+	manager.AddHook("second", func(context.Context) error { hooks = append(hooks, "second"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- manager.Run(ctx)
+	}()
+
+	status := make(chan int)
+
+	go func() {
+		resp, err := http.Post("http://"+listener.Addr().String()+"/upload", "", nil)
+		if err != nil {
+			status <- 0
+			return
+		}
+
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	// the in-flight request holds the shutdown.
+	select {
+	case <-done:
+		t.Fatal("manager stopped with a request in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	assert.Equal(t, http.StatusCreated, <-status)
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{"second", "first"}, hooks)
+}
+
+func TestRunComponentError(t *testing.T) {
+	errWorker := errors.New("worker failed")
+	hooked := false
+
+	manager := New(newTestLogger())
+	manager.AddWorker("failing", func(context.Context) error { return errWorker })
+	manager.AddWorker("other", func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.New("stopped")
+	})
+	manager.AddHook("hook", func(context.Context) error { hooked = true; return nil })
+
+	err := manager.Run(context.Background())
+
+	assert.ErrorIs(t, err, errWorker)
+	assert.Contains(t, err.Error(), "failing")
+	assert.True(t, hooked)
+
+	// a server failing to listen stops the manager.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	manager = New(newTestLogger())
+	manager.AddServer("api", &http.Server{Addr: listener.Addr().String(), ReadHeaderTimeout: time.Second})
+	manager.AddWorker("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	err = manager.Run(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "api")
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	manager := New(newTestLogger(), WithShutdownTimeout(10*time.Millisecond))
+	manager.AddWorker("stuck", func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, manager.Run(ctx), ErrShutdownTimeout)
+}
@@ -0,0 +1,205 @@
+package lookup
+
+import (
+	"context"
+	"sync"
+
+	"github.com/metal-toolbox/bomservice/internal/store"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultWorkers is the number of concurrent store lookups when none is configured.
+	DefaultWorkers = 8
+
+	// MaxBulkKeys is the maximum number of MAC addresses and serial numbers in a bulk request.
+	MaxBulkKeys = 1000
+)
+
+var (
+	ErrBulkRequest = errors.New("invalid bulk lookup request")
+)
+
+// BulkRequest lists the MAC addresses and serial numbers to look up.
+type BulkRequest struct {
+	MacAddresses []string `json:"mac_addresses"`
+	SerialNums   []string `json:"serial_nums"`
+}
+
+// BulkResult holds the records found for a BulkRequest, in request order, and the keys not found.
+type BulkResult struct {
+	MacAddresses []*Result        `json:"mac_addresses"`
+	SerialNums   []fleetdbapi.Bom `json:"serial_nums"`
+	NotFound     BulkRequest      `json:"not_found"`
+}
+
+// Validate checks the request has keys and is within MaxBulkKeys.
+func (r *BulkRequest) Validate() error {
+	n := len(r.MacAddresses) + len(r.SerialNums)
+
+	switch {
+	case n == 0:
+		return errors.Wrap(ErrBulkRequest, "no mac addresses or serial numbers")
+	case n > MaxBulkKeys:
+		return errors.Wrapf(ErrBulkRequest, "%d keys exceeds the limit of %d", n, MaxBulkKeys)
+	}
+
+	return nil
+}
+
+// Bulk looks up the MAC addresses and serial numbers with up to workers concurrent store lookups.
+func Bulk(ctx context.Context, repository store.Repository, req *BulkRequest, workers int) (*BulkResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{
+		MacAddresses: []*Result{},
+		SerialNums:   []fleetdbapi.Bom{},
+		NotFound:     BulkRequest{MacAddresses: []string{}, SerialNums: []string{}},
+	}
+
+	if len(req.MacAddresses) > 0 {
+		macResults, err := lookupMacs(ctx, repository, req.MacAddresses, workers)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, r := range macResults {
+			if r == nil {
+				result.NotFound.MacAddresses = append(result.NotFound.MacAddresses, req.MacAddresses[i])
+				continue
+			}
+
+			result.MacAddresses = append(result.MacAddresses, r)
+		}
+	}
+
+	if len(req.SerialNums) > 0 {
+		boms, err := lookupSerials(ctx, repository, req.SerialNums, workers)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, bom := range boms {
+			if bom == nil {
+				result.NotFound.SerialNums = append(result.NotFound.SerialNums, req.SerialNums[i])
+				continue
+			}
+
+			result.SerialNums = append(result.SerialNums, *bom)
+		}
+	}
+
+	return result, nil
+}
+
+// lookupMacs returns the lookup result for each MAC address by index, nil when it was not found.
+func lookupMacs(ctx context.Context, repository store.Repository, macs []string, workers int) ([]*Result, error) {
+	results := make([]*Result, len(macs))
+
+	err := ForEach(ctx, len(macs), workers, func(ctx context.Context, i int) error {
+		r, err := Mac(ctx, repository, macs[i])
+		if err != nil {
+			if errors.Is(err, store.ErrBomNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		results[i] = r
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// lookupSerials returns the stored bom for each serial number by index, nil when it was not found.
+func lookupSerials(ctx context.Context, repository store.Repository, serials []string, workers int) ([]*fleetdbapi.Bom, error) {
+	boms := make([]*fleetdbapi.Bom, len(serials))
+
+	err := ForEach(ctx, len(serials), workers, func(ctx context.Context, i int) error {
+		bom, err := Serial(ctx, repository, serials[i])
+		if err != nil {
+			if errors.Is(err, store.ErrBomNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		boms[i] = bom
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return boms, nil
+}
+
+// ForEach calls fn with each index up to n from up to workers goroutines, DefaultWorkers when workers is not positive.
+//
+// The first error cancels the remaining calls and is returned.
+func ForEach(ctx context.Context, n, workers int, fn func(ctx context.Context, i int) error) error {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	if workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
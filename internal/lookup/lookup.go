@@ -0,0 +1,287 @@
+// Package lookup finds stored BOMs by MAC address regardless of the role the address plays,
+// and detects MAC addresses claimed by more than one serial number.
+package lookup
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+// Role is the role a MAC address plays in a BOM.
+type Role string
+
+const (
+	// RoleAOC is an add-on card NIC MAC address.
+	RoleAOC Role = "aoc"
+	// RoleBMC is a BMC MAC address.
+	RoleBMC Role = "bmc"
+)
+
+// Match is a stored BOM listing the MAC address.
+type Match struct {
+	Role Role            `json:"role"`
+	Bom  *fleetdbapi.Bom `json:"bom"`
+}
+
+// Result lists the stored BOMs listing a MAC address.
+type Result struct {
+	MacAddress string  `json:"mac_address"`
+	Matches    []Match `json:"matches"`
+	// Collision is true when the MAC address is listed by more than one serial number.
+	Collision bool `json:"collision"`
+}
+
+// Mac looks up the MAC address in both the AOC and BMC indexes.
+//
+// store.ErrBomNotFound is returned when neither index has the address.
+func Mac(ctx context.Context, repository store.Repository, macAddr string) (*Result, error) {
+	result := &Result{MacAddress: macAddr, Matches: []Match{}}
+
+	lookups := []struct {
+		role Role
+		fn   func(context.Context, string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error)
+	}{
+		{RoleAOC, repository.GetBomInfoByAOCMacAddr},
+		{RoleBMC, repository.GetBomInfoByBMCMacAddr},
+	}
+
+	for _, l := range lookups {
+		bom, _, err := l.fn(ctx, macAddr)
+		if err != nil {
+			if errors.Is(err, store.ErrBomNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if bom != nil {
+			result.Matches = append(result.Matches, Match{Role: l.role, Bom: bom})
+		}
+	}
+
+	if len(result.Matches) == 0 {
+		return nil, errors.Wrap(store.ErrBomNotFound, "mac address "+macAddr)
+	}
+
+	serials := map[string]struct{}{}
+	for _, m := range result.Matches {
+		serials[m.Bom.SerialNum] = struct{}{}
+	}
+
+	result.Collision = len(serials) > 1
+
+	return result, nil
+}
+
+// Serial looks up the stored BOM with the serial number.
+//
+// store.ErrBomNotFound is returned when no BOM has the serial number.
+func Serial(ctx context.Context, repository store.Repository, serial string) (*fleetdbapi.Bom, error) {
+	bom, _, err := repository.GetBomInfoBySerial(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	return bom, nil
+}
+
+// Stored returns the stored BOMs with the serial numbers of the BOMs, looked up with up to workers concurrent store lookups.
+//
+// Serial numbers not stored are skipped.
+func Stored(ctx context.Context, repository store.Repository, boms []fleetdbapi.Bom, workers int) ([]fleetdbapi.Bom, error) {
+	seen := make(map[string]struct{}, len(boms))
+	serials := make([]string, 0, len(boms))
+
+	for i := range boms {
+		if _, ok := seen[boms[i].SerialNum]; ok {
+			continue
+		}
+
+		seen[boms[i].SerialNum] = struct{}{}
+		serials = append(serials, boms[i].SerialNum)
+	}
+
+	found, err := lookupSerials(ctx, repository, serials, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make([]fleetdbapi.Bom, 0, len(found))
+
+	for _, bom := range found {
+		if bom != nil {
+			stored = append(stored, *bom)
+		}
+	}
+
+	return stored, nil
+}
+
+// Collision is a MAC address claimed by more than one serial number.
+type Collision struct {
+	MacAddress string `json:"mac_address"`
+	// Serials are the serial numbers claiming the address in the uploaded BOMs.
+	Serials []string `json:"serials"`
+	// ExistingSerials are the other serial numbers claiming the address in the stored BOMs.
+	ExistingSerials []string `json:"existing_serials,omitempty"`
+	// SameRole is true when two of the serial numbers claim the address in the same role,
+	// fleetdb stores each address once per role so such a collision can not be stored.
+	SameRole bool `json:"same_role,omitempty"`
+}
+
+// SameRole returns the collisions claiming the address in the same role.
+func SameRole(collisions []Collision) []Collision {
+	var same []Collision
+
+	for _, c := range collisions {
+		if c.SameRole {
+			same = append(same, c)
+		}
+	}
+
+	return same
+}
+
+// Collisions returns the MAC addresses in the BOMs claimed by more than one serial number,
+// either within the BOMs or by a different serial in the stored BOMs, looked up with up to workers concurrent store lookups.
+func Collisions(ctx context.Context, repository store.Repository, boms []fleetdbapi.Bom, workers int) ([]Collision, error) {
+	// MAC addresses are compared normalized, and looked up as uploaded since that is how they are stored.
+	claims := map[string]map[string]struct{}{}
+	// roles are the serial numbers claiming each address by role.
+	roles := map[string]map[Role]map[string]struct{}{}
+	uploaded := map[string]string{}
+
+	for i := range boms {
+		fields := []struct {
+			role  Role
+			value string
+		}{
+			{RoleAOC, boms[i].AocMacAddress},
+			{RoleBMC, boms[i].BmcMacAddress},
+		}
+
+		for _, field := range fields {
+			for _, raw := range splitRaw(field.value) {
+				mac := model.NormalizeMacAddr(raw)
+				if _, ok := claims[mac]; !ok {
+					claims[mac] = map[string]struct{}{}
+					roles[mac] = map[Role]map[string]struct{}{}
+					uploaded[mac] = raw
+				}
+
+				if _, ok := roles[mac][field.role]; !ok {
+					roles[mac][field.role] = map[string]struct{}{}
+				}
+
+				claims[mac][boms[i].SerialNum] = struct{}{}
+				roles[mac][field.role][boms[i].SerialNum] = struct{}{}
+			}
+		}
+	}
+
+	macs := make([]string, 0, len(claims))
+	for mac := range claims {
+		macs = append(macs, mac)
+	}
+
+	sort.Strings(macs)
+
+	existing := make([][]Match, len(macs))
+
+	err := ForEach(ctx, len(macs), workers, func(ctx context.Context, i int) error {
+		matches, err := existingMatches(ctx, repository, uploaded[macs[i]], claims[macs[i]])
+		existing[i] = matches
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var collisions []Collision
+
+	for i, mac := range macs {
+		collision := Collision{MacAddress: mac, Serials: sortedKeys(claims[mac])}
+
+		for _, serials := range roles[mac] {
+			if len(serials) > 1 {
+				collision.SameRole = true
+			}
+		}
+
+		others := make(map[string]struct{}, len(existing[i]))
+
+		for _, m := range existing[i] {
+			others[m.Bom.SerialNum] = struct{}{}
+
+			if len(roles[mac][m.Role]) > 0 {
+				collision.SameRole = true
+			}
+		}
+
+		collision.ExistingSerials = sortedKeys(others)
+
+		if len(collision.Serials) > 1 || len(collision.ExistingSerials) > 0 {
+			collisions = append(collisions, collision)
+		}
+	}
+
+	return collisions, nil
+}
+
+// existingMatches returns the stored BOMs listing the MAC address with serial numbers not in claimed.
+func existingMatches(ctx context.Context, repository store.Repository, macAddr string, claimed map[string]struct{}) ([]Match, error) {
+	result, err := Mac(ctx, repository, macAddr)
+	if err != nil {
+		if errors.Is(err, store.ErrBomNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var others []Match
+
+	for _, m := range result.Matches {
+		if _, ok := claimed[m.Bom.SerialNum]; !ok {
+			others = append(others, m)
+		}
+	}
+
+	return others, nil
+}
+
+// splitRaw splits a comma separated BOM field without normalizing the values.
+func splitRaw(field string) []string {
+	var values []string
+
+	for _, v := range strings.Split(field, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
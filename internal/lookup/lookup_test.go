@@ -0,0 +1,150 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	// the re-uploaded serial-1 bom is stored, serial-3 is stored with a MAC now claimed by serial-2.
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "aa:aa:aa:aa:aa:01").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-1"}, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bb:bb:bb:bb:bb:02").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-3"}, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+
+	boms := []fleetdbapi.Bom{
+		{SerialNum: "serial-1", AocMacAddress: "aa:aa:aa:aa:aa:01", BmcMacAddress: "AA:AA:AA:AA:AA:FF"},
+		{SerialNum: "serial-2", AocMacAddress: "aa:aa:aa:aa:aa:ff", BmcMacAddress: "bb:bb:bb:bb:bb:02"},
+	}
+
+	collisions, err := Collisions(context.TODO(), repository, boms, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Collision{
+		{MacAddress: "aa:aa:aa:aa:aa:ff", Serials: []string{"serial-1", "serial-2"}},
+		{MacAddress: "bb:bb:bb:bb:bb:02", Serials: []string{"serial-2"}, ExistingSerials: []string{"serial-3"}, SameRole: true},
+	}
+
+	assert.Equal(t, expected, collisions)
+	assert.Equal(t, expected[1:], SameRole(collisions))
+}
+
+func TestStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	// serial-1 is looked up once although uploaded twice, serial-2 is not stored.
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), "serial-1").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-1", Metro: "am6"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), "serial-2").
+		Return(nil, nil, store.ErrBomNotFound).
+		Times(1)
+
+	boms := []fleetdbapi.Bom{{SerialNum: "serial-1"}, {SerialNum: "serial-2"}, {SerialNum: "serial-1"}}
+
+	stored, err := Stored(context.TODO(), repository, boms, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []fleetdbapi.Bom{{SerialNum: "serial-1", Metro: "am6"}}, stored)
+}
+
+func TestBulk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	for _, mac := range []string{"mac-1", "mac-2", "mac-3"} {
+		repository.EXPECT().
+			GetBomInfoByBMCMacAddr(gomock.Any(), mac).
+			Return(&fleetdbapi.Bom{SerialNum: "serial-" + mac, BmcMacAddress: mac}, &fleetdbapi.ServerResponse{}, nil).
+			Times(1)
+	}
+
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), "serial-b").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-b"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), "unknown-serial").
+		Return(nil, nil, store.ErrBomNotFound).
+		Times(1)
+
+	req := &BulkRequest{
+		MacAddresses: []string{"mac-3", "unknown-mac", "mac-1", "mac-2"},
+		SerialNums:   []string{"serial-b", "unknown-serial"},
+	}
+
+	result, err := Bulk(context.TODO(), repository, req, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	macs := []string{}
+	for _, r := range result.MacAddresses {
+		macs = append(macs, r.MacAddress)
+	}
+
+	assert.Equal(t, []string{"mac-3", "mac-1", "mac-2"}, macs)
+	assert.Equal(t, []fleetdbapi.Bom{{SerialNum: "serial-b"}}, result.SerialNums)
+	assert.Equal(t, []string{"unknown-mac"}, result.NotFound.MacAddresses)
+	assert.Equal(t, []string{"unknown-serial"}, result.NotFound.SerialNums)
+}
+
+func TestBulkError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, errors.New("fleetdb unavailable")).
+		AnyTimes()
+
+	_, err := Bulk(context.TODO(), repository, &BulkRequest{MacAddresses: []string{"mac-1", "mac-2", "mac-3"}}, 2)
+	assert.EqualError(t, err, "fleetdb unavailable")
+
+	_, err = Bulk(context.TODO(), repository, &BulkRequest{}, 2)
+	assert.ErrorIs(t, err, ErrBulkRequest)
+}
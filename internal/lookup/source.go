@@ -0,0 +1,116 @@
+package lookup
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// serverPageSize is the number of servers requested per page by ServerSource.
+	serverPageSize = 100
+
+	// serverOrder lists the most recently updated servers first, so a refresh stops at its cursor.
+	serverOrder = "servers.updated_at DESC"
+
+	// sinceOverlap re-lists the servers updated shortly before the cursor,
+	// since the attributes of an enrolled server are written after its record.
+	sinceOverlap = time.Minute
+)
+
+var (
+	ErrSource = errors.New("error listing fleetdb server boms")
+)
+
+// ServerSource returns the boms of the servers enrolled in fleetdb, it implements store.IndexSource.
+//
+// fleetdb does not list the stored boms, so the servers are listed by update time and each bom is looked up
+// by the serial in the server vendor attributes. Boms of serials that are not enrolled are only returned
+// when their serial numbers are asked for.
+type ServerSource struct {
+	fleetdb    inventory.ServerLister
+	repository store.Repository
+	workers    int
+}
+
+var _ store.IndexSource = (*ServerSource)(nil)
+
+// NewServerSource returns a ServerSource looking up boms in the repository with up to workers concurrent lookups.
+func NewServerSource(fleetdb inventory.ServerLister, repository store.Repository, workers int) *ServerSource {
+	return &ServerSource{fleetdb: fleetdb, repository: repository, workers: workers}
+}
+
+// Changed returns the boms of the servers updated after since and the latest server update time,
+// the zero time returns the boms of every server.
+func (s *ServerSource) Changed(ctx context.Context, since time.Time) ([]fleetdbapi.Bom, time.Time, error) {
+	next := since
+	after := since.Add(-sinceOverlap)
+
+	var serials []string
+
+	for page := 1; ; page++ {
+		params := &fleetdbapi.ServerListParams{
+			PaginationParams: &fleetdbapi.PaginationParams{Limit: serverPageSize, Page: page, Preload: true, OrderBy: serverOrder},
+		}
+
+		servers, _, err := s.fleetdb.List(ctx, params)
+		if err != nil {
+			return nil, since, errors.Wrap(ErrSource, err.Error())
+		}
+
+		done := len(servers) < serverPageSize
+
+		for i := range servers {
+			if !since.IsZero() && !servers[i].UpdatedAt.After(after) {
+				done = true
+				break
+			}
+
+			if servers[i].UpdatedAt.After(next) {
+				next = servers[i].UpdatedAt
+			}
+
+			if serial := inventory.SerialNum(&servers[i]); serial != "" {
+				serials = append(serials, serial)
+			}
+		}
+
+		if done {
+			break
+		}
+	}
+
+	boms, err := s.Boms(ctx, serials)
+	if err != nil {
+		return nil, since, err
+	}
+
+	return boms, next, nil
+}
+
+// Boms returns the stored boms of the serial numbers, serial numbers not stored are skipped.
+func (s *ServerSource) Boms(ctx context.Context, serials []string) ([]fleetdbapi.Bom, error) {
+	serials = slices.Clone(serials)
+	slices.Sort(serials)
+	serials = slices.Compact(serials)
+
+	found, err := lookupSerials(ctx, s.repository, serials, s.workers)
+	if err != nil {
+		return nil, errors.Wrap(ErrSource, err.Error())
+	}
+
+	boms := make([]fleetdbapi.Bom, 0, len(found))
+
+	for _, bom := range found {
+		if bom != nil {
+			boms = append(boms, *bom)
+		}
+	}
+
+	return boms, nil
+}
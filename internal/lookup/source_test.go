@@ -0,0 +1,99 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServerLister serves a single page of servers and counts the pages requested.
+type fakeServerLister struct {
+	servers []fleetdbapi.Server
+	pages   int
+}
+
+func (f *fakeServerLister) List(_ context.Context, params *fleetdbapi.ServerListParams) ([]fleetdbapi.Server, *fleetdbapi.ServerResponse, error) {
+	f.pages++
+
+	if params.PaginationParams.OrderBy != serverOrder || params.PaginationParams.Page > 1 {
+		return nil, &fleetdbapi.ServerResponse{}, nil
+	}
+
+	return f.servers, &fleetdbapi.ServerResponse{}, nil
+}
+
+func testServer(serial string, updated time.Time) fleetdbapi.Server {
+	return fleetdbapi.Server{
+		UpdatedAt: updated,
+		Attributes: []fleetdbapi.Attributes{{
+			Namespace: inventory.VendorAttributesNamespace,
+			Data:      json.RawMessage(`{"serial":"` + serial + `"}`),
+		}},
+	}
+}
+
+func TestServerSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	// servers are listed most recently updated first, serial-4 has no stored bom.
+	fleetdb := &fakeServerLister{
+		servers: []fleetdbapi.Server{
+			testServer("serial-3", now),
+			testServer("serial-4", now.Add(-time.Minute)),
+			testServer("serial-2", now.Add(-90*time.Second)),
+			testServer("serial-1", now.Add(-time.Hour)),
+		},
+	}
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), "serial-4").
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			return &fleetdbapi.Bom{SerialNum: serial}, &fleetdbapi.ServerResponse{}, nil
+		}).
+		AnyTimes()
+
+	source := NewServerSource(fleetdb, repository, 2)
+
+	boms, next, err := source.Changed(context.TODO(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []fleetdbapi.Bom{{SerialNum: "serial-1"}, {SerialNum: "serial-2"}, {SerialNum: "serial-3"}}, boms)
+	assert.Equal(t, now, next)
+
+	// servers updated up to a minute before the cursor are listed again, the listing stops at older ones.
+	fleetdb.pages = 0
+
+	boms, next, err = source.Changed(context.TODO(), now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []fleetdbapi.Bom{{SerialNum: "serial-2"}, {SerialNum: "serial-3"}}, boms)
+	assert.Equal(t, now, next)
+	assert.Equal(t, 1, fleetdb.pages)
+
+	boms, err = source.Boms(context.TODO(), []string{"serial-5", "serial-4", "serial-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []fleetdbapi.Bom{{SerialNum: "serial-5"}}, boms)
+}
@@ -1,58 +1,454 @@
 package metrics
 
 import (
-	"log"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
-	apiLatencySeconds *prometheus.HistogramVec
+const (
+	// CacheHit, CacheNegativeHit and CacheMiss are the store cache lookup results.
+	CacheHit         = "hit"
+	CacheNegativeHit = "negative_hit"
+	CacheMiss        = "miss"
+
+	// DefaultListenAddress is the metrics listen address when none is configured.
+	DefaultListenAddress = "0.0.0.0:9090"
+
+	// UnknownMetro labels the stored boms not tagged with a metro.
+	UnknownMetro = "unknown"
+
+	// ConfigReloadSuccess and ConfigReloadFailure are the configuration reload results.
+	ConfigReloadSuccess = "success"
+	ConfigReloadFailure = "failure"
+
+	// LimitRate and LimitUploadConcurrency are the limits a throttled request exceeded.
+	LimitRate              = "rate"
+	LimitUploadConcurrency = "upload_concurrency"
+
+	// unmatchedEndpoint labels requests not matching a route, so the raw path is never a label value.
+	unmatchedEndpoint = "unmatched"
 )
 
+// Metrics holds the bomservice collectors registered with a single registry.
+type Metrics struct {
+	apiLatencySeconds *prometheus.HistogramVec
+
+	cacheLookupsTotal       *prometheus.CounterVec
+	cacheEvictionsTotal     prometheus.Counter
+	cacheInvalidationsTotal prometheus.Counter
+
+	uploadRowsParsedTotal   prometheus.Counter
+	uploadBomsWrittenTotal  prometheus.Counter
+	uploadParseErrorsTotal  *prometheus.CounterVec
+	storeCallLatencySeconds *prometheus.HistogramVec
+	storeCallErrorsTotal    *prometheus.CounterVec
+	bomsStored              *prometheus.GaugeVec
+
+	configReloadsTotal          *prometheus.CounterVec
+	configLastReloadSuccessTime prometheus.Gauge
+
+	apiKeyRequestsTotal *prometheus.CounterVec
+	apiKeyLastUsedTime  *prometheus.GaugeVec
+
+	throttledRequestsTotal     *prometheus.CounterVec
+	rateLimitRequestsPerSecond prometheus.Gauge
+	rateLimitBurst             prometheus.Gauge
+	rateLimitClients           prometheus.Gauge
+	uploadConcurrencyLimit     prometheus.Gauge
+	uploadsInFlight            prometheus.Gauge
+}
+
+// current is the Metrics recorded by the package functions.
+var current atomic.Pointer[Metrics]
+
 func init() {
-	apiLatencySeconds = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Namespace: "bomservice",
-			Subsystem: "api",
-			Name:      "latency_seconds",
-			Help:      "api latency measurements in seconds",
-			// XXX: will need to tune these buckets once we understand common behaviors better
-			// buckets between 25ms to 10 s
-			Buckets: []float64{0.025, 0.05, 0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10.0},
-		}, []string{
-			"endpoint",
-			"response_code",
-		},
-	)
-}
-
-// ListenAndServeMetrics exposes prometheus metrics as /metrics on port 9090
-func ListenAndServe() {
-	endpoint := "0.0.0.0:9090"
-
-	go func() {
-		http.Handle("/metrics", promhttp.Handler())
-
-		server := &http.Server{
-			Addr:              endpoint,
-			ReadHeaderTimeout: 2 * time.Second,
-		}
+	// recorded in a registry nothing gathers until Use sets the Metrics of the server.
+	current.Store(New(prometheus.NewRegistry()))
+}
 
-		if err := server.ListenAndServe(); err != nil {
-			log.Println(err)
-		}
-	}()
+// Option sets a parameter on the Metrics type.
+type Option func(*options)
+
+type options struct {
+	bomsStored bool
+}
+
+// WithBomsStored registers the stored boms gauge, reported by the in-memory index refresh.
+func WithBomsStored() Option {
+	return func(o *options) {
+		o.bomsStored = true
+	}
+}
+
+// New returns Metrics with the collectors registered on the registerer.
+func New(registerer prometheus.Registerer, opts ...Option) *Metrics {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	factory := promauto.With(registerer)
+
+	m := &Metrics{
+		apiLatencySeconds: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "bomservice",
+				Subsystem: "api",
+				Name:      "latency_seconds",
+				Help:      "api latency measurements in seconds by route",
+				// XXX: will need to tune these buckets once we understand common behaviors better
+				// buckets between 25ms to 10 s
+				Buckets: []float64{0.025, 0.05, 0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10.0},
+			}, []string{
+				"endpoint",
+				"response_code",
+			},
+		),
+
+		cacheLookupsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "store_cache",
+				Name:      "lookups_total",
+				Help:      "store cache lookups by mac address index and result",
+			}, []string{
+				"index",
+				"result",
+			},
+		),
+
+		cacheEvictionsTotal: factory.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "store_cache",
+				Name:      "evictions_total",
+				Help:      "store cache entries evicted to stay within the size limit",
+			},
+		),
+
+		cacheInvalidationsTotal: factory.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "store_cache",
+				Name:      "invalidations_total",
+				Help:      "store cache entries invalidated by bom uploads",
+			},
+		),
+
+		uploadRowsParsedTotal: factory.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "upload",
+				Name:      "rows_parsed_total",
+				Help:      "xlsx rows parsed from uploaded bom files",
+			},
+		),
+
+		uploadBomsWrittenTotal: factory.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "upload",
+				Name:      "boms_written_total",
+				Help:      "boms written to the store by uploads",
+			},
+		),
+
+		uploadParseErrorsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "upload",
+				Name:      "parse_errors_total",
+				Help:      "uploaded bom file parse errors by error code",
+			}, []string{
+				"code",
+			},
+		),
+
+		storeCallLatencySeconds: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "bomservice",
+				Subsystem: "fleetdb",
+				Name:      "latency_seconds",
+				Help:      "fleetdb call latency in seconds by repository method",
+				Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0},
+			}, []string{
+				"method",
+			},
+		),
+
+		storeCallErrorsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "fleetdb",
+				Name:      "errors_total",
+				Help:      "fleetdb call errors by repository method, lookups of unknown boms are not errors",
+			}, []string{
+				"method",
+			},
+		),
+
+		configReloadsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "config",
+				Name:      "reloads_total",
+				Help:      "configuration file reloads by result, rejected configurations are failures",
+			}, []string{
+				"result",
+			},
+		),
+
+		configLastReloadSuccessTime: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "config",
+				Name:      "last_reload_success_timestamp_seconds",
+				Help:      "unix time of the last applied configuration reload",
+			},
+		),
+
+		apiKeyRequestsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "api_key",
+				Name:      "requests_total",
+				Help:      "API requests authenticated by API key",
+			}, []string{
+				"key_id",
+			},
+		),
+
+		apiKeyLastUsedTime: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "api_key",
+				Name:      "last_used_timestamp_seconds",
+				Help:      "unix time an API key last authenticated a request",
+			}, []string{
+				"key_id",
+			},
+		),
+
+		throttledRequestsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "bomservice",
+				Subsystem: "api",
+				Name:      "throttled_requests_total",
+				Help:      "API requests rejected with 429 by route and the limit exceeded",
+			}, []string{
+				"endpoint",
+				"limit",
+			},
+		),
+
+		rateLimitRequestsPerSecond: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "rate_limit",
+				Name:      "requests_per_second",
+				Help:      "sustained request rate allowed per client, zero when rate limiting is disabled",
+			},
+		),
+
+		rateLimitBurst: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "rate_limit",
+				Name:      "burst",
+				Help:      "requests a client may make above the sustained rate",
+			},
+		),
+
+		rateLimitClients: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "rate_limit",
+				Name:      "clients",
+				Help:      "clients with a tracked request rate",
+			},
+		),
+
+		uploadConcurrencyLimit: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "upload",
+				Name:      "concurrency_limit",
+				Help:      "maximum concurrent uploads, zero when unlimited",
+			},
+		),
+
+		uploadsInFlight: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Subsystem: "upload",
+				Name:      "in_flight",
+				Help:      "uploads being processed",
+			},
+		),
+	}
+
+	if o.bomsStored {
+		m.bomsStored = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "bomservice",
+				Name:      "boms_stored",
+				Help:      "boms held by the in-memory index by metro",
+			}, []string{
+				"metro",
+			},
+		)
+	}
+
+	return m
 }
 
-// APICallEpilog observes the results and latency of an API call
+// Use sets the Metrics recorded by the package functions.
+func Use(m *Metrics) {
+	current.Store(m)
+}
+
+// NewRegistry returns a registry with the go runtime and process collectors registered.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return registry
+}
+
+// NewServer returns a server exposing the gathered metrics as /metrics on the address.
+func NewServer(address string, gatherer prometheus.Gatherer) *http.Server {
+	if address == "" {
+		address = DefaultListenAddress
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+}
+
+// APICallEpilog observes the results and latency of an API call to the route template endpoint.
 func APICallEpilog(start time.Time, endpoint string, responseCode int) {
+	if endpoint == "" {
+		endpoint = unmatchedEndpoint
+	}
+
 	code := strconv.Itoa(responseCode)
 	elapsed := time.Since(start).Seconds()
-	apiLatencySeconds.WithLabelValues(endpoint, code).Observe(elapsed)
+	current.Load().apiLatencySeconds.WithLabelValues(endpoint, code).Observe(elapsed)
+}
+
+// CacheLookup counts a store cache lookup on the mac address index with the given result.
+func CacheLookup(index, result string) {
+	current.Load().cacheLookupsTotal.WithLabelValues(index, result).Inc()
+}
+
+// CacheEviction counts a store cache entry evicted to stay within the size limit.
+func CacheEviction() {
+	current.Load().cacheEvictionsTotal.Inc()
+}
+
+// CacheInvalidations counts store cache entries invalidated by a bom upload.
+func CacheInvalidations(n int) {
+	current.Load().cacheInvalidationsTotal.Add(float64(n))
+}
+
+// UploadRowsParsed counts the xlsx rows parsed from an uploaded file.
+func UploadRowsParsed(n int) {
+	current.Load().uploadRowsParsedTotal.Add(float64(n))
+}
+
+// UploadBomsWritten counts the boms written to the store by an upload.
+func UploadBomsWritten(n int) {
+	current.Load().uploadBomsWrittenTotal.Add(float64(n))
+}
+
+// UploadParseError counts an uploaded file parse error with the error code.
+func UploadParseError(code string) {
+	current.Load().uploadParseErrorsTotal.WithLabelValues(code).Inc()
+}
+
+// StoreCall observes the latency of a fleetdb call by the repository method, counting it as an error when failed is set.
+func StoreCall(method string, start time.Time, failed bool) {
+	m := current.Load()
+	m.storeCallLatencySeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	if failed {
+		m.storeCallErrorsTotal.WithLabelValues(method).Inc()
+	}
+}
+
+// BomsStored sets the stored bom counts keyed by metro, metros no longer listed are removed.
+// The counts are dropped unless the Metrics are created WithBomsStored.
+func BomsStored(counts map[string]int) {
+	m := current.Load()
+	if m.bomsStored == nil {
+		return
+	}
+
+	m.bomsStored.Reset()
+
+	for metro, n := range counts {
+		if metro == "" {
+			metro = UnknownMetro
+		}
+
+		m.bomsStored.WithLabelValues(metro).Add(float64(n))
+	}
+}
+
+// ConfigReload counts a configuration reload with the result, setting the last success time on success.
+func ConfigReload(result string) {
+	m := current.Load()
+	m.configReloadsTotal.WithLabelValues(result).Inc()
+
+	if result == ConfigReloadSuccess {
+		m.configLastReloadSuccessTime.SetToCurrentTime()
+	}
+}
+
+// APIKeyUsed records a request authenticated by the API key with the ID.
+func APIKeyUsed(id string) {
+	m := current.Load()
+	m.apiKeyRequestsTotal.WithLabelValues(id).Inc()
+	m.apiKeyLastUsedTime.WithLabelValues(id).SetToCurrentTime()
+}
+
+// Throttled counts a request to the route template endpoint rejected for exceeding the limit.
+func Throttled(endpoint, limit string) {
+	if endpoint == "" {
+		endpoint = unmatchedEndpoint
+	}
+
+	current.Load().throttledRequestsTotal.WithLabelValues(endpoint, limit).Inc()
+}
+
+// RateLimits sets the configured per-client request rate and burst.
+func RateLimits(requestsPerSecond float64, burst int) {
+	m := current.Load()
+	m.rateLimitRequestsPerSecond.Set(requestsPerSecond)
+	m.rateLimitBurst.Set(float64(burst))
+}
+
+// RateLimitClients sets the number of clients with a tracked request rate.
+func RateLimitClients(n int) {
+	current.Load().rateLimitClients.Set(float64(n))
+}
+
+// UploadConcurrency sets the configured upload concurrency limit and the uploads in flight.
+func UploadConcurrency(limit, inFlight int) {
+	m := current.Load()
+	m.uploadConcurrencyLimit.Set(float64(limit))
+	m.uploadsInFlight.Set(float64(inFlight))
 }
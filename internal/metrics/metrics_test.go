@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry, WithBomsStored())
+
+	previous := current.Load()
+	Use(m)
+
+	defer Use(previous)
+
+	APICallEpilog(time.Now(), "/api/v1/bomservice/mac/:mac_address", http.StatusOK)
+	APICallEpilog(time.Now(), "", http.StatusNotFound)
+	UploadRowsParsed(10)
+	UploadBomsWritten(2)
+	UploadParseError("empty_serial_num")
+	StoreCall("ListBoms", time.Now(), false)
+	StoreCall("ListBoms", time.Now(), true)
+
+	// the stored boms gauge has no series until the index reports them.
+	assert.Equal(t, 0, testutil.CollectAndCount(m.bomsStored))
+
+	BomsStored(map[string]int{"dc13": 3, "": 1})
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.apiLatencySeconds))
+	assert.Equal(t, float64(10), testutil.ToFloat64(m.uploadRowsParsedTotal))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.uploadBomsWrittenTotal))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.uploadParseErrorsTotal.WithLabelValues("empty_serial_num")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.storeCallErrorsTotal.WithLabelValues("ListBoms")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.bomsStored.WithLabelValues("dc13")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.bomsStored.WithLabelValues(UnknownMetro)))
+
+	// metros no longer stored are removed.
+	BomsStored(map[string]int{"dc13": 4})
+	assert.Equal(t, 1, testutil.CollectAndCount(m.bomsStored))
+
+	srv := httptest.NewServer(NewServer("", registry).Handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.Contains(string(body), `bomservice_api_latency_seconds_count{endpoint="unmatched",response_code="404"} 1`))
+	assert.True(t, strings.Contains(string(body), `bomservice_fleetdb_errors_total{method="ListBoms"} 1`))
+}
+
+func TestMetricsWithoutBomsStored(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	previous := current.Load()
+	Use(New(registry))
+
+	defer Use(previous)
+
+	// the counts are dropped without the index.
+	BomsStored(map[string]int{"dc13": 3})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, family := range families {
+		assert.NotEqual(t, "bomservice_boms_stored", family.GetName())
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"strings"
+)
+
+// NormalizeMacAddr returns the MAC address in lower case with surrounding whitespace removed,
+// so addresses from vendor files and fleetdb inventory can be compared.
+func NormalizeMacAddr(macAddr string) string {
+	return strings.ToLower(strings.TrimSpace(macAddr))
+}
+
+// SplitMacAddrs returns the normalized MAC addresses in a comma separated BOM field.
+func SplitMacAddrs(macAddrs string) []string {
+	if macAddrs == "" {
+		return nil
+	}
+
+	parts := strings.Split(macAddrs, ",")
+	addrs := make([]string, 0, len(parts))
+
+	for _, p := range parts {
+		if addr := NormalizeMacAddr(p); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
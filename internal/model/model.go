@@ -12,6 +12,12 @@ const (
 	// AppKindServer identifies a bomservice.
 	AppKindServer AppKind = "bomservice-server"
 
+	// AppKindCLI identifies a bomservice command that runs against the store and exits.
+	AppKindCLI AppKind = "bomservice-cli"
+
+	// StoreKindServerservice stores boms in fleetdb, formerly serverservice.
+	StoreKindServerservice = "serverservice"
+
 	LogLevelInfo  LogLevel = "info"
 	LogLevelDebug LogLevel = "debug"
 	LogLevelTrace LogLevel = "trace"
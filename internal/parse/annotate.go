@@ -0,0 +1,91 @@
+package parse
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	annotationAuthor     = "bomservice"
+	annotationFillColor  = "FFC7CE"
+	annotationSheetName  = "bomservice-errors"
+	annotationHeaderCell = "A1"
+)
+
+var (
+	ErrAnnotate = errors.New("error annotating xlsx file")
+)
+
+// Annotate returns the uploaded workbook with each invalid cell highlighted and commented with
+// its parser error, along with a summary sheet listing all the errors.
+//
+// The workbook is edited with excelize since the xlsx package used for parsing can't write cell comments.
+func Annotate(fileBytes []byte, cellErrs []*CellError) ([]byte, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		return nil, errors.Wrap(ErrAnnotate, err.Error())
+	}
+
+	defer file.Close()
+
+	style, err := file.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{annotationFillColor}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(ErrAnnotate, err.Error())
+	}
+
+	// the sheet is replaced when a previously annotated file is annotated again.
+	if idx, _ := file.GetSheetIndex(annotationSheetName); idx != -1 {
+		if err := file.DeleteSheet(annotationSheetName); err != nil {
+			return nil, errors.Wrap(ErrAnnotate, err.Error())
+		}
+	}
+
+	if _, err := file.NewSheet(annotationSheetName); err != nil {
+		return nil, errors.Wrap(ErrAnnotate, err.Error())
+	}
+
+	if err := file.SetSheetRow(annotationSheetName, annotationHeaderCell, &[]string{"Sheet", "Cell", "Error"}); err != nil {
+		return nil, errors.Wrap(ErrAnnotate, err.Error())
+	}
+
+	for i, cellErr := range cellErrs {
+		cell := cellErr.Cell()
+
+		if err := file.SetCellStyle(cellErr.Sheet, cell, cell, style); err != nil {
+			return nil, errors.Wrap(ErrAnnotate, err.Error())
+		}
+
+		comment := excelize.Comment{
+			Author: annotationAuthor,
+			Cell:   cell,
+			Text:   cellErr.Err.Error(),
+		}
+
+		if err := file.AddComment(cellErr.Sheet, comment); err != nil {
+			return nil, errors.Wrap(ErrAnnotate, err.Error())
+		}
+
+		// summary rows start below the header row.
+		summaryCell, _ := excelize.CoordinatesToCellName(1, i+2)
+		row := []string{cellErr.Sheet, cell, cellErr.Err.Error()}
+
+		if err := file.SetSheetRow(annotationSheetName, summaryCell, &row); err != nil {
+			return nil, errors.Wrap(ErrAnnotate, err.Error())
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, errors.Wrap(ErrAnnotate, err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+func isAnnotationSheet(name string) bool {
+	return name == annotationSheetName
+}
@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestAnnotate(t *testing.T) {
+	var testCases = []struct {
+		testName     string
+		filePath     string
+		expectedErr  error
+		expectedCell string
+	}{
+		{
+			testName:     "file missing bmcMacAddress",
+			filePath:     "./testdata/test_empty_bmcMacAddress.xlsx",
+			expectedErr:  ErrEmptyBMCMacAddr,
+			expectedCell: "F18",
+		},
+		{
+			testName:     "file missing serial number",
+			filePath:     "./testdata/test_empty_serial.xlsx",
+			expectedErr:  ErrEmptySerialNum,
+			expectedCell: "D2",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.testName, func(t *testing.T) {
+			bs, err := os.ReadFile(tt.filePath)
+			if err != nil {
+				t.Fatalf("os.ReadFile(%v) failed %v", tt.filePath, err)
+			}
+
+			cellErrs, err := ValidateXlsxFileWithMapping(bs, DefaultMapping())
+			if err != nil {
+				t.Fatalf("ValidateXlsxFileWithMapping() failed %v", err)
+			}
+
+			if len(cellErrs) == 0 {
+				t.Fatalf("test %v expected cell errors, got none", tt.testName)
+			}
+
+			if !errors.Is(cellErrs[0], tt.expectedErr) {
+				t.Fatalf("test %v got error %v, expect %v", tt.testName, cellErrs[0], tt.expectedErr)
+			}
+
+			annotated, err := Annotate(bs, cellErrs)
+			if err != nil {
+				t.Fatalf("Annotate() failed %v", err)
+			}
+
+			file, err := excelize.OpenReader(bytes.NewReader(annotated))
+			if err != nil {
+				t.Fatalf("annotated file failed to open %v", err)
+			}
+			defer file.Close()
+
+			comments, err := file.GetComments(cellErrs[0].Sheet)
+			if err != nil {
+				t.Fatalf("GetComments() failed %v", err)
+			}
+
+			if len(comments) != len(cellErrs) {
+				t.Fatalf("test %v got %v comments, expect %v", tt.testName, len(comments), len(cellErrs))
+			}
+
+			if comments[0].Cell != tt.expectedCell {
+				t.Fatalf("test %v got comment on cell %v, expect %v", tt.testName, comments[0].Cell, tt.expectedCell)
+			}
+
+			rows, err := file.GetRows(annotationSheetName)
+			if err != nil {
+				t.Fatalf("summary sheet missing %v", err)
+			}
+
+			// header row followed by one row per error.
+			if len(rows) != len(cellErrs)+1 {
+				t.Fatalf("test %v got %v summary rows, expect %v", tt.testName, len(rows), len(cellErrs)+1)
+			}
+		})
+	}
+}
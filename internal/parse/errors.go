@@ -1,7 +1,63 @@
 package parse
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tealeg/xlsx/v3"
+)
 
 var (
 	ErrInvalidXslxFile = errors.New("invalid xlsx file")
+	ErrEmptySerialNum  = errors.New("empty serial number")
+	ErrEmptyAOCMacAddr = errors.New("empty aoc mac address")
+	ErrEmptyBMCMacAddr = errors.New("empty bmc mac address")
+	ErrMissingColumn   = errors.New("missing colomn")
+
+	// errSkipSheet stops parsing the rows of the current sheet.
+	errSkipSheet = errors.New("skip sheet")
 )
+
+// CellError is returned for an invalid cell in an xlsx file.
+type CellError struct {
+	// Sheet is the name of the sheet holding the cell.
+	Sheet string
+	// Row and Col are the zero based coordinates of the cell.
+	Row int
+	Col int
+	// Err is the parser error for the cell.
+	Err error
+}
+
+// Error returns the parser error along with the cell location.
+func (e *CellError) Error() string {
+	return fmt.Sprintf("%s (sheet %s, cell %s)", e.Err.Error(), e.Sheet, e.Cell())
+}
+
+// Unwrap returns the parser error for the cell.
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
+// Cell returns the cell reference in the A1 notation.
+func (e *CellError) Cell() string {
+	return xlsx.GetCellIDStringFromCoords(e.Col, e.Row)
+}
+
+// ErrorCode returns a short code for the parser error, used to label metrics.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrEmptySerialNum):
+		return "empty_serial_num"
+	case errors.Is(err, ErrEmptyAOCMacAddr):
+		return "empty_aoc_mac_address"
+	case errors.Is(err, ErrEmptyBMCMacAddr):
+		return "empty_bmc_mac_address"
+	case errors.Is(err, ErrMissingColumn):
+		return "missing_column"
+	case errors.Is(err, ErrInvalidXslxFile):
+		return "invalid_file"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,102 @@
+package parse
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMapping = errors.New("invalid column mapping")
+)
+
+// Mapping defines the column headers and SUB-ITEM values expected in a vendor BOM sheet.
+//
+// Fields left empty in a configured mapping fall back to the values from DefaultMapping.
+type Mapping struct {
+	// SerialNumColumn is the header of the column holding the server serial number.
+	SerialNumColumn string `mapstructure:"serial_num_column"`
+	// SubItemColumn is the header of the column identifying the kind of value in the row.
+	SubItemColumn string `mapstructure:"sub_item_column"`
+	// SubSerialColumn is the header of the column holding the value of the sub-item.
+	SubSerialColumn string `mapstructure:"sub_serial_column"`
+
+	// AOCMacAddressItem is the sub-item value for AOC MAC address rows.
+	AOCMacAddressItem string `mapstructure:"aoc_mac_address_item"`
+	// BMCMacAddressItem is the sub-item value for BMC MAC address rows.
+	BMCMacAddressItem string `mapstructure:"bmc_mac_address_item"`
+	// IPMIUserItem is the sub-item value for default IPMI user rows.
+	IPMIUserItem string `mapstructure:"ipmi_user_item"`
+	// IPMIPasswordItem is the sub-item value for default IPMI password rows.
+	IPMIPasswordItem string `mapstructure:"ipmi_password_item"`
+}
+
+// DefaultMapping returns the column mapping used when none is configured.
+func DefaultMapping() *Mapping {
+	return &Mapping{
+		SerialNumColumn:   serialNumColName,
+		SubItemColumn:     subItemColName,
+		SubSerialColumn:   subSerialColName,
+		AOCMacAddressItem: aocFieldName,
+		BMCMacAddressItem: bmcFieldName,
+		IPMIUserItem:      ipmiFieldName,
+		IPMIPasswordItem:  ipwdFieldName,
+	}
+}
+
+// WithDefaults returns a copy of the mapping with empty fields set from DefaultMapping.
+func (m *Mapping) WithDefaults() *Mapping {
+	d := DefaultMapping()
+	if m == nil {
+		return d
+	}
+
+	merged := *m
+
+	setDefault := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+
+	setDefault(&merged.SerialNumColumn, d.SerialNumColumn)
+	setDefault(&merged.SubItemColumn, d.SubItemColumn)
+	setDefault(&merged.SubSerialColumn, d.SubSerialColumn)
+	setDefault(&merged.AOCMacAddressItem, d.AOCMacAddressItem)
+	setDefault(&merged.BMCMacAddressItem, d.BMCMacAddressItem)
+	setDefault(&merged.IPMIUserItem, d.IPMIUserItem)
+	setDefault(&merged.IPMIPasswordItem, d.IPMIPasswordItem)
+
+	return &merged
+}
+
+// SubItems returns the allowed SUB-ITEM values in the order they appear in a template.
+func (m *Mapping) SubItems() []string {
+	return []string{m.AOCMacAddressItem, m.BMCMacAddressItem, m.IPMIUserItem, m.IPMIPasswordItem}
+}
+
+// Validate checks the mapping headers and sub-item values are set and distinct.
+func (m *Mapping) Validate() error {
+	columns := []string{m.SerialNumColumn, m.SubItemColumn, m.SubSerialColumn}
+	if err := distinct("column", columns); err != nil {
+		return err
+	}
+
+	return distinct("sub-item", m.SubItems())
+}
+
+func distinct(kind string, values []string) error {
+	seen := make(map[string]struct{}, len(values))
+
+	for _, v := range values {
+		if v == "" {
+			return errors.Wrap(ErrMapping, "empty "+kind+" value")
+		}
+
+		if _, ok := seen[v]; ok {
+			return errors.Wrap(ErrMapping, "duplicate "+kind+" value "+v)
+		}
+
+		seen[v] = struct{}{}
+	}
+
+	return nil
+}
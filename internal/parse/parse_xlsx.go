@@ -1,12 +1,22 @@
 package parse
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/pkg/errors"
 	"github.com/tealeg/xlsx/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
 )
 
+// tracerName is the instrumentation scope of the parser spans.
+const tracerName = "github.com/metal-toolbox/bomservice/internal/parse"
+
 const (
 	// column name
 	serialNumColName string = "SERIALNUM"
@@ -20,6 +30,12 @@ const (
 	ipwdFieldName string = "NUM-DEFPWD"
 )
 
+// Stats counts the sheets and data rows parsed from a workbook.
+type Stats struct {
+	Sheets int
+	Rows   int
+}
+
 type categoryColNum struct {
 	serialNumCol int // column number of the serial number, -1 means no such column
 	subItemCol   int // column number of the sub-item, -1 means no such column
@@ -36,30 +52,124 @@ func newCategoryColNum() *categoryColNum {
 
 // ParseXlsxFile is the helper function to parse xlsx to boms.
 //
-//nolint:gocyclo,revive // this is inherently cyclomatic and yes, the name stutters
+//nolint:revive // yes, the name stutters
 func ParseXlsxFile(fileBytes []byte) ([]fleetdbapi.Bom, error) {
+	return ParseXlsxFileWithMapping(fileBytes, DefaultMapping())
+}
+
+// ParseXlsxFileWithMapping parses xlsx to boms, locating columns and sub-items with the given mapping.
+//
+// The first invalid cell found is returned as a *CellError.
+func ParseXlsxFileWithMapping(fileBytes []byte, mapping *Mapping) ([]fleetdbapi.Bom, error) {
+	boms, _, err := ParseXlsxFileContext(context.Background(), fileBytes, mapping)
+
+	return boms, err
+}
+
+// ParseXlsxFileContext is ParseXlsxFileWithMapping recording a span with the sheet and row counts,
+// the counts are returned along with the boms.
+func ParseXlsxFileContext(ctx context.Context, fileBytes []byte, mapping *Mapping) ([]fleetdbapi.Bom, *Stats, error) {
+	_, span := otel.Tracer(tracerName).Start(ctx, "ParseXlsxFile")
+	defer span.End()
+
+	stats := &Stats{}
+	defer endSpan(span, stats)
+
+	file, err := xlsx.OpenBinary(fileBytes)
+	if err != nil {
+		return nil, stats, spanError(span, errors.Wrap(ErrInvalidXslxFile, "failed to open the file"))
+	}
+
+	boms, err := parseSheets(file, mapping, stats, func(cellErr *CellError) error { return cellErr })
+	if err != nil {
+		return nil, stats, spanError(span, err)
+	}
+
+	span.SetAttributes(attribute.Int("bom.count", len(boms)))
+
+	return boms, stats, nil
+}
+
+// ValidateXlsxFileWithMapping parses the xlsx file and returns every invalid cell found,
+// instead of stopping at the first one as ParseXlsxFileWithMapping does.
+func ValidateXlsxFileWithMapping(fileBytes []byte, mapping *Mapping) ([]*CellError, error) {
+	return ValidateXlsxFileContext(context.Background(), fileBytes, mapping)
+}
+
+// ValidateXlsxFileContext is ValidateXlsxFileWithMapping recording a span with the sheet, row and invalid cell counts.
+func ValidateXlsxFileContext(ctx context.Context, fileBytes []byte, mapping *Mapping) ([]*CellError, error) {
+	_, span := otel.Tracer(tracerName).Start(ctx, "ValidateXlsxFile")
+	defer span.End()
+
+	stats := &Stats{}
+	defer endSpan(span, stats)
+
 	file, err := xlsx.OpenBinary(fileBytes)
 	if err != nil {
-		return nil, errors.New("failed to open the file")
+		return nil, spanError(span, errors.Wrap(ErrInvalidXslxFile, "failed to open the file"))
+	}
+
+	var cellErrs []*CellError
+
+	_, err = parseSheets(file, mapping, stats, func(cellErr *CellError) error {
+		cellErrs = append(cellErrs, cellErr)
+		return nil
+	})
+	if err != nil {
+		return nil, spanError(span, err)
 	}
 
+	span.SetAttributes(attribute.Int("xlsx.invalid_cells", len(cellErrs)))
+
+	return cellErrs, nil
+}
+
+func endSpan(span trace.Span, stats *Stats) {
+	span.SetAttributes(attribute.Int("xlsx.sheets", stats.Sheets), attribute.Int("xlsx.rows", stats.Rows))
+}
+
+func spanError(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	return err
+}
+
+// parseSheets parses the boms in each sheet counting them in stats, invalid cells are passed to report,
+// which returns a non-nil error to stop parsing.
+//
+//nolint:gocyclo // this is inherently cyclomatic
+func parseSheets(file *xlsx.File, mapping *Mapping, stats *Stats, report func(*CellError) error) ([]fleetdbapi.Bom, error) {
 	bomsMap := make(map[string]*fleetdbapi.Bom)
 
 	for _, sheet := range file.Sheets {
+		// the summary added by Annotate is skipped so a corrected file can be uploaded as is.
+		if isAnnotationSheet(sheet.Name) {
+			continue
+		}
+
+		stats.Sheets++
+
 		var categoryCol *categoryColNum
 
+		cellError := func(row, col int, err error) *CellError {
+			return &CellError{Sheet: sheet.Name, Row: row, Col: col, Err: err}
+		}
+
 		rowProcessor := func(row *xlsx.Row) error {
+			rowNum := row.GetCoordinate()
+
 			if categoryCol == nil {
 				categoryCol = newCategoryColNum()
 
 				cellProcessor := func(cell *xlsx.Cell) error {
 					i, _ := cell.GetCoordinates()
 					switch cell.Value {
-					case serialNumColName:
+					case mapping.SerialNumColumn:
 						categoryCol.serialNumCol = i
-					case subItemColName:
+					case mapping.SubItemColumn:
 						categoryCol.subItemCol = i
-					case subSerialColName:
+					case mapping.SubSerialColumn:
 						categoryCol.subSerialCol = i
 					}
 					return nil
@@ -67,17 +177,25 @@ func ParseXlsxFile(fileBytes []byte) ([]fleetdbapi.Bom, error) {
 				_ = row.ForEachCell(cellProcessor)
 
 				if categoryCol.serialNumCol == -1 || categoryCol.subItemCol == -1 || categoryCol.subSerialCol == -1 {
-					return errors.Errorf("missing colomn, serial num %v, sub-item %v, sub-serial %v", categoryCol.serialNumCol, categoryCol.subItemCol, categoryCol.subSerialCol)
+					err := fmt.Errorf("%w, serial num %v, sub-item %v, sub-serial %v", ErrMissingColumn, categoryCol.serialNumCol, categoryCol.subItemCol, categoryCol.subSerialCol)
+					if rerr := report(cellError(rowNum, 0, err)); rerr != nil {
+						return rerr
+					}
+
+					// the remaining rows can't be parsed without the columns.
+					return errSkipSheet
 				}
 
 				return nil
 			}
 
+			stats.Rows++
+
 			// There won't be any out of idex issue since any non-existing value will default to empty string.
 			serialNum := row.GetCell(categoryCol.serialNumCol).Value
 
 			if serialNum == "" {
-				return errors.New("empty serial number")
+				return report(cellError(rowNum, categoryCol.serialNumCol, ErrEmptySerialNum))
 			}
 
 			bom, ok := bomsMap[serialNum]
@@ -88,10 +206,10 @@ func ParseXlsxFile(fileBytes []byte) ([]fleetdbapi.Bom, error) {
 
 			v := row.GetCell(categoryCol.subSerialCol).Value
 			switch row.GetCell(categoryCol.subItemCol).Value {
-			case aocFieldName:
+			case mapping.AOCMacAddressItem:
 				aocMacAddress := v
 				if aocMacAddress == "" {
-					return errors.New("empty aoc mac address")
+					return report(cellError(rowNum, categoryCol.subSerialCol, ErrEmptyAOCMacAddr))
 				}
 
 				if bom.AocMacAddress != "" {
@@ -99,10 +217,10 @@ func ParseXlsxFile(fileBytes []byte) ([]fleetdbapi.Bom, error) {
 				}
 
 				bom.AocMacAddress += aocMacAddress
-			case bmcFieldName:
+			case mapping.BMCMacAddressItem:
 				bmcMacAddress := v
 				if bmcMacAddress == "" {
-					return errors.New("empty bmc mac address")
+					return report(cellError(rowNum, categoryCol.subSerialCol, ErrEmptyBMCMacAddr))
 				}
 
 				if bom.BmcMacAddress != "" {
@@ -110,15 +228,15 @@ func ParseXlsxFile(fileBytes []byte) ([]fleetdbapi.Bom, error) {
 				}
 
 				bom.BmcMacAddress += bmcMacAddress
-			case ipmiFieldName:
+			case mapping.IPMIUserItem:
 				bom.NumDefiPmi = v
-			case ipwdFieldName:
+			case mapping.IPMIPasswordItem:
 				bom.NumDefPWD = v
 			}
 			return nil
 		}
 		err := sheet.ForEachRow(rowProcessor)
-		if err != nil {
+		if err != nil && !errors.Is(err, errSkipSheet) {
 			return nil, err
 		}
 	}
@@ -2,6 +2,7 @@ package parse
 
 import (
 	"bufio"
+	"context"
 	"io"
 	"os"
 	"reflect"
@@ -10,6 +11,11 @@ import (
 	"testing"
 
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 var testSerialNumBomInfo1 = fleetdbapi.Bom{
@@ -136,3 +142,53 @@ func TestParseXlsxFile(t *testing.T) {
 		})
 	}
 }
+
+func TestParseXlsxFileContextSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+
+	defer otel.SetTracerProvider(previous)
+
+	bs, err := os.ReadFile("./testdata/test_valid_one_bom.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boms, stats, err := ParseXlsxFileContext(context.Background(), bs, DefaultMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+
+	if spans[0].Name != "ParseXlsxFile" {
+		t.Fatalf("span name %q, expect ParseXlsxFile", spans[0].Name)
+	}
+
+	if got := attrs["bom.count"].AsInt64(); got != int64(len(boms)) {
+		t.Fatalf("bom.count %d, expect %d", got, len(boms))
+	}
+
+	if attrs["xlsx.sheets"].AsInt64() != int64(stats.Sheets) || attrs["xlsx.rows"].AsInt64() != int64(stats.Rows) || stats.Rows < 1 {
+		t.Fatalf("expect sheet and row counts %+v, got %v", stats, spans[0].Attributes)
+	}
+
+	if _, _, err := ParseXlsxFileContext(context.Background(), []byte("not xlsx"), DefaultMapping()); err == nil {
+		t.Fatal("expect error parsing invalid file")
+	}
+
+	if spans := exporter.GetSpans(); spans[1].Status.Code != codes.Error {
+		t.Fatalf("expect error span status, got %v", spans[1].Status)
+	}
+}
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/tealeg/xlsx/v3"
+)
+
+const (
+	templateSheetName = "BOM"
+	// templateValidatedRows is the number of rows below the header the SUB-ITEM drop-down applies to.
+	templateValidatedRows = 5000
+
+	templateExampleSerial       = "EXAMPLE-SERIAL-1"
+	templateExampleAOCMacAddr   = "b4:96:91:00:00:01"
+	templateExampleBMCMacAddr   = "b4:96:91:00:00:02"
+	templateExampleIPMIUser     = "ADMIN"
+	templateExampleIPMIPassword = "EXAMPLE-PASSWORD"
+)
+
+var (
+	ErrTemplate = errors.New("error generating xlsx template")
+)
+
+// Template returns an xlsx workbook that ParseXlsxFileWithMapping accepts for the given mapping.
+//
+// The workbook has a header row, a drop-down on the SUB-ITEM column listing the allowed values
+// and example rows for a single serial number.
+func Template(mapping *Mapping) ([]byte, error) {
+	file := xlsx.NewFile()
+
+	sheet, err := file.AddSheet(templateSheetName)
+	if err != nil {
+		return nil, errors.Wrap(ErrTemplate, err.Error())
+	}
+
+	headerStyle := xlsx.NewStyle()
+	headerStyle.Font.Bold = true
+	headerStyle.ApplyFont = true
+
+	header := sheet.AddRow()
+	for _, name := range []string{mapping.SerialNumColumn, mapping.SubItemColumn, mapping.SubSerialColumn} {
+		cell := header.AddCell()
+		cell.SetString(name)
+		cell.SetStyle(headerStyle)
+	}
+
+	examples := [][2]string{
+		{mapping.AOCMacAddressItem, templateExampleAOCMacAddr},
+		{mapping.BMCMacAddressItem, templateExampleBMCMacAddr},
+		{mapping.IPMIUserItem, templateExampleIPMIUser},
+		{mapping.IPMIPasswordItem, templateExampleIPMIPassword},
+	}
+
+	for _, example := range examples {
+		row := sheet.AddRow()
+		row.AddCell().SetString(templateExampleSerial)
+		row.AddCell().SetString(example[0])
+		row.AddCell().SetString(example[1])
+	}
+
+	// column indexes follow the header order above.
+	subItemCol := 1
+	dv := xlsx.NewDataValidation(1, subItemCol, templateValidatedRows, subItemCol, false)
+	if err := dv.SetDropList(mapping.SubItems()); err != nil {
+		return nil, errors.Wrap(ErrTemplate, err.Error())
+	}
+
+	errTitle := "Invalid " + mapping.SubItemColumn
+	errMsg := "Pick one of the listed values"
+	dv.SetError(xlsx.StyleStop, &errTitle, &errMsg)
+	sheet.AddDataValidation(dv)
+
+	buf := &bytes.Buffer{}
+	if err := file.Write(buf); err != nil {
+		return nil, errors.Wrap(ErrTemplate, err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
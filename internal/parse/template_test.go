@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+)
+
+func TestTemplate(t *testing.T) {
+	expectedBom := fleetdbapi.Bom{
+		SerialNum:     templateExampleSerial,
+		AocMacAddress: templateExampleAOCMacAddr,
+		BmcMacAddress: templateExampleBMCMacAddr,
+		NumDefiPmi:    templateExampleIPMIUser,
+		NumDefPWD:     templateExampleIPMIPassword,
+	}
+
+	var testCases = []struct {
+		testName string
+		mapping  *Mapping
+	}{
+		{
+			testName: "default mapping",
+			mapping:  DefaultMapping(),
+		},
+		{
+			testName: "vendor mapping",
+			mapping: (&Mapping{
+				SerialNumColumn:   "Serial",
+				SubItemColumn:     "Item",
+				SubSerialColumn:   "Value",
+				BMCMacAddressItem: "BMC MAC",
+			}).WithDefaults(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.testName, func(t *testing.T) {
+			data, err := Template(tt.mapping)
+			if err != nil {
+				t.Fatalf("Template() failed %v", err)
+			}
+
+			boms, err := ParseXlsxFileWithMapping(data, tt.mapping)
+			if err != nil {
+				t.Fatalf("test %v failed to parse template: %v", tt.testName, err)
+			}
+
+			if len(boms) != 1 {
+				t.Fatalf("test %v parsed incorrect numbers of boms, got %v, expect 1", tt.testName, len(boms))
+			}
+
+			if !reflect.DeepEqual(boms[0], expectedBom) {
+				t.Fatalf("test %v parsed incorrect bom info, got %v, expect %v", tt.testName, boms[0], expectedBom)
+			}
+		})
+	}
+}
+
+func TestMappingValidate(t *testing.T) {
+	if err := DefaultMapping().Validate(); err != nil {
+		t.Fatalf("default mapping failed validation %v", err)
+	}
+
+	m := DefaultMapping()
+	m.SubItemColumn = m.SerialNumColumn
+	if err := m.Validate(); err == nil {
+		t.Fatal("expected error for duplicate column headers, got nil")
+	}
+}
@@ -0,0 +1,167 @@
+// Package ratelimit limits the API request rate of each client and the number of concurrent uploads.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval is how often clients whose token bucket has refilled are forgotten.
+const sweepInterval = time.Minute
+
+// client is the token bucket of a client.
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter is a token bucket rate limiter per client key.
+type Limiter struct {
+	mu        sync.Mutex
+	enabled   bool
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*client
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewLimiter returns a Limiter with the request rate limits from the options.
+func NewLimiter(options *app.RateLimitOptions) *Limiter {
+	l := &Limiter{clients: map[string]*client{}, now: time.Now}
+	l.Configure(options)
+
+	return l
+}
+
+// Configure applies the request rate limits, clients start over with a full bucket when the limits change.
+func (l *Limiter) Configure(options *app.RateLimitOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, burst := rate.Limit(options.RequestsPerSecond), options.Burst
+	if l.enabled != options.Enabled || l.limit != limit || l.burst != burst {
+		l.clients = map[string]*client{}
+	}
+
+	l.enabled, l.limit, l.burst = options.Enabled, limit, burst
+
+	if l.enabled {
+		metrics.RateLimits(options.RequestsPerSecond, burst)
+	} else {
+		metrics.RateLimits(0, 0)
+	}
+
+	metrics.RateLimitClients(len(l.clients))
+}
+
+// Allow takes a token from the client bucket, returning false and the time until a token is available when it is empty.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = c
+		metrics.RateLimitClients(len(l.clients))
+	}
+
+	c.lastSeen = now
+
+	reservation := c.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		// a zero burst allows no requests.
+		return false, sweepInterval
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// sweep forgets the clients idle long enough for their bucket to refill, the caller holds the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+
+	l.lastSweep = now
+
+	refill := sweepInterval
+	if l.limit > 0 {
+		refill = max(refill, time.Duration(float64(l.burst)/float64(l.limit)*float64(time.Second)))
+	}
+
+	for key, c := range l.clients {
+		if now.Sub(c.lastSeen) > refill {
+			delete(l.clients, key)
+		}
+	}
+
+	metrics.RateLimitClients(len(l.clients))
+}
+
+// UploadLimiter limits the number of uploads in flight.
+type UploadLimiter struct {
+	mu         sync.Mutex
+	limit      int
+	retryAfter time.Duration
+	inFlight   int
+}
+
+// NewUploadLimiter returns an UploadLimiter with the upload concurrency limit from the options.
+func NewUploadLimiter(options *app.RateLimitOptions) *UploadLimiter {
+	u := &UploadLimiter{}
+	u.Configure(options)
+
+	return u
+}
+
+// Configure applies the upload concurrency limit, uploads in flight over a lowered limit are left to complete.
+func (u *UploadLimiter) Configure(options *app.RateLimitOptions) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.limit, u.retryAfter = options.UploadConcurrency, options.UploadRetryAfter
+	metrics.UploadConcurrency(u.limit, u.inFlight)
+}
+
+// Acquire returns true when an upload may start, in which case Release must be called once it completes.
+// Otherwise the time after which the upload may be retried is returned.
+func (u *UploadLimiter) Acquire() (bool, time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.limit > 0 && u.inFlight >= u.limit {
+		return false, u.retryAfter
+	}
+
+	u.inFlight++
+	metrics.UploadConcurrency(u.limit, u.inFlight)
+
+	return true, 0
+}
+
+// Release ends an upload started by Acquire.
+func (u *UploadLimiter) Release() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.inFlight--
+	metrics.UploadConcurrency(u.limit, u.inFlight)
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	options := &app.RateLimitOptions{Enabled: true, RequestsPerSecond: 1, Burst: 2}
+	limiter := NewLimiter(options)
+
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.Allow("a")
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter := limiter.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// clients have their own bucket.
+	ok, _ = limiter.Allow("b")
+	assert.True(t, ok)
+
+	// rejected requests take no token.
+	now = now.Add(time.Second)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+
+	ok, _ = limiter.Allow("a")
+	assert.False(t, ok)
+
+	// idle clients are forgotten once their bucket has refilled.
+	now = now.Add(2 * sweepInterval)
+	ok, _ = limiter.Allow("c")
+	assert.True(t, ok)
+	assert.Len(t, limiter.clients, 1)
+
+	// changed limits apply to clients with a full bucket.
+	limiter.Configure(&app.RateLimitOptions{Enabled: true, RequestsPerSecond: 1, Burst: 1})
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+
+	ok, _ = limiter.Allow("a")
+	assert.False(t, ok)
+
+	limiter.Configure(&app.RateLimitOptions{})
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+}
+
+func TestUploadLimiter(t *testing.T) {
+	limiter := NewUploadLimiter(&app.RateLimitOptions{UploadConcurrency: 1, UploadRetryAfter: 5 * time.Second})
+
+	ok, _ := limiter.Acquire()
+	assert.True(t, ok)
+
+	ok, retryAfter := limiter.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, 5*time.Second, retryAfter)
+
+	limiter.Release()
+
+	ok, _ = limiter.Acquire()
+	assert.True(t, ok)
+
+	// zero is unlimited.
+	limiter.Configure(&app.RateLimitOptions{})
+
+	ok, _ = limiter.Acquire()
+	assert.True(t, ok)
+}
@@ -0,0 +1,377 @@
+// Package reconcile compares the stored BOMs with the servers enrolled in fleetdb.
+package reconcile
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	rivets "github.com/metal-toolbox/rivets/v2/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FindingKind is the kind of difference between a BOM and the fleetdb inventory.
+type FindingKind string
+
+const (
+	// FindingMissing is reported when a server or MAC address in the BOM is not in fleetdb.
+	FindingMissing FindingKind = "missing"
+	// FindingMismatched is reported when the BOM and fleetdb have different values for a field.
+	FindingMismatched FindingKind = "mismatched"
+	// FindingExtra is reported when fleetdb has a server without a BOM or a MAC address the BOM does not list.
+	FindingExtra FindingKind = "extra"
+
+	// serverPageSize is the number of servers requested per page by ReconcileAll.
+	serverPageSize = 100
+
+	fieldSerialNum     = "serial_num"
+	fieldBMCMacAddress = "bmc_mac_address"
+	fieldAOCMacAddress = "aoc_mac_address"
+)
+
+var (
+	ErrReconcile = errors.New("reconcile error")
+)
+
+// FleetDB is the subset of the fleetdb client the reconciler queries server records with.
+type FleetDB interface {
+	inventory.ServerLister
+	inventory.InventoryGetter
+}
+
+// Finding is a difference between a BOM and the fleetdb inventory.
+type Finding struct {
+	Kind       FindingKind `json:"kind"`
+	SerialNum  string      `json:"serial_num"`
+	ServerUUID string      `json:"server_uuid,omitempty"`
+	Field      string      `json:"field"`
+	Expected   string      `json:"expected,omitempty"`
+	Actual     string      `json:"actual,omitempty"`
+}
+
+// Report holds the findings of a reconciliation run.
+type Report struct {
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Servers     int       `json:"servers"`
+	Boms        int       `json:"boms"`
+	Findings    []Finding `json:"findings"`
+	// Credentials lists the BMC credentials pushed to the reconciled servers, set when credential push is enabled.
+	Credentials *enroll.CredentialResult `json:"credentials,omitempty"`
+}
+
+// Count returns the number of findings of the given kind.
+func (r *Report) Count(kind FindingKind) int {
+	var n int
+
+	for i := range r.Findings {
+		if r.Findings[i].Kind == kind {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Reconciler compares BOMs with the servers enrolled in fleetdb.
+type Reconciler struct {
+	fleetdb     FleetDB
+	repository  store.Repository
+	logger      *logrus.Logger
+	credentials *enroll.CredentialPusher
+	// workers is the number of servers or BOMs reconciled concurrently.
+	workers int
+}
+
+// Option sets a parameter on the Reconciler type.
+type Option func(*Reconciler)
+
+// WithCredentialPusher pushes the BOM default BMC credential to each reconciled server matching its BOM,
+// so servers enrolled after their BOM was uploaded get their credential.
+func WithCredentialPusher(pusher *enroll.CredentialPusher) Option {
+	return func(r *Reconciler) {
+		r.credentials = pusher
+	}
+}
+
+// WithWorkers sets the number of servers or BOMs reconciled concurrently.
+func WithWorkers(workers int) Option {
+	return func(r *Reconciler) {
+		r.workers = workers
+	}
+}
+
+// New returns a Reconciler that reads stored BOMs from the repository and server records from fleetdb.
+func New(fleetdb FleetDB, repository store.Repository, logger *logrus.Logger, options ...Option) *Reconciler {
+	r := &Reconciler{
+		fleetdb:    fleetdb,
+		repository: repository,
+		logger:     logger,
+		workers:    lookup.DefaultWorkers,
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// ReconcileAll reconciles every server enrolled in fleetdb with its BOM, reconciling the servers of each
+// page concurrently.
+//
+// fleetdb does not list the stored BOMs, so the servers are listed instead and each BOM is looked up by the
+// MAC addresses in the server attributes and inventory. BOMs without a server are not reported here, they are
+// reported missing by Reconcile as they are uploaded.
+func (r *Reconciler) ReconcileAll(ctx context.Context) (*Report, error) {
+	report := &Report{
+		StartedAt: time.Now(),
+		Findings:  []Finding{},
+	}
+
+	if r.credentials != nil {
+		report.Credentials = enroll.NewCredentialResult()
+	}
+
+	for page := 1; ; page++ {
+		params := &fleetdbapi.ServerListParams{
+			PaginationParams: &fleetdbapi.PaginationParams{Limit: serverPageSize, Page: page, Preload: true},
+		}
+
+		servers, _, err := r.fleetdb.List(ctx, params)
+		if err != nil {
+			return nil, errors.Wrap(ErrReconcile, err.Error())
+		}
+
+		results := make([]serverResult, len(servers))
+
+		err = lookup.ForEach(ctx, len(servers), r.workers, func(ctx context.Context, i int) error {
+			return r.reconcileServer(ctx, &servers[i], &results[i])
+		})
+		if err != nil {
+			return nil, errors.Wrap(ErrReconcile, err.Error())
+		}
+
+		// results are merged in listing order, so reports of the same inventory are the same.
+		for i := range results {
+			report.Servers++
+			if results[i].bom != nil {
+				report.Boms++
+			}
+
+			if results[i].pushed && report.Credentials != nil {
+				report.Credentials.Add(results[i].credential, results[i].written, results[i].pushErr)
+			}
+
+			report.Findings = append(report.Findings, results[i].findings...)
+		}
+
+		if len(servers) < serverPageSize {
+			break
+		}
+	}
+
+	report.CompletedAt = time.Now()
+
+	return report, nil
+}
+
+// Reconcile compares the given BOMs with the fleetdb server records of the same serial, BOMs without
+// a server are reported missing.
+func (r *Reconciler) Reconcile(ctx context.Context, boms []fleetdbapi.Bom) (*Report, error) {
+	report := &Report{
+		StartedAt: time.Now(),
+		Boms:      len(boms),
+		Findings:  []Finding{},
+	}
+
+	results := make([][]Finding, len(boms))
+
+	err := lookup.ForEach(ctx, len(boms), r.workers, func(ctx context.Context, i int) error {
+		findings, err := r.reconcileBom(ctx, &boms[i])
+		results[i] = findings
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(ErrReconcile, err.Error())
+	}
+
+	for _, findings := range results {
+		report.Findings = append(report.Findings, findings...)
+	}
+
+	report.CompletedAt = time.Now()
+
+	return report, nil
+}
+
+func (r *Reconciler) reconcileBom(ctx context.Context, bom *fleetdbapi.Bom) ([]Finding, error) {
+	server, err := inventory.ServerBySerial(ctx, r.fleetdb, bom.SerialNum)
+	if err != nil {
+		return nil, err
+	}
+
+	if server == nil {
+		return []Finding{
+			{Kind: FindingMissing, SerialNum: bom.SerialNum, Field: fieldSerialNum, Expected: bom.SerialNum},
+		}, nil
+	}
+
+	inv, err := r.inventory(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	return compare(bom, server.UUID.String(), inv), nil
+}
+
+// serverResult is the outcome of reconciling a server.
+type serverResult struct {
+	findings []Finding
+	// bom is nil when no BOM was found for the server.
+	bom *fleetdbapi.Bom
+
+	// pushed is true when the BOM default credential was pushed to the server.
+	pushed     bool
+	credential enroll.Credential
+	written    bool
+	pushErr    error
+}
+
+// reconcileServer compares a fleetdb server with the BOM of its MAC addresses, pushing the BOM default
+// BMC credential when credential push is enabled and the server has the BOM serial number.
+func (r *Reconciler) reconcileServer(ctx context.Context, server *fleetdbapi.Server, result *serverResult) error {
+	findings, bom, err := r.compareServer(ctx, server)
+	if err != nil {
+		return err
+	}
+
+	result.findings, result.bom = findings, bom
+
+	if r.credentials != nil && bom != nil && bom.NumDefPWD != "" && inventory.SerialNum(server) == bom.SerialNum {
+		result.pushed = true
+		result.credential, result.written, result.pushErr = r.credentials.PushServer(ctx, server.UUID, bom)
+	}
+
+	return nil
+}
+
+// compareServer compares a fleetdb server with the BOM of its MAC addresses,
+// the BOM is nil when none was found for the server.
+func (r *Reconciler) compareServer(ctx context.Context, server *fleetdbapi.Server) ([]Finding, *fleetdbapi.Bom, error) {
+	serial := inventory.SerialNum(server)
+
+	inv, err := r.inventory(ctx, server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bmc, aoc := inventory.BomMacAddrs(server)
+	if addr := inventory.BMCMacAddr(inv); addr != "" {
+		bmc = append(bmc, addr)
+	}
+
+	aoc = append(aoc, inventory.NICMacAddrs(inv)...)
+
+	bom, err := store.BomByMacAddrs(ctx, r.repository, bmc, aoc)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "server "+server.UUID.String())
+	}
+
+	if bom == nil {
+		return []Finding{
+			{Kind: FindingExtra, SerialNum: serial, ServerUUID: server.UUID.String(), Field: fieldSerialNum, Actual: serial},
+		}, nil, nil
+	}
+
+	var findings []Finding
+
+	if serial != bom.SerialNum {
+		findings = append(findings, Finding{
+			Kind:       FindingMismatched,
+			SerialNum:  bom.SerialNum,
+			ServerUUID: server.UUID.String(),
+			Field:      fieldSerialNum,
+			Expected:   bom.SerialNum,
+			Actual:     serial,
+		})
+	}
+
+	return append(findings, compare(bom, server.UUID.String(), inv)...), bom, nil
+}
+
+// inventory returns the fleetdb inventory of the server, servers without an inventory have no components.
+func (r *Reconciler) inventory(ctx context.Context, server *fleetdbapi.Server) (*rivets.Server, error) {
+	inv, _, err := r.fleetdb.GetServerInventory(ctx, server.UUID, false)
+	if err != nil {
+		if inventory.IsNotFound(err) {
+			return &rivets.Server{}, nil
+		}
+
+		return nil, errors.Wrap(err, "server "+server.UUID.String())
+	}
+
+	return inv, nil
+}
+
+// compare returns the differences between the MAC addresses in the BOM and the server inventory.
+func compare(bom *fleetdbapi.Bom, serverUUID string, inv *rivets.Server) []Finding {
+	finding := func(kind FindingKind, field, expected, actual string) Finding {
+		return Finding{
+			Kind:       kind,
+			SerialNum:  bom.SerialNum,
+			ServerUUID: serverUUID,
+			Field:      field,
+			Expected:   expected,
+			Actual:     actual,
+		}
+	}
+
+	var findings []Finding
+
+	expectedBMC := model.SplitMacAddrs(bom.BmcMacAddress)
+	actualBMC := inventory.BMCMacAddr(inv)
+
+	switch {
+	case len(expectedBMC) == 0:
+	case actualBMC == "":
+		findings = append(findings, finding(FindingMissing, fieldBMCMacAddress, bom.BmcMacAddress, ""))
+	case !contains(expectedBMC, actualBMC):
+		findings = append(findings, finding(FindingMismatched, fieldBMCMacAddress, strings.Join(expectedBMC, ","), actualBMC))
+	}
+
+	expectedNICs := model.SplitMacAddrs(bom.AocMacAddress)
+	actualNICs := inventory.NICMacAddrs(inv)
+
+	for _, addr := range expectedNICs {
+		if !contains(actualNICs, addr) {
+			findings = append(findings, finding(FindingMissing, fieldAOCMacAddress, addr, ""))
+		}
+	}
+
+	for _, addr := range actualNICs {
+		if !contains(expectedNICs, addr) {
+			findings = append(findings, finding(FindingExtra, fieldAOCMacAddress, "", addr))
+		}
+	}
+
+	return findings
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,350 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	common "github.com/metal-toolbox/bmc-common"
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	rivets "github.com/metal-toolbox/rivets/v2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFleetDB serves the fleetdb server list, inventory and credential endpoints used by the reconciler.
+type fakeFleetDB struct {
+	mu          sync.Mutex
+	servers     map[string]fleetdbapi.Server
+	inventory   map[string]*rivets.Server
+	credentials map[string]*fleetdbapi.ServerCredential
+}
+
+func (f *fakeFleetDB) handler(t *testing.T) http.Handler {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		records := []fleetdbapi.Server{}
+
+		// without attribute filters every server is listed with its attributes.
+		if _, ok := r.URL.Query()["attr"]; !ok {
+			for _, serial := range slices.Sorted(maps.Keys(f.servers)) {
+				records = append(records, f.servers[serial])
+			}
+		}
+
+		// attribute filters are encoded as namespace~key~operator~value
+		for _, attr := range r.URL.Query()["attr"] {
+			parts := strings.Split(attr, "~")
+			if len(parts) != 4 || parts[0] != inventory.VendorAttributesNamespace {
+				continue
+			}
+
+			if srv, ok := f.servers[parts[3]]; ok {
+				records = append(records, srv)
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(&fleetdbapi.ServerResponse{Records: records}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	mux.HandleFunc("GET /api/v1/inventory/{id}", func(w http.ResponseWriter, r *http.Request) {
+		inv, ok := f.inventory[r.PathValue("id")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(&fleetdbapi.ServerResponse{Record: inv}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	mux.HandleFunc("GET /api/v1/servers/{id}/credentials/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		cred, ok := f.credentials[r.PathValue("id")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"credential not found"}`))
+
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(&fleetdbapi.ServerResponse{Record: cred}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	mux.HandleFunc("PUT /api/v1/servers/{id}/credentials/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		cred := &fleetdbapi.ServerCredential{}
+		if err := json.NewDecoder(r.Body).Decode(cred); err != nil {
+			t.Error(err)
+		}
+
+		f.credentials[r.PathValue("id")] = cred
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	// the factory default attribute is accepted and not kept.
+	mux.HandleFunc("PUT /api/v1/servers/{id}/attributes/{ns}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"attributes not found"}`))
+	})
+
+	mux.HandleFunc("POST /api/v1/servers/{id}/attributes", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	return mux
+}
+
+func newFakeFleetDBClient(t *testing.T, fake *fakeFleetDB) *fleetdbapi.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(fake.handler(t))
+	t.Cleanup(srv.Close)
+
+	client, err := fleetdbapi.NewClientWithToken("fake", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func testInventory(bmcMacAddr string, nicMacAddrs ...string) *rivets.Server {
+	inv := &rivets.Server{
+		Components: []*rivets.Component{
+			{Name: common.SlugBMC, Attributes: &rivets.ComponentAttributes{MacAddress: bmcMacAddr}},
+		},
+	}
+
+	for _, addr := range nicMacAddrs {
+		inv.Components = append(inv.Components, &rivets.Component{
+			Name:       common.SlugNIC,
+			Attributes: &rivets.ComponentAttributes{MacAddress: addr},
+		})
+	}
+
+	return inv
+}
+
+func TestReconcile(t *testing.T) {
+	matchedID := uuid.New()
+	mismatchedID := uuid.New()
+
+	fake := &fakeFleetDB{
+		servers: map[string]fleetdbapi.Server{
+			"serial-matched":    {UUID: matchedID},
+			"serial-mismatched": {UUID: mismatchedID},
+		},
+		inventory: map[string]*rivets.Server{
+			matchedID.String():    testInventory("AA:AA:AA:AA:AA:01", "aa:aa:aa:aa:aa:02"),
+			mismatchedID.String(): testInventory("bb:bb:bb:bb:bb:ff", "bb:bb:bb:bb:bb:02", "bb:bb:bb:bb:bb:03"),
+		},
+	}
+
+	boms := []fleetdbapi.Bom{
+		{
+			SerialNum:     "serial-matched",
+			BmcMacAddress: "aa:aa:aa:aa:aa:01",
+			AocMacAddress: "aa:aa:aa:aa:aa:02",
+		},
+		{
+			SerialNum:     "serial-mismatched",
+			BmcMacAddress: "bb:bb:bb:bb:bb:01",
+			AocMacAddress: "bb:bb:bb:bb:bb:02,bb:bb:bb:bb:bb:04",
+		},
+		{
+			SerialNum:     "serial-missing",
+			BmcMacAddress: "cc:cc:cc:cc:cc:01",
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expected := []Finding{
+		{
+			Kind:       FindingMismatched,
+			SerialNum:  "serial-mismatched",
+			ServerUUID: mismatchedID.String(),
+			Field:      fieldBMCMacAddress,
+			Expected:   "bb:bb:bb:bb:bb:01",
+			Actual:     "bb:bb:bb:bb:bb:ff",
+		},
+		{
+			Kind:       FindingMissing,
+			SerialNum:  "serial-mismatched",
+			ServerUUID: mismatchedID.String(),
+			Field:      fieldAOCMacAddress,
+			Expected:   "bb:bb:bb:bb:bb:04",
+		},
+		{
+			Kind:       FindingExtra,
+			SerialNum:  "serial-mismatched",
+			ServerUUID: mismatchedID.String(),
+			Field:      fieldAOCMacAddress,
+			Actual:     "bb:bb:bb:bb:bb:03",
+		},
+		{
+			Kind:      FindingMissing,
+			SerialNum: "serial-missing",
+			Field:     fieldSerialNum,
+			Expected:  "serial-missing",
+		},
+	}
+
+	// findings are reported in BOM order however many BOMs are reconciled concurrently.
+	for _, workers := range []int{1, 3} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			reconciler := New(newFakeFleetDBClient(t, fake), mockstore.NewMockRepository(ctrl), logrus.New(), WithWorkers(workers))
+
+			report, err := reconciler.Reconcile(context.TODO(), boms)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, len(boms), report.Boms)
+			assert.Equal(t, expected, report.Findings)
+			assert.Equal(t, 2, report.Count(FindingMissing))
+		})
+	}
+}
+
+func vendorAttributes(t *testing.T, serial string) fleetdbapi.Attributes {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{"serial": serial})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fleetdbapi.Attributes{Namespace: inventory.VendorAttributesNamespace, Data: data}
+}
+
+func TestReconcileAll(t *testing.T) {
+	matchedID := uuid.New()
+	renamedID := uuid.New()
+	unknownID := uuid.New()
+
+	fake := &fakeFleetDB{
+		servers: map[string]fleetdbapi.Server{
+			"serial-matched": {UUID: matchedID, Attributes: []fleetdbapi.Attributes{vendorAttributes(t, "serial-matched")}},
+			"serial-renamed": {UUID: renamedID, Attributes: []fleetdbapi.Attributes{vendorAttributes(t, "serial-renamed")}},
+			"serial-unknown": {UUID: unknownID, Attributes: []fleetdbapi.Attributes{vendorAttributes(t, "serial-unknown")}},
+		},
+		inventory: map[string]*rivets.Server{
+			matchedID.String(): testInventory("aa:aa:aa:aa:aa:01", "aa:aa:aa:aa:aa:02"),
+			renamedID.String(): testInventory("", "bb:bb:bb:bb:bb:02"),
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "aa:aa:aa:aa:aa:01").
+		Return(nil, nil, store.ErrBomNotFound)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "AA:AA:AA:AA:AA:01").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-matched", BmcMacAddress: "AA:AA:AA:AA:AA:01", AocMacAddress: "AA:AA:AA:AA:AA:02"}, nil, nil)
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "bb:bb:bb:bb:bb:02").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-bom", AocMacAddress: "bb:bb:bb:bb:bb:02"}, nil, nil)
+
+	reconciler := New(newFakeFleetDBClient(t, fake), repository, logrus.New())
+
+	report, err := reconciler.ReconcileAll(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Finding{
+		{
+			Kind:       FindingMismatched,
+			SerialNum:  "serial-bom",
+			ServerUUID: renamedID.String(),
+			Field:      fieldSerialNum,
+			Expected:   "serial-bom",
+			Actual:     "serial-renamed",
+		},
+		{
+			Kind:       FindingExtra,
+			SerialNum:  "serial-unknown",
+			ServerUUID: unknownID.String(),
+			Field:      fieldSerialNum,
+			Actual:     "serial-unknown",
+		},
+	}
+
+	assert.Equal(t, 3, report.Servers)
+	assert.Equal(t, 2, report.Boms)
+	assert.Equal(t, expected, report.Findings)
+}
+
+func TestReconcileAllCredentials(t *testing.T) {
+	matchedID := uuid.New()
+	renamedID := uuid.New()
+
+	fake := &fakeFleetDB{
+		servers: map[string]fleetdbapi.Server{
+			"serial-matched": {UUID: matchedID, Attributes: []fleetdbapi.Attributes{vendorAttributes(t, "serial-matched")}},
+			"serial-renamed": {UUID: renamedID, Attributes: []fleetdbapi.Attributes{vendorAttributes(t, "serial-renamed")}},
+		},
+		inventory: map[string]*rivets.Server{
+			matchedID.String(): testInventory("aa:aa:aa:aa:aa:01"),
+			renamedID.String(): testInventory("bb:bb:bb:bb:bb:01"),
+		},
+		credentials: map[string]*fleetdbapi.ServerCredential{},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "aa:aa:aa:aa:aa:01").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-matched", BmcMacAddress: "aa:aa:aa:aa:aa:01", NumDefiPmi: "ADMIN", NumDefPWD: "factory"}, nil, nil)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bb:bb:bb:bb:bb:01").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-bom", BmcMacAddress: "bb:bb:bb:bb:bb:01", NumDefiPmi: "ADMIN", NumDefPWD: "factory"}, nil, nil)
+
+	client := newFakeFleetDBClient(t, fake)
+	reconciler := New(client, repository, logrus.New(), WithCredentialPusher(enroll.NewCredentialPusher(client, logrus.New())))
+
+	report, err := reconciler.ReconcileAll(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the server enrolled with the BOM serial gets its credential, the renamed one is left alone.
+	assert.Equal(t, []enroll.Credential{{SerialNum: "serial-matched", ServerUUID: matchedID.String(), FactoryDefault: true}}, report.Credentials.Written)
+	assert.Empty(t, report.Credentials.Failed)
+	assert.Equal(t, "factory", fake.credentials[matchedID.String()].Password)
+	assert.NotContains(t, fake.credentials, renamedID.String())
+}
@@ -0,0 +1,64 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Worker reconciles the enrolled servers on an interval and logs the findings.
+type Worker struct {
+	reconciler *Reconciler
+	interval   time.Duration
+	logger     *logrus.Logger
+}
+
+// NewWorker returns a Worker that runs the reconciler on the given interval.
+func NewWorker(reconciler *Reconciler, interval time.Duration, logger *logrus.Logger) *Worker {
+	return &Worker{
+		reconciler: reconciler,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Run reconciles the enrolled servers on each interval until the context is canceled.
+func (w *Worker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) reconcile(ctx context.Context) {
+	report, err := w.reconciler.ReconcileAll(ctx)
+	if err != nil {
+		w.logger.WithError(err).Warn("reconcile run failed")
+		return
+	}
+
+	fields := logrus.Fields{
+		"servers":                 report.Servers,
+		"boms":                    report.Boms,
+		string(FindingMissing):    report.Count(FindingMissing),
+		string(FindingMismatched): report.Count(FindingMismatched),
+		string(FindingExtra):      report.Count(FindingExtra),
+	}
+
+	if report.Credentials != nil {
+		fields["credentials_written"] = len(report.Credentials.Written)
+		fields["credentials_failed"] = len(report.Credentials.Failed)
+		fields["credentials_factory_default"] = len(report.Credentials.FactoryDefault())
+	}
+
+	w.logger.WithFields(fields).Info("reconcile run completed")
+}
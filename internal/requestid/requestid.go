@@ -0,0 +1,108 @@
+// Package requestid carries the X-Request-ID of an API request through the request context,
+// into its log entries and the fleetdb requests made on its behalf.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Header is the request and response header carrying the request ID.
+	Header = "X-Request-ID"
+
+	// LogField is the log entry field set to the request ID.
+	LogField = "request_id"
+
+	// maxLength is the maximum length of a request ID accepted from a client.
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// New returns a new request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid returns true when the request ID from a client is non-empty, within 128 characters
+// and made of printable ASCII characters other than space, so it is safe to log and forward.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a copy of the context carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by the context, or an empty string.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(contextKey{}).(string)
+
+	return id
+}
+
+// transport sets the request ID header on outgoing requests whose context carries one.
+type transport struct {
+	next http.RoundTripper
+}
+
+// NewTransport returns a RoundTripper sending the request ID carried by the request context on to the next RoundTripper.
+func NewTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{next: next}
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	id := FromContext(r.Context())
+	if id == "" || r.Header.Get(Header) != "" {
+		return t.next.RoundTrip(r)
+	}
+
+	// round trippers must not modify the request.
+	r = r.Clone(r.Context())
+	r.Header.Set(Header, id)
+
+	return t.next.RoundTrip(r)
+}
+
+// Hook sets the request_id field on log entries made with a context carrying a request ID,
+// e.g. logger.WithContext(ctx).Warn(...).
+type Hook struct{}
+
+// Levels returns all log levels.
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire sets the request ID field on the entry.
+func (Hook) Fire(entry *logrus.Entry) error {
+	if id := FromContext(entry.Context); id != "" {
+		if _, ok := entry.Data[LogField]; !ok {
+			entry.Data[LogField] = id
+		}
+	}
+
+	return nil
+}
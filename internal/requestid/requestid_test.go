@@ -0,0 +1,65 @@
+package requestid
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(New()))
+	assert.True(t, Valid("ticket-1234/retry:2"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("with space"))
+	assert.False(t, Valid("line\nbreak"))
+	assert.False(t, Valid("ünicode"))
+	assert.False(t, Valid(strings.Repeat("a", maxLength+1)))
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get(Header))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+
+	get := func(ctx context.Context) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return string(body)
+	}
+
+	assert.Equal(t, "ticket-1234", get(NewContext(context.Background(), "ticket-1234")))
+	assert.Equal(t, "", get(context.Background()))
+}
+
+func TestHook(t *testing.T) {
+	logger, logs := test.NewNullLogger()
+	logger.AddHook(Hook{})
+
+	logger.WithContext(NewContext(context.Background(), "ticket-1234")).Warn("with request")
+	assert.Equal(t, logrus.Fields{LogField: "ticket-1234"}, logs.LastEntry().Data)
+
+	logger.WithContext(context.Background()).Warn("without request")
+	assert.Empty(t, logs.LastEntry().Data)
+}
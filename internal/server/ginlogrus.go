@@ -32,13 +32,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
 	"github.com/metal-toolbox/rivets/v2/ginjwt"
 	"github.com/sirupsen/logrus"
 )
 
 var timeFormat = "02/Jan/2006:15:04:05 -0700"
 
-// loggerMiddleware is the logrus gin logger middleware handler modified to include jwt attributes.
+// loggerMiddleware is the logrus gin logger middleware handler modified to include jwt attributes and the request ID.
 //
 // nolint:gocritic // nope.
 func loggerMiddleware(logger logrus.FieldLogger, notLogged ...string) gin.HandlerFunc {
@@ -89,6 +90,7 @@ func loggerMiddleware(logger logrus.FieldLogger, notLogged ...string) gin.Handle
 			"userAgent":   clientUserAgent,
 			"jwt_subject": ginjwt.GetSubject(c),
 			"jwt_user":    ginjwt.GetUser(c),
+			"request_id":  requestid.FromContext(c.Request.Context()),
 		})
 
 		if len(c.Errors) > 0 {
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	"github.com/metal-toolbox/bomservice/internal/version"
+)
+
+var (
+	// readinessTimeout is the maximum time spent checking the repository backend on /readyz.
+	readinessTimeout = 5 * time.Second
+)
+
+// notProbe returns false for the health probe requests.
+func notProbe(r *http.Request) bool {
+	return r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
+// healthz responds once the server is up.
+func (s *Server) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz checks the server can serve API requests, the repository backend has to be reachable
+// with valid credentials, and the readiness gate when set has to be open.
+func (s *Server) readyz(c *gin.Context) {
+	checks := gin.H{}
+	code := http.StatusOK
+
+	if s.ready != nil && !s.ready() {
+		checks["ready"] = "waiting"
+		code = http.StatusServiceUnavailable
+	} else if s.ready != nil {
+		checks["ready"] = "ok"
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := store.Ping(ctx, s.repository); err != nil {
+		s.logger.WithError(err).Warn("readiness check failed")
+
+		checks["store"] = err.Error()
+		code = http.StatusServiceUnavailable
+	} else {
+		checks["store"] = "ok"
+	}
+
+	status := "ok"
+	if code != http.StatusOK {
+		status = "unavailable"
+	}
+
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// versionInfo responds with the build version.
+func (s *Server) versionInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Current())
+}
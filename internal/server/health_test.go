@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	"github.com/metal-toolbox/bomservice/internal/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkedRepository is a repository with a backend check.
+type checkedRepository struct {
+	store.Repository
+	err error
+}
+
+func (r *checkedRepository) Ping(_ context.Context) error {
+	return r.err
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name       string
+		path       string
+		repository store.Repository
+		ready      func() bool
+		wantCode   int
+		wantBody   map[string]any
+	}{
+		{
+			name:       "healthz",
+			path:       "/healthz",
+			repository: &checkedRepository{Repository: mockstore.NewMockRepository(ctrl), err: errors.New("unreachable")},
+			wantCode:   http.StatusOK,
+			wantBody:   map[string]any{"status": "ok"},
+		},
+		{
+			name:       "readyz ok",
+			path:       "/readyz",
+			repository: &checkedRepository{Repository: mockstore.NewMockRepository(ctrl)},
+			wantCode:   http.StatusOK,
+			wantBody:   map[string]any{"status": "ok", "checks": map[string]any{"store": "ok"}},
+		},
+		{
+			name:       "readyz store unavailable",
+			path:       "/readyz",
+			repository: &checkedRepository{Repository: mockstore.NewMockRepository(ctrl), err: errors.New("unreachable")},
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody:   map[string]any{"status": "unavailable", "checks": map[string]any{"store": "unreachable"}},
+		},
+		{
+			name:       "readyz waiting on readiness gate",
+			path:       "/readyz",
+			repository: mockstore.NewMockRepository(ctrl),
+			ready:      func() bool { return false },
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody:   map[string]any{"status": "unavailable", "checks": map[string]any{"ready": "waiting", "store": "ok"}},
+		},
+		{
+			name:       "api held by readiness gate",
+			path:       "/api/v1/bomservice/mac/aa:bb",
+			repository: mockstore.NewMockRepository(ctrl),
+			ready:      func() bool { return false },
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody:   map[string]any{"message": "service not ready", "code": "not_ready", "request_id": "test-request"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := []Option{WithLogger(logrus.New()), WithStore(tc.repository)}
+			if tc.ready != nil {
+				options = append(options, WithReadiness(tc.ready))
+			}
+
+			srv := New(options...)
+
+			request := httptest.NewRequest(http.MethodGet, tc.path, http.NoBody)
+			request.Header.Set(requestid.Header, "test-request")
+
+			recorder := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+
+			var body map[string]any
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tc.wantBody, body)
+		})
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := New(WithLogger(logrus.New()), WithStore(mockstore.NewMockRepository(ctrl)))
+
+	request := httptest.NewRequest(http.MethodGet, "/version", http.NoBody)
+	recorder := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var got version.Version
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, version.Current(), &got)
+}
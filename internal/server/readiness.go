@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+)
+
+// readinessMiddleware responds with 503 Service Unavailable until ready returns true.
+func readinessMiddleware(ready func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ready() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"message":    "service not ready",
+				"code":       routes.CodeNotReady,
+				"request_id": requestid.FromContext(c.Request.Context()),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
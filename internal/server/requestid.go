@@ -0,0 +1,23 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDMiddleware sets the request ID on the request context and the response,
+// the X-Request-ID header from the client is used when valid, otherwise a new ID is generated.
+func requestIDMiddleware(c *gin.Context) {
+	id := c.GetHeader(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+
+	ctx := requestid.NewContext(c.Request.Context(), id)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", id))
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Header(requestid.Header, id)
+}
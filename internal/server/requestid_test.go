@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	testcases := []struct {
+		name      string
+		requestID string
+		// want is the expected request ID, empty when a new one is generated.
+		want string
+	}{
+		{"accepted", "ticket-1234", "ticket-1234"},
+		{"generated", "", ""},
+		{"invalid replaced", "bad id\n", ""},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			logger, logs := test.NewNullLogger()
+			logger.AddHook(requestid.Hook{})
+
+			// the request ID seen by the store call.
+			var stored string
+
+			repository := mockstore.NewMockRepository(ctrl)
+			repository.EXPECT().
+				GetBomInfoByBMCMacAddr(gomock.Any(), "aa:bb").
+				DoAndReturn(func(ctx context.Context, _ string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+					stored = requestid.FromContext(ctx)
+					return nil, nil, errors.New("fleetdb unavailable")
+				})
+
+			srv := New(WithLogger(logger), WithStore(repository))
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/bomservice/bmc-mac-address/aa:bb", http.NoBody)
+			if tc.requestID != "" {
+				request.Header.Set(requestid.Header, tc.requestID)
+			}
+
+			recorder := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+			id := recorder.Header().Get(requestid.Header)
+			if tc.want != "" {
+				assert.Equal(t, tc.want, id)
+			} else {
+				assert.True(t, requestid.Valid(id))
+				assert.NotEqual(t, tc.requestID, id)
+			}
+
+			assert.Equal(t, id, stored)
+			assert.Contains(t, recorder.Body.String(), `"request_id":"`+id+`"`)
+
+			// the access log entry carries the handler error and the request ID.
+			entry := logs.LastEntry()
+			assert.Equal(t, logrus.ErrorLevel, entry.Level)
+			assert.Contains(t, entry.Message, "fleetdb unavailable")
+			assert.Equal(t, id, entry.Data[requestid.LogField])
+		})
+	}
+}
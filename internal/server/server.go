@@ -1,15 +1,26 @@
 package server
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/ratelimit"
+	"github.com/metal-toolbox/bomservice/internal/reconcile"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
 	"github.com/metal-toolbox/bomservice/internal/store"
 	"github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	v2routes "github.com/metal-toolbox/bomservice/pkg/api/v2/routes"
 	"github.com/metal-toolbox/rivets/v2/ginjwt"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 var (
@@ -28,6 +39,18 @@ type Server struct {
 	logger        *logrus.Logger
 	listenAddress string
 	repository    store.Repository
+	mapping       func() *parse.Mapping
+	reconciler    *reconcile.Reconciler
+	enroller      *enroll.Enroller
+	credentials   *enroll.CredentialPusher
+	exporter      *export.Exporter
+	lookupWorkers int
+	ready         func() bool
+	tlsConfig     *tls.Config
+	// authenticators authenticate API requests carrying credentials other than a JWT.
+	authenticators []auth.Authenticator
+	rateLimiter    *ratelimit.Limiter
+	uploadLimiter  *ratelimit.UploadLimiter
 }
 
 // Option type sets a parameter on the Server type.
@@ -54,6 +77,90 @@ func WithListenAddress(addr string) Option {
 	}
 }
 
+// WithMapping sets the column mapping used to parse and generate xlsx files.
+func WithMapping(mapping *parse.Mapping) Option {
+	return func(s *Server) {
+		s.mapping = func() *parse.Mapping { return mapping }
+	}
+}
+
+// WithMappingFunc sets the function returning the column mapping for each request.
+func WithMappingFunc(mapping func() *parse.Mapping) Option {
+	return func(s *Server) {
+		s.mapping = mapping
+	}
+}
+
+// WithReconciler sets the reconciler for on-demand BOM and fleetdb inventory reconciliation.
+func WithReconciler(reconciler *reconcile.Reconciler) Option {
+	return func(s *Server) {
+		s.reconciler = reconciler
+	}
+}
+
+// WithEnroller sets the enroller that creates fleetdb server records for uploaded BOMs.
+func WithEnroller(enroller *enroll.Enroller) Option {
+	return func(s *Server) {
+		s.enroller = enroller
+	}
+}
+
+// WithCredentialPusher sets the pusher that writes the BOM default BMC credentials of uploaded BOMs to fleetdb.
+func WithCredentialPusher(pusher *enroll.CredentialPusher) Option {
+	return func(s *Server) {
+		s.credentials = pusher
+	}
+}
+
+// WithExporter sets the DHCP reservation exporter.
+func WithExporter(exporter *export.Exporter) Option {
+	return func(s *Server) {
+		s.exporter = exporter
+	}
+}
+
+// WithLookupWorkers sets the number of concurrent store lookups for a bulk lookup request.
+func WithLookupWorkers(workers int) Option {
+	return func(s *Server) {
+		s.lookupWorkers = workers
+	}
+}
+
+// WithReadiness holds API requests with 503 Service Unavailable until ready returns true.
+func WithReadiness(ready func() bool) Option {
+	return func(s *Server) {
+		s.ready = ready
+	}
+}
+
+// WithTLSConfig serves the API over TLS with the configuration.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsConfig = config
+	}
+}
+
+// WithAuthenticators sets the authenticators tried before JWT verification on API requests.
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithRateLimiter limits the API request rate of each client.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.rateLimiter = limiter
+	}
+}
+
+// WithUploadLimiter limits the number of concurrent uploads.
+func WithUploadLimiter(limiter *ratelimit.UploadLimiter) Option {
+	return func(s *Server) {
+		s.uploadLimiter = limiter
+	}
+}
+
 // WithAuthMiddlewareConfig sets the auth middleware configuration.
 func WithAuthMiddlewareConfig(authMWConfig *ginjwt.AuthConfig) Option {
 	return func(s *Server) {
@@ -69,13 +176,55 @@ func New(opts ...Option) *http.Server {
 	}
 
 	g := gin.New()
-	g.Use(loggerMiddleware(s.logger), gin.Recovery())
+	// probes are not logged or traced.
+	g.Use(
+		otelgin.Middleware(model.AppName, otelgin.WithFilter(notProbe)),
+		requestIDMiddleware,
+		loggerMiddleware(s.logger, "/healthz", "/readyz"),
+		gin.Recovery(),
+	)
 
 	options := []routes.Option{
 		routes.WithLogger(s.logger),
 		routes.WithStore(s.repository),
 	}
 
+	if s.mapping != nil {
+		options = append(options, routes.WithMappingFunc(s.mapping))
+	}
+
+	if s.reconciler != nil {
+		options = append(options, routes.WithReconciler(s.reconciler))
+	}
+
+	if s.enroller != nil {
+		options = append(options, routes.WithEnroller(s.enroller))
+	}
+
+	if s.credentials != nil {
+		options = append(options, routes.WithCredentialPusher(s.credentials))
+	}
+
+	if s.exporter != nil {
+		options = append(options, routes.WithExporter(s.exporter))
+	}
+
+	if s.lookupWorkers > 0 {
+		options = append(options, routes.WithLookupWorkers(s.lookupWorkers))
+	}
+
+	if len(s.authenticators) > 0 {
+		options = append(options, routes.WithAuthenticators(s.authenticators...))
+	}
+
+	if s.rateLimiter != nil {
+		options = append(options, routes.WithRateLimiter(s.rateLimiter))
+	}
+
+	if s.uploadLimiter != nil {
+		options = append(options, routes.WithUploadLimiter(s.uploadLimiter))
+	}
+
 	// add auth middleware
 	if s.authMWConfig != nil && s.authMWConfig.Enabled {
 		authMW, err := ginjwt.NewAuthMiddleware(*s.authMWConfig)
@@ -86,15 +235,41 @@ func New(opts ...Option) *http.Server {
 		options = append(options, routes.WithAuthMiddleware(authMW))
 	}
 
+	g.GET("/healthz", s.healthz)
+	g.GET("/readyz", s.readyz)
+	g.GET("/version", s.versionInfo)
+
 	v1Router, err := routes.NewRoutes(options...)
 	if err != nil {
 		s.logger.Fatal(errors.Wrap(err, ErrRoutes.Error()))
 	}
 
-	v1Router.Routes(g.Group(routes.PathPrefix))
+	v1Group := g.Group(routes.PathPrefix)
+	if s.ready != nil {
+		v1Group.Use(readinessMiddleware(s.ready))
+	}
+
+	v1Router.Routes(v1Group)
+
+	// v2 runs alongside v1 with the same credentials, rate limits and metro scopes.
+	v2Router, err := v2routes.NewRoutes(v1Router, v2routes.WithStore(s.repository), v2routes.WithLogger(s.logger))
+	if err != nil {
+		s.logger.Fatal(errors.Wrap(err, ErrRoutes.Error()))
+	}
+
+	v2Group := g.Group(v2routes.PathPrefix)
+	if s.ready != nil {
+		v2Group.Use(readinessMiddleware(s.ready))
+	}
+
+	v2Router.Routes(v2Group)
 
 	g.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{"message": "invalid request - route not found"})
+		c.JSON(http.StatusNotFound, gin.H{
+			"message":    "invalid request - route not found",
+			"code":       routes.CodeNotFound,
+			"request_id": requestid.FromContext(c.Request.Context()),
+		})
 	})
 
 	return &http.Server{
@@ -102,5 +277,6 @@ func New(opts ...Option) *http.Server {
 		Handler:      g,
 		ReadTimeout:  readTimeout,
 		WriteTimeout: writeTimeout,
+		TLSConfig:    s.tlsConfig,
 	}
 }
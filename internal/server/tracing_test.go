@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+
+	defer otel.SetTracerProvider(previous)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "aa:bb").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-1"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "aa:bb").
+		Return(nil, nil, store.ErrBomNotFound).
+		Times(1)
+
+	srv := New(WithLogger(logrus.New()), WithStore(store.NewTraced(repository)))
+
+	for _, path := range []string{"/api/v1/bomservice/mac/aa:bb", "/healthz"} {
+		request := httptest.NewRequest(http.MethodGet, path, http.NoBody).WithContext(context.Background())
+		recorder := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, path)
+	}
+
+	spans := exporter.GetSpans()
+
+	byName := map[string]tracetest.SpanStub{}
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+
+	// the probe is not traced.
+	assert.Len(t, spans, 3)
+
+	root, ok := byName["/api/v1/bomservice/mac/:mac_address"]
+	if !assert.True(t, ok, "request span named by route") {
+		return
+	}
+
+	for _, name := range []string{"Repository.GetBomInfoByAOCMacAddr", "Repository.GetBomInfoByBMCMacAddr"} {
+		assert.Equal(t, root.SpanContext.SpanID(), byName[name].Parent.SpanID(), name)
+	}
+}
@@ -0,0 +1,279 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cacheIndexAOC and cacheIndexBMC are the lookup index the cache entry belongs to.
+	cacheIndexAOC = "aoc"
+	cacheIndexBMC = "bmc"
+)
+
+// Cache is a Repository decorator caching the MAC address lookups of the wrapped Repository.
+//
+// Entries expire after the TTL, lookups of unknown MAC addresses are cached for the negative TTL,
+// and the least recently used entry is evicted once the cache holds Size entries.
+// A batch upload invalidates the entries for the MAC addresses and serial numbers it writes.
+type Cache struct {
+	repository Repository
+	now        func() time.Time
+
+	mu          sync.Mutex
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+	entries     map[string]*list.Element
+	lru         *list.List
+	// byMac and bySerial index the entry keys by normalized MAC address and cached serial number for invalidation.
+	byMac    map[string]map[string]struct{}
+	bySerial map[string]map[string]struct{}
+	// generation is incremented on invalidation so lookups started before an upload are not cached.
+	generation uint64
+}
+
+type cacheEntry struct {
+	key     string
+	mac     string
+	bom     *fleetdbapi.Bom
+	resp    *fleetdbapi.ServerResponse
+	expires time.Time
+}
+
+// NewCache returns a Cache wrapping the repository.
+func NewCache(repository Repository, options *app.CacheOptions) *Cache {
+	return &Cache{
+		repository:  repository,
+		ttl:         options.TTL,
+		negativeTTL: options.NegativeTTL,
+		size:        options.Size,
+		now:         time.Now,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		byMac:       map[string]map[string]struct{}{},
+		bySerial:    map[string]map[string]struct{}{},
+	}
+}
+
+// GetBomInfoByAOCMacAddr returns the cached bom object by AOCMacAddr, looking it up on a miss.
+func (c *Cache) GetBomInfoByAOCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return c.get(ctx, cacheIndexAOC, macAddr, c.repository.GetBomInfoByAOCMacAddr)
+}
+
+// GetBomInfoByBMCMacAddr returns the cached bom object by BMCMacAddr, looking it up on a miss.
+func (c *Cache) GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return c.get(ctx, cacheIndexBMC, macAddr, c.repository.GetBomInfoByBMCMacAddr)
+}
+
+// BillOfMaterialsBatchUpload writes the boms and invalidates the cached entries they touch.
+func (c *Cache) BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error) {
+	// invalidated whether or not the upload succeeds, a failed batch may have been partially written.
+	defer c.Invalidate(boms)
+
+	return c.repository.BillOfMaterialsBatchUpload(ctx, boms)
+}
+
+// GetBomInfoBySerial is not cached, uploads invalidate the cached entries by MAC address.
+func (c *Cache) GetBomInfoBySerial(ctx context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return c.repository.GetBomInfoBySerial(ctx, serial)
+}
+
+// ListBoms is not cached.
+func (c *Cache) ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) ([]fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return c.repository.ListBoms(ctx, params)
+}
+
+// Ping checks the wrapped repository backend is available.
+func (c *Cache) Ping(ctx context.Context) error {
+	return Ping(ctx, c.repository)
+}
+
+// Configure applies the cache TTLs and size, entries beyond the size are evicted and cached entries keep their expiry.
+func (c *Cache) Configure(config *app.Configuration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = config.CacheOptions.TTL
+	c.negativeTTL = config.CacheOptions.NegativeTTL
+	c.size = config.CacheOptions.Size
+
+	c.evict()
+}
+
+// Invalidate removes the cached entries for the MAC addresses and serial numbers in the boms.
+func (c *Cache) Invalidate(boms []fleetdbapi.Bom) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	keys := map[string]struct{}{}
+
+	for i := range boms {
+		for k := range c.bySerial[boms[i].SerialNum] {
+			keys[k] = struct{}{}
+		}
+
+		for _, field := range []string{boms[i].AocMacAddress, boms[i].BmcMacAddress} {
+			for _, mac := range model.SplitMacAddrs(field) {
+				for k := range c.byMac[mac] {
+					keys[k] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for k := range keys {
+		if elem, ok := c.entries[k]; ok {
+			c.remove(elem)
+		}
+	}
+
+	metrics.CacheInvalidations(len(keys))
+}
+
+// Len returns the number of cached entries.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *Cache) get(
+	ctx context.Context,
+	index, macAddr string,
+	lookup func(context.Context, string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error),
+) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	key := index + "/" + macAddr
+
+	c.mu.Lock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if c.now().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+
+			if entry.bom == nil {
+				metrics.CacheLookup(index, metrics.CacheNegativeHit)
+				return nil, nil, errors.Wrap(ErrBomNotFound, index+" mac address "+macAddr)
+			}
+
+			metrics.CacheLookup(index, metrics.CacheHit)
+
+			// callers get their own copies, the cached entry is shared.
+			bom := *entry.bom
+
+			return &bom, copyResponse(entry.resp, &bom), nil
+		}
+
+		c.remove(elem)
+	}
+
+	generation := c.generation
+	c.mu.Unlock()
+
+	metrics.CacheLookup(index, metrics.CacheMiss)
+
+	bom, resp, err := lookup(ctx, macAddr)
+
+	switch {
+	case err == nil && bom != nil:
+		cached := *bom
+		c.add(generation, &cacheEntry{key: key, mac: macAddr, bom: &cached, resp: copyResponse(resp, &cached)})
+	case errors.Is(err, ErrBomNotFound):
+		c.add(generation, &cacheEntry{key: key, mac: macAddr})
+	}
+
+	return bom, resp, err
+}
+
+// copyResponse returns a shallow copy of the response with its bom record replaced by bom.
+func copyResponse(resp *fleetdbapi.ServerResponse, bom *fleetdbapi.Bom) *fleetdbapi.ServerResponse {
+	if resp == nil {
+		return nil
+	}
+
+	copied := *resp
+	if _, ok := copied.Record.(*fleetdbapi.Bom); ok {
+		copied.Record = bom
+	}
+
+	return &copied
+}
+
+// add caches the entry unless an invalidation happened since the lookup started at generation,
+// entries without a bom are negative entries.
+func (c *Cache) add(generation uint64, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.ttl
+	if entry.bom == nil {
+		ttl = c.negativeTTL
+	}
+
+	if c.size <= 0 || ttl <= 0 || generation != c.generation {
+		return
+	}
+
+	entry.expires = c.now().Add(ttl)
+
+	if elem, ok := c.entries[entry.key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	indexKey(c.byMac, model.NormalizeMacAddr(entry.mac), entry.key)
+
+	if entry.bom != nil {
+		indexKey(c.bySerial, entry.bom.SerialNum, entry.key)
+	}
+
+	c.evict()
+}
+
+// evict removes the least recently used entries beyond the size, the caller holds the lock.
+func (c *Cache) evict() {
+	for c.lru.Len() > max(c.size, 0) {
+		c.remove(c.lru.Back())
+		metrics.CacheEviction()
+	}
+}
+
+// remove deletes the element from the cache, the caller holds the lock.
+func (c *Cache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	unindexKey(c.byMac, model.NormalizeMacAddr(entry.mac), entry.key)
+
+	if entry.bom != nil {
+		unindexKey(c.bySerial, entry.bom.SerialNum, entry.key)
+	}
+}
+
+func indexKey(index map[string]map[string]struct{}, value, key string) {
+	if _, ok := index[value]; !ok {
+		index[value] = map[string]struct{}{}
+	}
+
+	index[value][key] = struct{}{}
+}
+
+func unindexKey(index map[string]map[string]struct{}, value, key string) {
+	delete(index[value], key)
+
+	if len(index[value]) == 0 {
+		delete(index, value)
+	}
+}
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/app"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(repository Repository, size int) (*Cache, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := NewCache(repository, &app.CacheOptions{
+		Enabled:     true,
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+		Size:        size,
+	})
+
+	cache.now = func() time.Time { return now }
+
+	return cache, &now
+}
+
+func TestCacheLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	cache, now := newTestCache(repository, 10)
+
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-1").
+		DoAndReturn(func(context.Context, string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			bom := &fleetdbapi.Bom{SerialNum: "serial-1", BmcMacAddress: "bmc-1"}
+			return bom, &fleetdbapi.ServerResponse{Record: bom}, nil
+		}).
+		Times(2)
+
+	for i := 0; i < 3; i++ {
+		bom, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "serial-1", bom.SerialNum)
+	}
+
+	// the returned bom and response are copies of the cached entry.
+	bom, resp, _ := cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.Same(t, bom, resp.Record)
+
+	bom.SerialNum = "changed"
+	resp.Message = "changed"
+
+	bom, resp, _ = cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.Equal(t, "serial-1", bom.SerialNum)
+	assert.Equal(t, "serial-1", resp.Record.(*fleetdbapi.Bom).SerialNum)
+	assert.Empty(t, resp.Message)
+
+	// expired entries are looked up again.
+	*now = now.Add(2 * time.Minute)
+
+	_, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.Nil(t, err)
+}
+
+func TestCacheNegative(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	cache, now := newTestCache(repository, 10)
+
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "unknown").
+		Return(nil, nil, errors.Wrap(ErrBomNotFound, "aoc mac address unknown")).
+		Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.GetBomInfoByAOCMacAddr(context.TODO(), "unknown")
+		assert.ErrorIs(t, err, ErrBomNotFound)
+	}
+
+	*now = now.Add(11 * time.Second)
+
+	_, _, err := cache.GetBomInfoByAOCMacAddr(context.TODO(), "unknown")
+	assert.ErrorIs(t, err, ErrBomNotFound)
+
+	// other errors are not cached.
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "flaky").
+		Return(nil, nil, errors.New("fleetdb unavailable")).
+		Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.GetBomInfoByAOCMacAddr(context.TODO(), "flaky")
+		assert.EqualError(t, err, "fleetdb unavailable")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	cache, _ := newTestCache(repository, 2)
+
+	for _, mac := range []string{"bmc-1", "bmc-2", "bmc-3"} {
+		repository.EXPECT().
+			GetBomInfoByBMCMacAddr(gomock.Any(), mac).
+			Return(&fleetdbapi.Bom{SerialNum: "serial-" + mac}, &fleetdbapi.ServerResponse{}, nil).
+			Times(1)
+	}
+
+	// bmc-2 is least recently used when bmc-3 is added, bmc-1 having been read again.
+	for _, mac := range []string{"bmc-1", "bmc-2", "bmc-1", "bmc-3", "bmc-1"} {
+		if _, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), mac); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Equal(t, 2, cache.Len())
+
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-2").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-bmc-2"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	_, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-2")
+	assert.Nil(t, err)
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	cache, _ := newTestCache(repository, 10)
+
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "AA:BB").
+		Return(nil, nil, errors.Wrap(ErrBomNotFound, "aoc mac address AA:BB")).
+		Times(1)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "old-bmc").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-1", BmcMacAddress: "old-bmc"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "other-bmc").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-2", BmcMacAddress: "other-bmc"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	_, _, _ = cache.GetBomInfoByAOCMacAddr(context.TODO(), "AA:BB")
+	_, _, _ = cache.GetBomInfoByBMCMacAddr(context.TODO(), "old-bmc")
+	_, _, _ = cache.GetBomInfoByBMCMacAddr(context.TODO(), "other-bmc")
+
+	assert.Equal(t, 3, cache.Len())
+
+	// the upload claims the negatively cached address, compared normalized, and replaces the bmc of serial-1.
+	boms := []fleetdbapi.Bom{{SerialNum: "serial-1", AocMacAddress: "aa:bb", BmcMacAddress: "new-bmc"}}
+
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), boms).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	if _, err := cache.BillOfMaterialsBatchUpload(context.TODO(), boms); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, cache.Len())
+
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "AA:BB").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-1"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	bom, _, err := cache.GetBomInfoByAOCMacAddr(context.TODO(), "AA:BB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "serial-1", bom.SerialNum)
+}
+
+func TestCacheStaleFill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	cache, _ := newTestCache(repository, 10)
+
+	// an upload completing while the lookup is in flight keeps the lookup result out of the cache.
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-1").
+		DoAndReturn(func(_ context.Context, _ string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			cache.Invalidate([]fleetdbapi.Bom{{SerialNum: "serial-1", BmcMacAddress: "bmc-1"}})
+			return &fleetdbapi.Bom{SerialNum: "serial-0"}, &fleetdbapi.ServerResponse{}, nil
+		}).
+		Times(1)
+
+	_, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheConfigure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	cache, now := newTestCache(repository, 10)
+
+	for _, mac := range []string{"bmc-1", "bmc-2", "bmc-3"} {
+		repository.EXPECT().
+			GetBomInfoByBMCMacAddr(gomock.Any(), mac).
+			Return(&fleetdbapi.Bom{SerialNum: "serial-" + mac}, &fleetdbapi.ServerResponse{}, nil).
+			Times(1)
+
+		if _, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), mac); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reconfigured through the tracing decorator, the smaller size evicts the least recently used entries.
+	Configure(NewTraced(cache), &app.Configuration{
+		CacheOptions: app.CacheOptions{TTL: 2 * time.Minute, Size: 1},
+	})
+
+	assert.Equal(t, 1, cache.Len())
+
+	// negative caching is disabled with a zero negative TTL.
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "unknown").
+		Return(nil, nil, errors.Wrap(ErrBomNotFound, "aoc mac address unknown")).
+		Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.GetBomInfoByAOCMacAddr(context.TODO(), "unknown")
+		assert.ErrorIs(t, err, ErrBomNotFound)
+	}
+
+	// new entries are cached for the new TTL.
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-4").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-bmc-4"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	_, _, _ = cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-4")
+	*now = now.Add(90 * time.Second)
+
+	_, _, err := cache.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-4")
+	assert.Nil(t, err)
+}
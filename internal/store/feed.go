@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+)
+
+// DefaultFeedBuffer is the number of changes held for a subscriber before it is dropped.
+const DefaultFeedBuffer = 256
+
+// ChangeType is the kind of change to a stored bom object.
+type ChangeType string
+
+const (
+	// ChangeUpserted is a bom object stored or updated.
+	ChangeUpserted ChangeType = "upserted"
+	// ChangeRemoved is a bom object no longer stored.
+	ChangeRemoved ChangeType = "removed"
+)
+
+// Change is a change to a stored bom object.
+type Change struct {
+	Type ChangeType
+	Bom  fleetdbapi.Bom
+	Time time.Time
+}
+
+// Feed is a Repository decorator publishing the bom objects written through it to its subscribers.
+//
+// Changes made by other writers are published with Publish, such as those found by an Index refresh.
+// Subscribers falling more than the buffer behind are dropped, rather than holding up the writers.
+type Feed struct {
+	repository Repository
+	buffer     int
+	now        func() time.Time
+
+	mu          sync.Mutex
+	subscribers map[chan Change]struct{}
+}
+
+// NewFeed returns a Feed wrapping the repository, holding up to buffer changes for each subscriber.
+func NewFeed(repository Repository, buffer int) *Feed {
+	if buffer <= 0 {
+		buffer = DefaultFeedBuffer
+	}
+
+	return &Feed{
+		repository:  repository,
+		buffer:      buffer,
+		now:         time.Now,
+		subscribers: map[chan Change]struct{}{},
+	}
+}
+
+// Subscribe returns a channel receiving the changes published from now on.
+//
+// The channel is closed once the context is canceled, or when the subscriber falls behind,
+// in which case the context error is nil.
+func (f *Feed) Subscribe(ctx context.Context) <-chan Change {
+	ch := make(chan Change, f.buffer)
+
+	f.mu.Lock()
+	f.subscribers[ch] = struct{}{}
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		f.mu.Lock()
+		f.drop(ch)
+		f.mu.Unlock()
+	}()
+
+	return ch
+}
+
+// Subscribers returns the number of subscribers.
+func (f *Feed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.subscribers)
+}
+
+// Publish sends the changes to the subscribers.
+func (f *Feed) Publish(changes ...Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		for _, change := range changes {
+			select {
+			case ch <- change:
+			default:
+				f.drop(ch)
+			}
+
+			if _, ok := f.subscribers[ch]; !ok {
+				break
+			}
+		}
+	}
+}
+
+// drop removes and closes the subscriber channel, the caller holds the lock.
+func (f *Feed) drop(ch chan Change) {
+	if _, ok := f.subscribers[ch]; !ok {
+		return
+	}
+
+	delete(f.subscribers, ch)
+	close(ch)
+}
+
+// GetBomInfoByAOCMacAddr gets bom object by AOCMacAddr.
+func (f *Feed) GetBomInfoByAOCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return f.repository.GetBomInfoByAOCMacAddr(ctx, macAddr)
+}
+
+// GetBomInfoByBMCMacAddr gets bom object by BMCMacAddr.
+func (f *Feed) GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return f.repository.GetBomInfoByBMCMacAddr(ctx, macAddr)
+}
+
+// GetBomInfoBySerial gets bom object by serial number.
+func (f *Feed) GetBomInfoBySerial(ctx context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return f.repository.GetBomInfoBySerial(ctx, serial)
+}
+
+// BillOfMaterialsBatchUpload writes the boms and publishes them once written.
+func (f *Feed) BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error) {
+	resp, err := f.repository.BillOfMaterialsBatchUpload(ctx, boms)
+	if err != nil {
+		return resp, err
+	}
+
+	now := f.now()
+	changes := make([]Change, 0, len(boms))
+
+	for i := range boms {
+		changes = append(changes, Change{Type: ChangeUpserted, Bom: boms[i], Time: now})
+	}
+
+	f.Publish(changes...)
+
+	return resp, nil
+}
+
+// ListBoms lists a page of the stored bom objects.
+func (f *Feed) ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) ([]fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return f.repository.ListBoms(ctx, params)
+}
+
+// Ping checks the wrapped repository backend is available.
+func (f *Feed) Ping(ctx context.Context) error {
+	return Ping(ctx, f.repository)
+}
+
+// Configure applies the reloadable settings to the wrapped repository.
+func (f *Feed) Configure(config *app.Configuration) {
+	Configure(f.repository, config)
+}
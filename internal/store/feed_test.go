@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	feed := NewFeed(repository, 2)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	feed.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	changes := feed.Subscribe(ctx)
+
+	boms := []fleetdbapi.Bom{{SerialNum: "serial-1"}, {SerialNum: "serial-2"}}
+
+	// failed uploads are not published.
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), boms).
+		Return(nil, ErrBackendUnavailable).
+		Times(1)
+
+	_, err := feed.BillOfMaterialsBatchUpload(context.TODO(), boms)
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+	assert.Empty(t, changes)
+
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), boms).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	_, err = feed.BillOfMaterialsBatchUpload(context.TODO(), boms)
+	assert.Nil(t, err)
+
+	assert.Equal(t, Change{Type: ChangeUpserted, Bom: boms[0], Time: now}, <-changes)
+	assert.Equal(t, Change{Type: ChangeUpserted, Bom: boms[1], Time: now}, <-changes)
+
+	// a subscriber beyond the buffer is dropped while its context is alive.
+	feed.Publish(make([]Change, 3)...)
+
+	for range changes {
+	}
+
+	assert.Nil(t, ctx.Err())
+	assert.Equal(t, 0, feed.Subscribers())
+
+	cancel()
+
+	// canceled subscribers are removed.
+	ctx, cancel = context.WithCancel(context.TODO())
+	changes = feed.Subscribe(ctx)
+	assert.Equal(t, 1, feed.Subscribers())
+
+	cancel()
+
+	for range changes {
+	}
+
+	assert.Equal(t, 0, feed.Subscribers())
+}
+
+func TestIndexOnChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := newFakeIndexSource()
+	index := NewIndex(mockstore.NewMockRepository(ctrl), source, 0, logrus.New())
+
+	var published []Change
+
+	index.OnChange(func(changes ...Change) {
+		published = append(published, changes...)
+	})
+
+	loaded := time.Now().Add(-time.Hour)
+	refreshed := loaded.Add(time.Minute)
+
+	listings := []map[string]fleetdbapi.Bom{
+		{"serial-1": {SerialNum: "serial-1"}, "serial-2": {SerialNum: "serial-2"}},
+		{"serial-1": {SerialNum: "serial-1", Metro: "dc13"}, "serial-3": {SerialNum: "serial-3"}},
+	}
+
+	for i, listing := range listings {
+		for _, bom := range listing {
+			source.enroll(bom, []time.Time{loaded, refreshed}[i])
+		}
+
+		if _, err := index.Refresh(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first load is not published.
+	got := map[string]ChangeType{}
+	for _, change := range published {
+		got[change.Bom.SerialNum] = change.Type
+	}
+
+	expected := map[string]ChangeType{
+		"serial-1": ChangeUpserted,
+		"serial-3": ChangeUpserted,
+	}
+
+	assert.Equal(t, expected, got)
+	assert.Len(t, published, 2)
+}
@@ -0,0 +1,411 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// indexLoadRetry is the time between attempts to load the index until the first load succeeds.
+const indexLoadRetry = 10 * time.Second
+
+// IndexSource returns the bom objects an Index is loaded and refreshed with.
+type IndexSource interface {
+	// Changed returns the boms changed after since and the time to pass as since on the next call,
+	// the zero time returns every bom the source lists.
+	Changed(ctx context.Context, since time.Time) ([]fleetdbapi.Bom, time.Time, error)
+	// Boms returns the stored boms of the serial numbers, serial numbers not stored are skipped.
+	Boms(ctx context.Context, serials []string) ([]fleetdbapi.Bom, error)
+}
+
+// Index is a Repository decorator holding bom objects in memory.
+//
+// The index is loaded with every bom its source lists, then refreshed with the boms changed since the
+// previous refresh on the interval and on Trigger. Boms written through BillOfMaterialsBatchUpload are indexed
+// as soon as the upload succeeds. Once the first load completes, lookups are answered from memory and those
+// missing the index go to the wrapped Repository, the boms found are indexed. Bom listings are always passed
+// to the wrapped Repository, the index only holds the boms it was loaded with or has seen.
+//
+// fleetdb has no route to delete bom objects, so indexed boms are not removed.
+type Index struct {
+	repository Repository
+	source     IndexSource
+	interval   time.Duration
+	logger     *logrus.Logger
+	trigger    chan struct{}
+	ready      chan struct{}
+	readyOnce  sync.Once
+	// onChange is called with the changes found by refreshes after the first load.
+	onChange func(changes ...Change)
+	// onUpload is called with the serial numbers of the boms uploaded through the index.
+	onUpload func(ctx context.Context, serials []string)
+
+	// refreshMu serializes refreshes, which advance the cursor.
+	refreshMu sync.Mutex
+	cursor    time.Time
+
+	pendingMu sync.Mutex
+	// pending are the serial numbers passed to Trigger, looked up by the next refresh.
+	pending map[string]struct{}
+
+	mu       sync.RWMutex
+	bySerial map[string]*fleetdbapi.Bom
+	// byAOC and byBMC map normalized MAC addresses to serial numbers.
+	byAOC map[string]string
+	byBMC map[string]string
+}
+
+// RefreshResult counts the serial numbers changed in the index by a refresh.
+type RefreshResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+}
+
+// NewIndex returns an Index over the repository loaded from the source and refreshed on the interval,
+// Run loads and refreshes it.
+func NewIndex(repository Repository, source IndexSource, interval time.Duration, logger *logrus.Logger) *Index {
+	return &Index{
+		repository: repository,
+		source:     source,
+		interval:   interval,
+		logger:     logger,
+		trigger:    make(chan struct{}, 1),
+		ready:      make(chan struct{}),
+		pending:    map[string]struct{}{},
+		bySerial:   map[string]*fleetdbapi.Bom{},
+		byAOC:      map[string]string{},
+		byBMC:      map[string]string{},
+	}
+}
+
+// Ready returns true once the first load has completed.
+func (x *Index) Ready() bool {
+	select {
+	case <-x.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ping checks the wrapped repository backend is available, the index refresh depends on it.
+func (x *Index) Ping(ctx context.Context) error {
+	return Ping(ctx, x.repository)
+}
+
+// Configure applies the reloadable settings to the wrapped repository.
+func (x *Index) Configure(config *app.Configuration) {
+	Configure(x.repository, config)
+}
+
+// OnChange sets the function called with the bom objects added and updated by each refresh
+// after the first load, such as Feed.Publish. It is set before Run.
+func (x *Index) OnChange(fn func(changes ...Change)) {
+	x.onChange = fn
+}
+
+// OnUpload sets the function called with the serial numbers of the boms uploaded through the index,
+// such as IndexEvents publishing them to the other replicas. It is set before Run.
+func (x *Index) OnUpload(fn func(ctx context.Context, serials []string)) {
+	x.onUpload = fn
+}
+
+// Trigger requests a refresh looking up the serial numbers in addition to the changed boms,
+// to be called when bom change events arrive.
+func (x *Index) Trigger(serials ...string) {
+	x.addPending(serials)
+
+	select {
+	case x.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run loads the index, then refreshes it on the interval and on Trigger until the context is canceled.
+//
+// A failed load is retried after indexLoadRetry, or the interval when shorter, until it succeeds.
+func (x *Index) Run(ctx context.Context) error {
+	for {
+		wait := x.interval
+
+		if _, err := x.Refresh(ctx); err != nil {
+			x.logger.WithError(err).Warn("bom index refresh failed")
+		}
+
+		if !x.Ready() && indexLoadRetry < wait {
+			wait = indexLoadRetry
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		case <-x.trigger:
+			timer.Stop()
+		}
+	}
+}
+
+// Refresh indexes the boms changed since the previous refresh and those of the serial numbers passed to
+// Trigger, the first refresh loads every bom the source lists. The result counts the serial numbers added
+// and changed compared to the previous contents.
+func (x *Index) Refresh(ctx context.Context) (*RefreshResult, error) {
+	x.refreshMu.Lock()
+	defer x.refreshMu.Unlock()
+
+	serials := x.takePending()
+
+	boms, next, err := x.source.Changed(ctx, x.cursor)
+	if err == nil && len(serials) > 0 {
+		var triggered []fleetdbapi.Bom
+
+		triggered, err = x.source.Boms(ctx, serials)
+		boms = append(boms, triggered...)
+	}
+
+	if err != nil {
+		// the triggered serial numbers are retried by the next refresh.
+		x.addPending(serials)
+		return nil, err
+	}
+
+	x.cursor = next
+	result := &RefreshResult{}
+
+	// the first load is not published, the boms were stored before the index.
+	publish := x.onChange != nil && x.Ready()
+	now := time.Now()
+
+	var changes []Change
+
+	x.mu.Lock()
+
+	for i := range boms {
+		current, exists := x.bySerial[boms[i].SerialNum]
+
+		switch {
+		case !exists:
+			result.Added++
+		case !reflect.DeepEqual(*current, boms[i]):
+			result.Updated++
+		default:
+			continue
+		}
+
+		x.put(boms[i])
+
+		if publish {
+			changes = append(changes, Change{Type: ChangeUpserted, Bom: boms[i], Time: now})
+		}
+	}
+
+	size := len(x.bySerial)
+	x.recordStored()
+
+	x.mu.Unlock()
+
+	x.readyOnce.Do(func() { close(x.ready) })
+
+	if len(changes) > 0 {
+		x.onChange(changes...)
+	}
+
+	x.logger.WithFields(logrus.Fields{
+		"boms":      size,
+		"added":     result.Added,
+		"updated":   result.Updated,
+		"triggered": len(serials),
+	}).Debug("bom index refreshed")
+
+	return result, nil
+}
+
+// takePending returns and clears the serial numbers passed to Trigger.
+func (x *Index) takePending() []string {
+	x.pendingMu.Lock()
+	defer x.pendingMu.Unlock()
+
+	serials := make([]string, 0, len(x.pending))
+	for serial := range x.pending {
+		serials = append(serials, serial)
+	}
+
+	clear(x.pending)
+
+	return serials
+}
+
+func (x *Index) addPending(serials []string) {
+	x.pendingMu.Lock()
+	defer x.pendingMu.Unlock()
+
+	for _, serial := range serials {
+		x.pending[serial] = struct{}{}
+	}
+}
+
+// GetBomInfoByAOCMacAddr returns the indexed bom object by AOCMacAddr.
+func (x *Index) GetBomInfoByAOCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	if !x.Ready() {
+		return x.repository.GetBomInfoByAOCMacAddr(ctx, macAddr)
+	}
+
+	if bom, resp, ok := x.get(x.byAOC, macAddr); ok {
+		return bom, resp, nil
+	}
+
+	return x.readThrough(x.repository.GetBomInfoByAOCMacAddr(ctx, macAddr))
+}
+
+// GetBomInfoByBMCMacAddr returns the indexed bom object by BMCMacAddr.
+func (x *Index) GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	if !x.Ready() {
+		return x.repository.GetBomInfoByBMCMacAddr(ctx, macAddr)
+	}
+
+	if bom, resp, ok := x.get(x.byBMC, macAddr); ok {
+		return bom, resp, nil
+	}
+
+	return x.readThrough(x.repository.GetBomInfoByBMCMacAddr(ctx, macAddr))
+}
+
+// GetBomInfoBySerial returns the indexed bom object by serial number.
+func (x *Index) GetBomInfoBySerial(ctx context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	if !x.Ready() {
+		return x.repository.GetBomInfoBySerial(ctx, serial)
+	}
+
+	x.mu.RLock()
+	bom, ok := x.bySerial[serial]
+	x.mu.RUnlock()
+
+	if !ok {
+		return x.readThrough(x.repository.GetBomInfoBySerial(ctx, serial))
+	}
+
+	found := *bom
+
+	return &found, &fleetdbapi.ServerResponse{Record: &found}, nil
+}
+
+// BillOfMaterialsBatchUpload writes the boms and indexes them once written,
+// a failed upload triggers a refresh of the serial numbers since the batch may have been partially written.
+func (x *Index) BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error) {
+	serials := make([]string, 0, len(boms))
+	for i := range boms {
+		serials = append(serials, boms[i].SerialNum)
+	}
+
+	resp, err := x.repository.BillOfMaterialsBatchUpload(ctx, boms)
+	if err != nil {
+		x.Trigger(serials...)
+		return resp, err
+	}
+
+	x.mu.Lock()
+	for i := range boms {
+		x.put(boms[i])
+	}
+
+	x.recordStored()
+	x.mu.Unlock()
+
+	if x.onUpload != nil {
+		x.onUpload(ctx, serials)
+	}
+
+	return resp, nil
+}
+
+// ListBoms lists a page of the stored bom objects from the wrapped repository,
+// the index does not hold every stored bom.
+func (x *Index) ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) ([]fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	return x.repository.ListBoms(ctx, params)
+}
+
+// readThrough indexes the bom found by a lookup that missed the index.
+func (x *Index) readThrough(bom *fleetdbapi.Bom, resp *fleetdbapi.ServerResponse, err error) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	if err != nil || bom == nil {
+		return bom, resp, err
+	}
+
+	x.mu.Lock()
+	x.put(*bom)
+	x.recordStored()
+	x.mu.Unlock()
+
+	return bom, resp, nil
+}
+
+// get returns the indexed bom object by normalized MAC address, ok is false when it is not indexed.
+func (x *Index) get(index map[string]string, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	serial, ok := index[model.NormalizeMacAddr(macAddr)]
+	if !ok {
+		return nil, nil, false
+	}
+
+	bom := *x.bySerial[serial]
+
+	return &bom, &fleetdbapi.ServerResponse{Record: &bom}, true
+}
+
+// recordStored sets the indexed boms gauge by metro, the caller holds the lock.
+func (x *Index) recordStored() {
+	counts := map[string]int{}
+	for _, bom := range x.bySerial {
+		counts[bom.Metro]++
+	}
+
+	metrics.BomsStored(counts)
+}
+
+// put indexes the bom replacing any indexed bom with the same serial number, the caller holds the lock.
+func (x *Index) put(bom fleetdbapi.Bom) {
+	x.delete(bom.SerialNum)
+
+	x.bySerial[bom.SerialNum] = &bom
+
+	for _, mac := range model.SplitMacAddrs(bom.AocMacAddress) {
+		x.byAOC[mac] = bom.SerialNum
+	}
+
+	for _, mac := range model.SplitMacAddrs(bom.BmcMacAddress) {
+		x.byBMC[mac] = bom.SerialNum
+	}
+}
+
+// delete removes the serial number and its MAC addresses from the index, the caller holds the lock.
+func (x *Index) delete(serial string) {
+	bom, ok := x.bySerial[serial]
+	if !ok {
+		return
+	}
+
+	delete(x.bySerial, serial)
+
+	for _, mac := range model.SplitMacAddrs(bom.AocMacAddress) {
+		if x.byAOC[mac] == serial {
+			delete(x.byAOC, mac)
+		}
+	}
+
+	for _, mac := range model.SplitMacAddrs(bom.BmcMacAddress) {
+		if x.byBMC[mac] == serial {
+			delete(x.byBMC, mac)
+		}
+	}
+}
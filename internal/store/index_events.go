@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/metal-toolbox/rivets/v2/events"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IndexEventsSubject is the subject suffix the uploaded serial numbers are published on.
+	IndexEventsSubject = "bomservice.boms.uploaded"
+
+	// indexEventsBuffer is the number of uploads queued for publishing, uploads beyond it are not published.
+	indexEventsBuffer = 64
+)
+
+var (
+	ErrIndexEvents = errors.New("bom index event stream error")
+)
+
+// uploadEvent is the event stream message listing the serial numbers of uploaded boms.
+type uploadEvent struct {
+	SerialNums []string `json:"serial_nums"`
+}
+
+// upload is an upload queued for publishing with the context of its request, for the trace.
+type upload struct {
+	ctx     context.Context
+	serials []string
+}
+
+// IndexEvents is the change feed of the Index across replicas.
+//
+// The serial numbers of the boms uploaded through the index are published on the event stream, and those
+// received from the stream trigger an index refresh looking them up, so each replica indexes the uploads made
+// through the others before its next scheduled refresh.
+type IndexEvents struct {
+	index   *Index
+	stream  events.Stream
+	logger  *logrus.Logger
+	uploads chan upload
+}
+
+// NewIndexEvents returns IndexEvents publishing the index uploads on the opened stream, Run consumes the stream.
+func NewIndexEvents(index *Index, stream events.Stream, logger *logrus.Logger) *IndexEvents {
+	e := &IndexEvents{index: index, stream: stream, logger: logger, uploads: make(chan upload, indexEventsBuffer)}
+
+	index.OnUpload(e.queue)
+
+	return e
+}
+
+// Run publishes the queued uploads and triggers an index refresh for each event received until the context
+// is canceled, the stream is closed on return.
+func (e *IndexEvents) Run(ctx context.Context) error {
+	defer e.stream.Close()
+
+	msgs, err := e.stream.Subscribe(ctx)
+	if err != nil {
+		return errors.Wrap(ErrIndexEvents, err.Error())
+	}
+
+	// publishing retries until the stream accepts the event, so it does not hold up the received events.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u := <-e.uploads:
+				e.publish(u.ctx, u.serials)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgs:
+			if !ok {
+				return errors.Wrap(ErrIndexEvents, "subscription closed")
+			}
+
+			e.handle(msg)
+		}
+	}
+}
+
+func (e *IndexEvents) handle(msg events.Message) {
+	var event uploadEvent
+
+	if err := json.Unmarshal(msg.Data(), &event); err != nil {
+		e.logger.WithError(err).WithField("subject", msg.Subject()).Warn("invalid bom index event")
+
+		if err := msg.Term(); err != nil {
+			e.logger.WithError(err).Warn("bom index event not terminated")
+		}
+
+		return
+	}
+
+	e.index.Trigger(event.SerialNums...)
+
+	if err := msg.Ack(); err != nil {
+		e.logger.WithError(err).Warn("bom index event not acknowledged")
+	}
+}
+
+// queue queues the uploaded serial numbers for publishing without holding up the upload.
+func (e *IndexEvents) queue(ctx context.Context, serials []string) {
+	select {
+	case e.uploads <- upload{ctx: context.WithoutCancel(ctx), serials: serials}:
+	default:
+		e.logger.WithContext(ctx).WithField("boms", len(serials)).Warn("bom index event queue full, upload not published")
+	}
+}
+
+// publish sends the uploaded serial numbers on the stream, failures are logged since the upload succeeded
+// and the other replicas find the boms of enrolled servers on their next refresh.
+func (e *IndexEvents) publish(ctx context.Context, serials []string) {
+	data, err := json.Marshal(uploadEvent{SerialNums: serials})
+	if err == nil {
+		err = e.stream.Publish(ctx, IndexEventsSubject, data)
+	}
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).WithField("boms", len(serials)).Warn("bom index event not published")
+	}
+}
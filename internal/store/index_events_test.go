@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/metal-toolbox/rivets/v2/events"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIndexEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// serial-2 was uploaded through another replica, its server is not enrolled.
+	source := newFakeIndexSource()
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-2", BmcMacAddress: "bmc-2"}, time.Time{})
+
+	repository := mockstore.NewMockRepository(ctrl)
+	index := NewIndex(repository, source, 0, logrus.New())
+
+	if _, err := index.Refresh(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := make(events.MsgCh, 2)
+	published := make(chan []byte, 1)
+
+	stream := events.NewMockStream(t)
+	stream.EXPECT().Subscribe(mock.Anything).Return(msgs, nil).Once()
+	stream.EXPECT().Close().Return(nil).Once()
+	stream.EXPECT().
+		Publish(mock.Anything, IndexEventsSubject, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ string, data []byte) error {
+			published <- data
+			return nil
+		}).
+		Once()
+
+	invalid := events.NewMockMessage(t)
+	invalid.EXPECT().Data().Return([]byte("{")).Once()
+	invalid.EXPECT().Subject().Return("com.hollow.sh.bomservice.boms.uploaded").Once()
+	invalid.EXPECT().Term().Return(nil).Once()
+
+	acked := make(chan struct{})
+
+	received := events.NewMockMessage(t)
+	received.EXPECT().Data().Return([]byte(`{"serial_nums":["serial-2"]}`)).Once()
+	received.EXPECT().Ack().RunAndReturn(func() error {
+		close(acked)
+		return nil
+	}).Once()
+
+	indexEvents := NewIndexEvents(index, stream, logrus.New())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() { done <- indexEvents.Run(ctx) }()
+
+	// uploads through this replica are published.
+	uploaded := []fleetdbapi.Bom{{SerialNum: "serial-1", BmcMacAddress: "bmc-1"}}
+
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), uploaded).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	if _, err := index.BillOfMaterialsBatchUpload(context.TODO(), uploaded); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-published:
+		assert.JSONEq(t, `{"serial_nums":["serial-1"]}`, string(data))
+	case <-time.After(time.Second):
+		t.Fatal("upload not published")
+	}
+
+	// invalid events are terminated, received uploads trigger a refresh looking them up.
+	msgs <- invalid
+	msgs <- received
+
+	select {
+	case <-acked:
+	case <-time.After(time.Second):
+		t.Fatal("event not acknowledged")
+	}
+
+	result, err := index.Refresh(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &RefreshResult{Added: 1}, result)
+
+	bom, _, err := index.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "serial-2", bom.SerialNum)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
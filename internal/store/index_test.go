@@ -0,0 +1,292 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIndexSource lists the boms of its enrolled serials by update time and looks up any of its boms by serial.
+type fakeIndexSource struct {
+	mu      sync.Mutex
+	boms    map[string]fleetdbapi.Bom
+	updated map[string]time.Time
+	since   time.Time
+	err     error
+}
+
+func newFakeIndexSource() *fakeIndexSource {
+	return &fakeIndexSource{boms: map[string]fleetdbapi.Bom{}, updated: map[string]time.Time{}}
+}
+
+// enroll stores the bom with a server updated at the time, the zero time stores it without a server.
+func (s *fakeIndexSource) enroll(bom fleetdbapi.Bom, updated time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.boms[bom.SerialNum] = bom
+
+	if !updated.IsZero() {
+		s.updated[bom.SerialNum] = updated
+	}
+}
+
+func (s *fakeIndexSource) Changed(_ context.Context, since time.Time) ([]fleetdbapi.Bom, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, since, s.err
+	}
+
+	s.since = since
+	next := since
+
+	var boms []fleetdbapi.Bom
+
+	for serial, updated := range s.updated {
+		if !updated.After(since) {
+			continue
+		}
+
+		boms = append(boms, s.boms[serial])
+
+		if updated.After(next) {
+			next = updated
+		}
+	}
+
+	sort.Slice(boms, func(i, j int) bool { return boms[i].SerialNum < boms[j].SerialNum })
+
+	return boms, next, nil
+}
+
+func (s *fakeIndexSource) Boms(_ context.Context, serials []string) ([]fleetdbapi.Bom, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var boms []fleetdbapi.Bom
+
+	for _, serial := range serials {
+		if bom, ok := s.boms[serial]; ok {
+			boms = append(boms, bom)
+		}
+	}
+
+	return boms, nil
+}
+
+func TestIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := prometheus.NewRegistry()
+	metrics.Use(metrics.New(registry, metrics.WithBomsStored()))
+
+	loaded := time.Now().Add(-time.Hour)
+
+	source := newFakeIndexSource()
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-1", AocMacAddress: "AA:01,aa:02", BmcMacAddress: "bmc-1"}, loaded)
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-2", AocMacAddress: "aa:03", BmcMacAddress: "bmc-2"}, loaded)
+
+	repository := mockstore.NewMockRepository(ctrl)
+	index := NewIndex(repository, source, 0, logrus.New())
+
+	var uploads [][]string
+
+	index.OnUpload(func(_ context.Context, serials []string) {
+		uploads = append(uploads, serials)
+	})
+
+	// lookups go to the repository until the first load.
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-1").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-1"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	_, _, err := index.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.Nil(t, err)
+	assert.False(t, index.Ready())
+
+	result, err := index.Refresh(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &RefreshResult{Added: 2}, result)
+	assert.True(t, index.Ready())
+	assert.True(t, source.since.IsZero())
+
+	bom, _, err := index.GetBomInfoByAOCMacAddr(context.TODO(), "aa:01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "serial-1", bom.SerialNum)
+
+	bom, _, err = index.GetBomInfoBySerial(context.TODO(), "serial-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "aa:03", bom.AocMacAddress)
+
+	// misses go to the repository and the boms found are indexed.
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "unknown").
+		Return(nil, nil, ErrBomNotFound).
+		Times(1)
+
+	_, _, err = index.GetBomInfoByBMCMacAddr(context.TODO(), "unknown")
+	assert.ErrorIs(t, err, ErrBomNotFound)
+
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-5").
+		Return(&fleetdbapi.Bom{SerialNum: "serial-5", BmcMacAddress: "bmc-5"}, &fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	for i := 0; i < 2; i++ {
+		bom, _, err = index.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-5")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "serial-5", bom.SerialNum)
+	}
+
+	// listings are passed to the repository, the index does not hold every stored bom.
+	repository.EXPECT().
+		ListBoms(gomock.Any(), gomock.Any()).
+		Return(nil, nil, ErrListUnsupported).
+		Times(1)
+
+	_, _, err = index.ListBoms(context.TODO(), &fleetdbapi.PaginationParams{Limit: 1, Page: 2})
+	assert.ErrorIs(t, err, ErrListUnsupported)
+
+	// uploads are indexed once written.
+	uploaded := []fleetdbapi.Bom{{SerialNum: "serial-3", BmcMacAddress: "bmc-3", Metro: "dc13"}}
+
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), uploaded).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	if _, err := index.BillOfMaterialsBatchUpload(context.TODO(), uploaded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, [][]string{{"serial-3"}}, uploads)
+
+	bom, _, err = index.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "serial-3", bom.SerialNum)
+
+	// indexed boms are counted by metro.
+	expected := `
+# HELP bomservice_boms_stored boms held by the in-memory index by metro
+# TYPE bomservice_boms_stored gauge
+bomservice_boms_stored{metro="dc13"} 1
+bomservice_boms_stored{metro="unknown"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "bomservice_boms_stored"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a refresh only applies the boms of the servers updated since the previous one.
+	refreshed := loaded.Add(time.Minute)
+
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-1", AocMacAddress: "aa:01", BmcMacAddress: "bmc-1"}, refreshed)
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-4", BmcMacAddress: "bmc-4"}, refreshed)
+
+	result, err = index.Refresh(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &RefreshResult{Added: 1, Updated: 1}, result)
+	assert.Equal(t, loaded, source.since)
+
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "aa:02").
+		Return(nil, nil, ErrBomNotFound).
+		Times(1)
+
+	_, _, err = index.GetBomInfoByAOCMacAddr(context.TODO(), "aa:02")
+	assert.ErrorIs(t, err, ErrBomNotFound)
+
+	_, _, err = index.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-4")
+	assert.Nil(t, err)
+
+	// triggered serial numbers are looked up by the next refresh, and retried when it fails.
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-6", BmcMacAddress: "bmc-6"}, time.Time{})
+	source.err = errors.New("fleetdb unavailable")
+
+	index.Trigger("serial-6")
+
+	_, err = index.Refresh(context.TODO())
+	assert.NotNil(t, err)
+
+	source.err = nil
+
+	result, err = index.Refresh(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &RefreshResult{Added: 1}, result)
+	assert.Equal(t, refreshed, source.since)
+
+	_, _, err = index.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-6")
+	assert.Nil(t, err)
+}
+
+func TestIndexRunLoadFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := newFakeIndexSource()
+	source.enroll(fleetdbapi.Bom{SerialNum: "serial-1", BmcMacAddress: "bmc-1"}, time.Now())
+	source.err = errors.New("fleetdb unavailable")
+
+	index := NewIndex(mockstore.NewMockRepository(ctrl), source, time.Millisecond, logrus.New())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() { done <- index.Run(ctx) }()
+
+	// a failed load does not stop the index, the next refresh loads it.
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, index.Ready())
+
+	source.mu.Lock()
+	source.err = nil
+	source.mu.Unlock()
+
+	assert.Eventually(t, index.Ready, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
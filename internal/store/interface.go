@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strings"
 
 	"github.com/metal-toolbox/bomservice/internal/app"
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
@@ -18,14 +19,135 @@ type Repository interface {
 	// GetBomInfoByBMCMacAddr gets bom object by BMCMacAddr.
 	GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error)
 
+	// GetBomInfoBySerial gets bom object by serial number.
+	GetBomInfoBySerial(ctx context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error)
+
 	// BillOfMaterialsBatchUpload creates a bom on a server.
 	BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error)
+
+	// ListBoms lists a page of the stored bom objects.
+	ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) ([]fleetdbapi.Bom, *fleetdbapi.ServerResponse, error)
 }
 
 var (
 	ErrRepository = errors.New("storage repository error")
+
+	// ErrBomNotFound is returned when no bom object matches the lookup.
+	ErrBomNotFound = errors.New("bom not found")
+
+	// ErrConflict is returned when the backend rejects a write conflicting with stored bom objects.
+	ErrConflict = errors.New("bom conflicts with stored records")
+
+	// ErrInvalidBom is returned when the backend rejects a bom object as invalid.
+	ErrInvalidBom = errors.New("invalid bom")
+
+	// ErrBackendUnavailable is returned when the backend cannot be reached or fails to serve the request.
+	ErrBackendUnavailable = errors.New("storage backend unavailable")
+
+	// ErrListUnsupported is returned when the backend does not serve a listing of the stored bom objects.
+	ErrListUnsupported = errors.New("storage backend does not list boms")
 )
 
+// Checker is implemented by repositories that can check their backend is available.
+type Checker interface {
+	// Ping returns an error when the backend cannot serve requests.
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the repository backend is available, repositories not implementing Checker are assumed available.
+func Ping(ctx context.Context, repository Repository) error {
+	checker, ok := repository.(Checker)
+	if !ok {
+		return nil
+	}
+
+	return checker.Ping(ctx)
+}
+
+// Configurable is implemented by repositories with settings that can change at runtime.
+type Configurable interface {
+	// Configure applies the reloadable settings from the configuration.
+	Configure(config *app.Configuration)
+}
+
+// Configure applies the reloadable settings to the repository, repositories not implementing Configurable are left as is.
+func Configure(repository Repository, config *app.Configuration) {
+	if configurable, ok := repository.(Configurable); ok {
+		configurable.Configure(config)
+	}
+}
+
+// listBomsPageSize is the number of boms requested per page by ListAllBoms.
+const listBomsPageSize = 500
+
+// ListAllBoms pages through the repository and returns every stored bom object.
+func ListAllBoms(ctx context.Context, repository Repository) ([]fleetdbapi.Bom, error) {
+	var all []fleetdbapi.Bom
+
+	for page := 1; ; page++ {
+		boms, resp, err := repository.ListBoms(ctx, &fleetdbapi.PaginationParams{Limit: listBomsPageSize, Page: page})
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, boms...)
+
+		if len(boms) < listBomsPageSize || (resp != nil && resp.TotalPages > 0 && page >= resp.TotalPages) {
+			return all, nil
+		}
+	}
+}
+
+// BomByMacAddrs returns the first bom object found by the BMC or AOC MAC addresses, in that order,
+// nil is returned when none of them is stored.
+//
+// bom objects are stored with the MAC addresses as uploaded, so the upper case form of each address is tried as well.
+func BomByMacAddrs(ctx context.Context, repository Repository, bmc, aoc []string) (*fleetdbapi.Bom, error) {
+	lookups := []struct {
+		addrs []string
+		get   func(context.Context, string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error)
+	}{
+		{bmc, repository.GetBomInfoByBMCMacAddr},
+		{aoc, repository.GetBomInfoByAOCMacAddr},
+	}
+
+	for _, lookup := range lookups {
+		for _, addr := range lookup.addrs {
+			for _, candidate := range macAddrForms(addr) {
+				bom, _, err := lookup.get(ctx, candidate)
+				if errors.Is(err, ErrBomNotFound) {
+					continue
+				}
+
+				if err != nil {
+					return nil, err
+				}
+
+				return bom, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func macAddrForms(addr string) []string {
+	if upper := strings.ToUpper(addr); upper != addr {
+		return []string{addr, upper}
+	}
+
+	return []string{addr}
+}
+
 func NewStore(ctx context.Context, config *app.Configuration, logger *logrus.Logger) (Repository, error) {
-	return newServerserviceStore(ctx, &config.ServerserviceOptions, logger)
+	repository, err := newServerserviceStore(ctx, &config.ServerserviceOptions, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.CacheOptions.Enabled {
+		repository = NewCache(repository, &config.CacheOptions)
+	}
+
+	return NewTraced(repository), nil
 }
@@ -81,3 +81,35 @@ func (mr *MockRepositoryMockRecorder) GetBomInfoByBMCMacAddr(ctx, macAddr interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBomInfoByBMCMacAddr", reflect.TypeOf((*MockRepository)(nil).GetBomInfoByBMCMacAddr), ctx, macAddr)
 }
+
+// GetBomInfoBySerial mocks base method.
+func (m *MockRepository) GetBomInfoBySerial(ctx context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBomInfoBySerial", ctx, serial)
+	ret0, _ := ret[0].(*fleetdbapi.Bom)
+	ret1, _ := ret[1].(*fleetdbapi.ServerResponse)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBomInfoBySerial indicates an expected call of GetBomInfoBySerial.
+func (mr *MockRepositoryMockRecorder) GetBomInfoBySerial(ctx, serial interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBomInfoBySerial", reflect.TypeOf((*MockRepository)(nil).GetBomInfoBySerial), ctx, serial)
+}
+
+// ListBoms mocks base method.
+func (m *MockRepository) ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) ([]fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBoms", ctx, params)
+	ret0, _ := ret[0].([]fleetdbapi.Bom)
+	ret1, _ := ret[1].(*fleetdbapi.ServerResponse)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListBoms indicates an expected call of ListBoms.
+func (mr *MockRepositoryMockRecorder) ListBoms(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBoms", reflect.TypeOf((*MockRepository)(nil).ListBoms), ctx, params)
+}
@@ -2,14 +2,24 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 
@@ -21,6 +31,9 @@ type Serverservice struct {
 	config *app.ServerserviceOptions
 	client *fleetdbapi.Client
 	logger *logrus.Logger
+	// httpClient and authToken are used for requests the fleetdb client does not wrap.
+	httpClient *http.Client
+	authToken  string
 }
 
 var (
@@ -30,35 +43,68 @@ var (
 	// ErrServserviceAttribute is returned when a serverservice attribute does not contain the expected fields.
 	ErrServerserviceAttribute = errors.New("error in serverservice attribute")
 
+	// bomInfoPath is the fleetdb bill of materials API path.
+	bomInfoPath = "api/v1/bill-of-materials"
+
+	// readinessPath is the fleetdb readiness check path.
+	readinessPath = "healthz/readiness"
+
 	// connectionTimeout is the maximum amount of time spent on each http connection to serverservice.
 	connectionTimeout = 30 * time.Second
 )
 
 func newServerserviceStore(ctx context.Context, config *app.ServerserviceOptions, logger *logrus.Logger) (Repository, error) {
-	s := &Serverservice{logger: logger, config: config}
+	httpClient, authToken, err := newHTTPClient(ctx, config, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	var client *fleetdbapi.Client
-	var err error
+	client, err := fleetdbapi.NewClientWithToken(authToken, config.Endpoint, httpClient)
+	if err != nil {
+		return nil, err
+	}
 
-	if !config.DisableOAuth {
-		client, err = newClientWithOAuth(ctx, config, logger)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		client, err = fleetdbapi.NewClientWithToken("fake", config.Endpoint, nil)
-		if err != nil {
-			return nil, err
-		}
+	return &Serverservice{
+		logger:     logger,
+		config:     config,
+		client:     client,
+		httpClient: httpClient,
+		authToken:  authToken,
+	}, nil
+}
+
+// NewFleetDBClient returns a fleetdb client with the same http client and credentials as the store,
+// for components that query fleetdb server records.
+func NewFleetDBClient(ctx context.Context, config *app.ServerserviceOptions, logger *logrus.Logger) (*fleetdbapi.Client, error) {
+	httpClient, authToken, err := newHTTPClient(ctx, config, logger)
+	if err != nil {
+		return nil, err
 	}
 
-	s.client = client
+	return fleetdbapi.NewClientWithToken(authToken, config.Endpoint, httpClient)
+}
+
+// newHTTPClient returns the http client and auth token to use for serverservice requests.
+func newHTTPClient(ctx context.Context, config *app.ServerserviceOptions, logger *logrus.Logger) (*http.Client, string, error) {
+	if config.DisableOAuth {
+		return &http.Client{Timeout: connectionTimeout, Transport: newTransport(http.DefaultTransport)}, "fake", nil
+	}
+
+	httpClient, err := newClientWithOAuth(ctx, config, logger)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return httpClient, config.OidcClientSecret, nil
+}
 
-	return s, nil
+// newTransport wraps the transport to propagate the trace context and the API request ID to fleetdb.
+func newTransport(next http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(requestid.NewTransport(next))
 }
 
 // returns a serverservice retryable http client with Otel and Oauth wrapped in
-func newClientWithOAuth(ctx context.Context, cfg *app.ServerserviceOptions, logger *logrus.Logger) (*fleetdbapi.Client, error) {
+func newClientWithOAuth(ctx context.Context, cfg *app.ServerserviceOptions, logger *logrus.Logger) (*http.Client, error) {
 	// init retryable http client
 	retryableClient := retryablehttp.NewClient()
 
@@ -95,30 +141,231 @@ func newClientWithOAuth(ctx context.Context, cfg *app.ServerserviceOptions, logg
 	// wrap OAuth transport, cookie jar in the retryable client
 	oAuthclient := oauthConfig.Client(ctx)
 
-	retryableClient.HTTPClient.Transport = oAuthclient.Transport
+	// trace context and request IDs are propagated to fleetdb on each attempt.
+	retryableClient.HTTPClient.Transport = newTransport(oAuthclient.Transport)
 	retryableClient.HTTPClient.Jar = oAuthclient.Jar
 
 	httpClient := retryableClient.StandardClient()
 	httpClient.Timeout = connectionTimeout
 
-	return fleetdbapi.NewClientWithToken(
-		cfg.OidcClientSecret,
-		cfg.Endpoint,
-		httpClient,
-	)
+	return httpClient, nil
 }
 
 // BillOfMaterialsBatchUpload will attempt to write multiple boms to database.
-func (s *Serverservice) BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error) {
-	return s.client.BillOfMaterialsBatchUpload(ctx, boms)
+func (s *Serverservice) BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (resp *fleetdbapi.ServerResponse, err error) {
+	defer observe("BillOfMaterialsBatchUpload", time.Now(), &err)
+
+	resp, err = s.client.BillOfMaterialsBatchUpload(ctx, boms)
+	if err != nil {
+		return nil, backendError(err, "upload")
+	}
+
+	return resp, nil
 }
 
 // GetBomInfoByAOCMacAddr will return the bom info object by the aoc mac address.
-func (s *Serverservice) GetBomInfoByAOCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
-	return s.client.GetBomInfoByAOCMacAddr(ctx, macAddr)
+func (s *Serverservice) GetBomInfoByAOCMacAddr(ctx context.Context, macAddr string) (_ *fleetdbapi.Bom, _ *fleetdbapi.ServerResponse, err error) {
+	defer observe("GetBomInfoByAOCMacAddr", time.Now(), &err)
+
+	bom, resp, err := s.client.GetBomInfoByAOCMacAddr(ctx, macAddr)
+	if err != nil {
+		return nil, nil, backendError(err, "aoc mac address "+macAddr)
+	}
+
+	return bom, resp, nil
 }
 
 // GetBomInfoByBMCMacAddr will return the bom info object by the bmc mac address.
-func (s *Serverservice) GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
-	return s.client.GetBomInfoByBMCMacAddr(ctx, macAddr)
+func (s *Serverservice) GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (_ *fleetdbapi.Bom, _ *fleetdbapi.ServerResponse, err error) {
+	defer observe("GetBomInfoByBMCMacAddr", time.Now(), &err)
+
+	bom, resp, err := s.client.GetBomInfoByBMCMacAddr(ctx, macAddr)
+	if err != nil {
+		return nil, nil, backendError(err, "bmc mac address "+macAddr)
+	}
+
+	return bom, resp, nil
+}
+
+// GetBomInfoBySerial will return the bom info object by serial number.
+//
+// fleetdb does not look up boms by serial, the bom is found by the MAC addresses of the server enrolled with the serial:
+// those recorded in its bom attributes on enrollment, or else the BMC MAC address in its inventory.
+func (s *Serverservice) GetBomInfoBySerial(ctx context.Context, serial string) (_ *fleetdbapi.Bom, _ *fleetdbapi.ServerResponse, err error) {
+	defer observe("GetBomInfoBySerial", time.Now(), &err)
+
+	server, err := inventory.ServerBySerial(ctx, s.client, serial)
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrBackendUnavailable, "serial "+serial+": "+err.Error())
+	}
+
+	if server == nil {
+		return nil, nil, errors.Wrap(ErrBomNotFound, "serial "+serial+": no server enrolled")
+	}
+
+	bmc, aoc, err := s.serverMacAddrs(ctx, server.UUID)
+	if err != nil {
+		return nil, nil, backendError(err, "serial "+serial)
+	}
+
+	bom, err := BomByMacAddrs(ctx, s, bmc, aoc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// a MAC address moved to another serial by a later upload doesn't match.
+	if bom == nil || bom.SerialNum != serial {
+		return nil, nil, errors.Wrap(ErrBomNotFound, "serial "+serial)
+	}
+
+	return bom, &fleetdbapi.ServerResponse{Record: bom}, nil
+}
+
+// serverMacAddrs returns the BMC and AOC MAC addresses in the bom attributes of the server,
+// or the BMC MAC address in its inventory when it has no bom attributes.
+func (s *Serverservice) serverMacAddrs(ctx context.Context, id uuid.UUID) (bmc, aoc []string, err error) {
+	attr, _, err := s.client.GetAttributes(ctx, id, inventory.BomAttributesNamespace)
+
+	switch {
+	case err == nil:
+		bmc, aoc = inventory.BomMacAddrs(&fleetdbapi.Server{Attributes: []fleetdbapi.Attributes{*attr}})
+		if len(bmc)+len(aoc) > 0 {
+			return bmc, aoc, nil
+		}
+	case !inventory.IsNotFound(err):
+		return nil, nil, err
+	}
+
+	inv, _, err := s.client.GetServerInventory(ctx, id, false)
+	if err != nil {
+		if inventory.IsNotFound(err) {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	if addr := inventory.BMCMacAddr(inv); addr != "" {
+		bmc = append(bmc, addr)
+	}
+
+	return bmc, nil, nil
+}
+
+// observe records the fleetdb call latency for the method, and the error unless it is a bom not being found.
+func observe(method string, start time.Time, err *error) {
+	metrics.StoreCall(method, start, *err != nil && !errors.Is(*err, ErrBomNotFound))
+}
+
+// backendError returns the store error for a failed fleetdb request on the subject,
+// fleetdb responses are mapped by status code and transport errors are ErrBackendUnavailable.
+func backendError(err error, subject string) error {
+	var serverErr fleetdbapi.ServerError
+	if !errors.As(err, &serverErr) {
+		return errors.Wrap(ErrBackendUnavailable, subject+": "+err.Error())
+	}
+
+	return statusError(serverErr.StatusCode, subject+": "+serverErr.Error())
+}
+
+// statusError returns the store error for a fleetdb response status code.
+func statusError(code int, msg string) error {
+	switch code {
+	case http.StatusNotFound:
+		return errors.Wrap(ErrBomNotFound, msg)
+	case http.StatusConflict:
+		return errors.Wrap(ErrConflict, msg)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return errors.Wrap(ErrInvalidBom, msg)
+	default:
+		return errors.Wrap(ErrBackendUnavailable, msg)
+	}
+}
+
+// Ping checks fleetdb is ready, the request is made with the store http client
+// so an OAuth token has to be obtained for it to succeed.
+func (s *Serverservice) Ping(ctx context.Context) (err error) {
+	defer observe("Ping", time.Now(), &err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.EndpointURL.JoinPath(readinessPath).String(), http.NoBody)
+	if err != nil {
+		return errors.Wrap(ErrRepository, err.Error())
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(ErrBackendUnavailable, err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(ErrBackendUnavailable, "fleetdb readiness check returned "+resp.Status)
+	}
+
+	return nil
+}
+
+// ListBoms will return a page of the boms stored in fleetdb.
+//
+// The fleetdb client does not wrap listing the bill of materials collection,
+// so the request is made with the http client and credentials of the store.
+// fleetdb releases up to v1.20.3 do not serve the listing, ErrListUnsupported is returned for them.
+func (s *Serverservice) ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) (_ []fleetdbapi.Bom, _ *fleetdbapi.ServerResponse, err error) {
+	defer observe("ListBoms", time.Now(), &err)
+
+	requestURL := s.config.EndpointURL.JoinPath(bomInfoPath)
+
+	if params != nil {
+		q := requestURL.Query()
+
+		if params.Limit > 0 {
+			q.Set("limit", strconv.Itoa(params.Limit))
+		}
+
+		if params.Page > 0 {
+			q.Set("page", strconv.Itoa(params.Page))
+		}
+
+		requestURL.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), http.NoBody)
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrRepository, err.Error())
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("bearer %s", s.authToken))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrBackendUnavailable, err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrBackendUnavailable, err.Error())
+	}
+
+	// the collection always exists, a 404 is an endpoint the fleetdb version does not serve.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, errors.Wrap(ErrListUnsupported, "fleetdb returned "+resp.Status+" for "+bomInfoPath)
+	}
+
+	if resp.StatusCode >= http.StatusMultiStatus {
+		return nil, nil, statusError(resp.StatusCode, "list boms: "+fleetdbapi.ServerError{StatusCode: resp.StatusCode, ErrorMessage: string(data)}.Error())
+	}
+
+	boms := []fleetdbapi.Bom{}
+	serverResponse := &fleetdbapi.ServerResponse{Records: &boms}
+
+	if err := json.Unmarshal(data, serverResponse); err != nil {
+		return nil, nil, errors.Wrap(ErrRepository, err.Error())
+	}
+
+	return boms, serverResponse, nil
 }
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	common "github.com/metal-toolbox/bmc-common"
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/inventory"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	rivets "github.com/metal-toolbox/rivets/v2/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerservicePing(t *testing.T) {
+	status := http.StatusOK
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz/readiness", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	endpointURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &app.ServerserviceOptions{Endpoint: srv.URL, EndpointURL: endpointURL, DisableOAuth: true}
+
+	repository, err := newServerserviceStore(context.TODO(), config, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, Ping(context.TODO(), repository))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorIs(t, Ping(context.TODO(), repository), ErrBackendUnavailable)
+}
+
+func TestServerserviceMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics.Use(metrics.New(registry))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/bill-of-materials/bmc-mac-address/{mac}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/v1/bill-of-materials", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	endpointURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &app.ServerserviceOptions{Endpoint: srv.URL, EndpointURL: endpointURL, DisableOAuth: true}
+
+	repository, err := newServerserviceStore(context.TODO(), config, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = repository.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.ErrorIs(t, err, ErrBomNotFound)
+
+	_, _, err = repository.ListBoms(context.TODO(), nil)
+	assert.NotNil(t, err)
+
+	// a bom not being found is not a fleetdb error.
+	expected := `
+# HELP bomservice_fleetdb_errors_total fleetdb call errors by repository method, lookups of unknown boms are not errors
+# TYPE bomservice_fleetdb_errors_total counter
+bomservice_fleetdb_errors_total{method="ListBoms"} 1
+`
+
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "bomservice_fleetdb_errors_total"); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := testutil.GatherAndCount(registry, "bomservice_fleetdb_latency_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, count)
+}
+
+func TestServerserviceErrors(t *testing.T) {
+	status := http.StatusOK
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	endpointURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &app.ServerserviceOptions{Endpoint: srv.URL, EndpointURL: endpointURL, DisableOAuth: true}
+
+	repository, err := newServerserviceStore(context.TODO(), config, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrBomNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusBadRequest, ErrInvalidBom},
+		{http.StatusUnprocessableEntity, ErrInvalidBom},
+		{http.StatusInternalServerError, ErrBackendUnavailable},
+		{http.StatusBadGateway, ErrBackendUnavailable},
+	}
+
+	for _, tc := range testcases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			status = tc.status
+
+			_, _, err := repository.GetBomInfoByAOCMacAddr(context.TODO(), "aoc-1")
+			assert.ErrorIs(t, err, tc.want)
+
+			_, err = repository.BillOfMaterialsBatchUpload(context.TODO(), nil)
+			assert.ErrorIs(t, err, tc.want)
+		})
+	}
+
+	// listing the collection is not a lookup, a 404 is an endpoint fleetdb does not serve.
+	status = http.StatusNotFound
+	_, _, err = repository.ListBoms(context.TODO(), nil)
+	assert.ErrorIs(t, err, ErrListUnsupported)
+
+	srv.Close()
+
+	_, _, err = repository.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+}
+
+func TestServerserviceGetBomInfoBySerial(t *testing.T) {
+	// serial-1 was enrolled with bom attributes, serial-2 only has an inventory,
+	// and the bmc of serial-3 is in the bom of another serial.
+	servers := map[string]uuid.UUID{"serial-1": uuid.New(), "serial-2": uuid.New(), "serial-3": uuid.New()}
+	boms := map[string]*fleetdbapi.Bom{
+		"aa:aa:aa:aa:aa:01": {SerialNum: "serial-1", BmcMacAddress: "aa:aa:aa:aa:aa:01"},
+		"BB:BB:BB:BB:BB:01": {SerialNum: "serial-2", BmcMacAddress: "BB:BB:BB:BB:BB:01"},
+		"cc:cc:cc:cc:cc:01": {SerialNum: "serial-other", BmcMacAddress: "cc:cc:cc:cc:cc:01"},
+	}
+
+	bomAttributes, err := json.Marshal(map[string][]string{"bmc_mac_address": {"aa:aa:aa:aa:aa:01"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventories := map[uuid.UUID]string{servers["serial-2"]: "BB:BB:BB:BB:BB:01", servers["serial-3"]: "cc:cc:cc:cc:cc:01"}
+
+	encode := func(w http.ResponseWriter, resp *fleetdbapi.ServerResponse) {
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		records := []fleetdbapi.Server{}
+
+		// attribute filters are encoded as namespace~key~operator~value
+		parts := strings.Split(r.URL.Query().Get("attr"), "~")
+		if id, ok := servers[parts[len(parts)-1]]; ok {
+			records = append(records, fleetdbapi.Server{UUID: id})
+		}
+
+		encode(w, &fleetdbapi.ServerResponse{Records: records})
+	})
+	mux.HandleFunc("GET /api/v1/servers/{id}/attributes/{ns}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != servers["serial-1"].String() || r.PathValue("ns") != inventory.BomAttributesNamespace {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		encode(w, &fleetdbapi.ServerResponse{Record: &fleetdbapi.Attributes{Namespace: inventory.BomAttributesNamespace, Data: bomAttributes}})
+	})
+	mux.HandleFunc("GET /api/v1/inventory/{id}", func(w http.ResponseWriter, r *http.Request) {
+		addr, ok := inventories[uuid.MustParse(r.PathValue("id"))]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		inv := &rivets.Server{Components: []*rivets.Component{
+			{Name: common.SlugBMC, Attributes: &rivets.ComponentAttributes{MacAddress: addr}},
+		}}
+
+		encode(w, &fleetdbapi.ServerResponse{Record: inv})
+	})
+	mux.HandleFunc("GET /api/v1/bill-of-materials/bmc-mac-address/{mac}", func(w http.ResponseWriter, r *http.Request) {
+		bom, ok := boms[r.PathValue("mac")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		encode(w, &fleetdbapi.ServerResponse{Record: bom})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	endpointURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &app.ServerserviceOptions{Endpoint: srv.URL, EndpointURL: endpointURL, DisableOAuth: true}
+
+	repository, err := newServerserviceStore(context.TODO(), config, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		serial string
+		want   error
+	}{
+		{"serial-1", nil},
+		{"serial-2", nil},
+		{"serial-3", ErrBomNotFound},
+		{"serial-unknown", ErrBomNotFound},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.serial, func(t *testing.T) {
+			bom, _, err := repository.GetBomInfoBySerial(context.TODO(), tc.serial)
+			if tc.want != nil {
+				assert.ErrorIs(t, err, tc.want)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tc.serial, bom.SerialNum)
+		})
+	}
+}
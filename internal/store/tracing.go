@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the repository spans.
+const tracerName = "github.com/metal-toolbox/bomservice/internal/store"
+
+// Traced is a Repository decorator recording a span for each call to the wrapped Repository.
+type Traced struct {
+	repository Repository
+}
+
+// NewTraced returns a Traced wrapping the repository.
+func NewTraced(repository Repository) *Traced {
+	return &Traced{repository: repository}
+}
+
+// GetBomInfoByAOCMacAddr gets bom object by AOCMacAddr.
+func (t *Traced) GetBomInfoByAOCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	ctx, span := startSpan(ctx, "GetBomInfoByAOCMacAddr", attribute.String("bom.mac_address", macAddr))
+	defer span.End()
+
+	bom, resp, err := t.repository.GetBomInfoByAOCMacAddr(ctx, macAddr)
+
+	return bom, resp, endSpan(span, err)
+}
+
+// GetBomInfoByBMCMacAddr gets bom object by BMCMacAddr.
+func (t *Traced) GetBomInfoByBMCMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	ctx, span := startSpan(ctx, "GetBomInfoByBMCMacAddr", attribute.String("bom.mac_address", macAddr))
+	defer span.End()
+
+	bom, resp, err := t.repository.GetBomInfoByBMCMacAddr(ctx, macAddr)
+
+	return bom, resp, endSpan(span, err)
+}
+
+// GetBomInfoBySerial gets bom object by serial number.
+func (t *Traced) GetBomInfoBySerial(ctx context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	ctx, span := startSpan(ctx, "GetBomInfoBySerial", attribute.String("bom.serial_num", serial))
+	defer span.End()
+
+	bom, resp, err := t.repository.GetBomInfoBySerial(ctx, serial)
+
+	return bom, resp, endSpan(span, err)
+}
+
+// BillOfMaterialsBatchUpload creates a bom on a server.
+func (t *Traced) BillOfMaterialsBatchUpload(ctx context.Context, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error) {
+	ctx, span := startSpan(ctx, "BillOfMaterialsBatchUpload", attribute.Int("bom.count", len(boms)))
+	defer span.End()
+
+	resp, err := t.repository.BillOfMaterialsBatchUpload(ctx, boms)
+
+	return resp, endSpan(span, err)
+}
+
+// ListBoms lists a page of the stored bom objects.
+func (t *Traced) ListBoms(ctx context.Context, params *fleetdbapi.PaginationParams) ([]fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+	var attrs []attribute.KeyValue
+	if params != nil {
+		attrs = append(attrs, attribute.Int("page", params.Page), attribute.Int("limit", params.Limit))
+	}
+
+	ctx, span := startSpan(ctx, "ListBoms", attrs...)
+	defer span.End()
+
+	boms, resp, err := t.repository.ListBoms(ctx, params)
+	span.SetAttributes(attribute.Int("bom.count", len(boms)))
+
+	return boms, resp, endSpan(span, err)
+}
+
+// Ping checks the wrapped repository backend is available.
+func (t *Traced) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "Ping")
+	defer span.End()
+
+	return endSpan(span, Ping(ctx, t.repository))
+}
+
+// Configure applies the reloadable settings to the wrapped repository.
+func (t *Traced) Configure(config *app.Configuration) {
+	Configure(t.repository, config)
+}
+
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "Repository."+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error on the span, a bom not being found is not a span error.
+func endSpan(span trace.Span, err error) error {
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrBomNotFound):
+		span.SetAttributes(attribute.Bool("bom.found", false))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/app"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+
+	return exporter
+}
+
+func TestTraced(t *testing.T) {
+	exporter := newTestTracer(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), "bmc-1").
+		Return(nil, nil, errors.Wrap(ErrBomNotFound, "bmc mac address bmc-1")).
+		Times(1)
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("fleetdb unavailable")).
+		Times(1)
+
+	traced := NewTraced(repository)
+
+	_, _, err := traced.GetBomInfoByBMCMacAddr(context.TODO(), "bmc-1")
+	assert.ErrorIs(t, err, ErrBomNotFound)
+
+	_, err = traced.BillOfMaterialsBatchUpload(context.TODO(), []fleetdbapi.Bom{{SerialNum: "serial-1"}})
+	assert.EqualError(t, err, "fleetdb unavailable")
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+
+	assert.Equal(t, "Repository.GetBomInfoByBMCMacAddr", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, "Repository.BillOfMaterialsBatchUpload", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
+
+func TestTracePropagation(t *testing.T) {
+	exporter := newTestTracer(t)
+
+	var traceparent string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/bill-of-materials/bmc-mac-address/{mac}", func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"record": {"serial_num": "serial-1"}}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	endpointURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &app.ServerserviceOptions{Endpoint: srv.URL, EndpointURL: endpointURL, DisableOAuth: true}
+
+	repository, err := newServerserviceStore(context.TODO(), config, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+
+	_, _, err = NewTraced(repository).GetBomInfoByBMCMacAddr(ctx, "bmc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span.End()
+
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+
+	for _, s := range exporter.GetSpans() {
+		assert.Equal(t, span.SpanContext().TraceID(), s.SpanContext.TraceID(), s.Name)
+	}
+}
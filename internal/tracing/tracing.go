@@ -0,0 +1,91 @@
+// Package tracing sets up the OpenTelemetry trace provider and propagators.
+//
+// Packages create their spans with otel.Tracer named after the package import path,
+// so spans are recorded once Init installs the provider and dropped otherwise.
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/version"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// ExporterOTLP exports spans over OTLP HTTP to the configured endpoint.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans to stdout.
+	ExporterStdout = "stdout"
+)
+
+var (
+	ErrTracing = errors.New("tracing configuration error")
+)
+
+// Shutdown flushes and stops the trace provider.
+type Shutdown func(ctx context.Context) error
+
+// Init installs the global trace provider with the configured exporter and the W3C trace context propagator.
+//
+// The propagator is installed even when tracing is disabled, so trace context received
+// from clients is passed on to fleetdb.
+func Init(ctx context.Context, options *app.TracingOptions) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !options.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, options, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewProvider(exporter, options.SampleRatio)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider returns a trace provider batching spans to the exporter, sampling the given ratio of root spans.
+func NewProvider(exporter sdktrace.SpanExporter, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", model.AppName),
+			attribute.String("service.version", version.Current().AppVersion),
+		)),
+	)
+}
+
+func newExporter(ctx context.Context, options *app.TracingOptions, stdout io.Writer) (sdktrace.SpanExporter, error) {
+	switch options.Exporter {
+	case ExporterOTLP:
+		if options.Endpoint == "" {
+			return nil, errors.Wrap(ErrTracing, "otlp exporter endpoint not defined")
+		}
+
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(stdout))
+	default:
+		return nil, errors.Wrap(ErrTracing, "unsupported exporter: "+options.Exporter)
+	}
+}
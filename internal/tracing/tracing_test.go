@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	exporter, err := newExporter(context.Background(), &app.TracingOptions{Exporter: ExporterStdout}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewProvider(exporter, 1)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, buf.String(), `"Name":"test-span"`)
+	assert.Contains(t, buf.String(), `"Value":"bomservice"`)
+
+	_, err = newExporter(context.Background(), &app.TracingOptions{Exporter: ExporterOTLP}, buf)
+	assert.ErrorIs(t, err, ErrTracing)
+
+	_, err = newExporter(context.Background(), &app.TracingOptions{Exporter: "zipkin"}, buf)
+	assert.ErrorIs(t, err, ErrTracing)
+}
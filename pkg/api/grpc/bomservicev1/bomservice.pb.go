@@ -0,0 +1,1293 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.3
+// 	protoc        v5.29.3
+// source: bomservice.proto
+
+package bomservicev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MacRole is the role a MAC address plays in a BOM.
+type MacRole int32
+
+const (
+	MacRole_MAC_ROLE_UNSPECIFIED MacRole = 0
+	MacRole_MAC_ROLE_AOC         MacRole = 1
+	MacRole_MAC_ROLE_BMC         MacRole = 2
+)
+
+// Enum value maps for MacRole.
+var (
+	MacRole_name = map[int32]string{
+		0: "MAC_ROLE_UNSPECIFIED",
+		1: "MAC_ROLE_AOC",
+		2: "MAC_ROLE_BMC",
+	}
+	MacRole_value = map[string]int32{
+		"MAC_ROLE_UNSPECIFIED": 0,
+		"MAC_ROLE_AOC":         1,
+		"MAC_ROLE_BMC":         2,
+	}
+)
+
+func (x MacRole) Enum() *MacRole {
+	p := new(MacRole)
+	*p = x
+	return p
+}
+
+func (x MacRole) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MacRole) Descriptor() protoreflect.EnumDescriptor {
+	return file_bomservice_proto_enumTypes[0].Descriptor()
+}
+
+func (MacRole) Type() protoreflect.EnumType {
+	return &file_bomservice_proto_enumTypes[0]
+}
+
+func (x MacRole) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MacRole.Descriptor instead.
+func (MacRole) EnumDescriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{0}
+}
+
+type BomEvent_Type int32
+
+const (
+	BomEvent_TYPE_UNSPECIFIED BomEvent_Type = 0
+	// TYPE_UPSERTED is a BOM stored or updated.
+	BomEvent_TYPE_UPSERTED BomEvent_Type = 1
+	// TYPE_REMOVED is a BOM no longer stored.
+	BomEvent_TYPE_REMOVED BomEvent_Type = 2
+)
+
+// Enum value maps for BomEvent_Type.
+var (
+	BomEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_UPSERTED",
+		2: "TYPE_REMOVED",
+	}
+	BomEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_UPSERTED":    1,
+		"TYPE_REMOVED":     2,
+	}
+)
+
+func (x BomEvent_Type) Enum() *BomEvent_Type {
+	p := new(BomEvent_Type)
+	*p = x
+	return p
+}
+
+func (x BomEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BomEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_bomservice_proto_enumTypes[1].Descriptor()
+}
+
+func (BomEvent_Type) Type() protoreflect.EnumType {
+	return &file_bomservice_proto_enumTypes[1]
+}
+
+func (x BomEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BomEvent_Type.Descriptor instead.
+func (BomEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{14, 0}
+}
+
+// Bom is the bill of materials of a server, identified by its serial number.
+type Bom struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SerialNum       string                 `protobuf:"bytes,1,opt,name=serial_num,json=serialNum,proto3" json:"serial_num,omitempty"`
+	AocMacAddresses []string               `protobuf:"bytes,2,rep,name=aoc_mac_addresses,json=aocMacAddresses,proto3" json:"aoc_mac_addresses,omitempty"`
+	BmcMacAddresses []string               `protobuf:"bytes,3,rep,name=bmc_mac_addresses,json=bmcMacAddresses,proto3" json:"bmc_mac_addresses,omitempty"`
+	NumDefiPmi      string                 `protobuf:"bytes,4,opt,name=num_defi_pmi,json=numDefiPmi,proto3" json:"num_defi_pmi,omitempty"`
+	NumDefPwd       string                 `protobuf:"bytes,5,opt,name=num_def_pwd,json=numDefPwd,proto3" json:"num_def_pwd,omitempty"`
+	Metro           string                 `protobuf:"bytes,6,opt,name=metro,proto3" json:"metro,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Bom) Reset() {
+	*x = Bom{}
+	mi := &file_bomservice_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bom) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bom) ProtoMessage() {}
+
+func (x *Bom) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bom.ProtoReflect.Descriptor instead.
+func (*Bom) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Bom) GetSerialNum() string {
+	if x != nil {
+		return x.SerialNum
+	}
+	return ""
+}
+
+func (x *Bom) GetAocMacAddresses() []string {
+	if x != nil {
+		return x.AocMacAddresses
+	}
+	return nil
+}
+
+func (x *Bom) GetBmcMacAddresses() []string {
+	if x != nil {
+		return x.BmcMacAddresses
+	}
+	return nil
+}
+
+func (x *Bom) GetNumDefiPmi() string {
+	if x != nil {
+		return x.NumDefiPmi
+	}
+	return ""
+}
+
+func (x *Bom) GetNumDefPwd() string {
+	if x != nil {
+		return x.NumDefPwd
+	}
+	return ""
+}
+
+func (x *Bom) GetMetro() string {
+	if x != nil {
+		return x.Metro
+	}
+	return ""
+}
+
+// MacMatch is a BOM listing a MAC address.
+type MacMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          MacRole                `protobuf:"varint,1,opt,name=role,proto3,enum=bomservice.v1.MacRole" json:"role,omitempty"`
+	Bom           *Bom                   `protobuf:"bytes,2,opt,name=bom,proto3" json:"bom,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MacMatch) Reset() {
+	*x = MacMatch{}
+	mi := &file_bomservice_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MacMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MacMatch) ProtoMessage() {}
+
+func (x *MacMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MacMatch.ProtoReflect.Descriptor instead.
+func (*MacMatch) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MacMatch) GetRole() MacRole {
+	if x != nil {
+		return x.Role
+	}
+	return MacRole_MAC_ROLE_UNSPECIFIED
+}
+
+func (x *MacMatch) GetBom() *Bom {
+	if x != nil {
+		return x.Bom
+	}
+	return nil
+}
+
+// MacLookupResult lists the BOMs listing a MAC address.
+type MacLookupResult struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	MacAddress string                 `protobuf:"bytes,1,opt,name=mac_address,json=macAddress,proto3" json:"mac_address,omitempty"`
+	Matches    []*MacMatch            `protobuf:"bytes,2,rep,name=matches,proto3" json:"matches,omitempty"`
+	// collision is true when the MAC address is listed by more than one serial number.
+	Collision     bool `protobuf:"varint,3,opt,name=collision,proto3" json:"collision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MacLookupResult) Reset() {
+	*x = MacLookupResult{}
+	mi := &file_bomservice_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MacLookupResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MacLookupResult) ProtoMessage() {}
+
+func (x *MacLookupResult) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MacLookupResult.ProtoReflect.Descriptor instead.
+func (*MacLookupResult) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MacLookupResult) GetMacAddress() string {
+	if x != nil {
+		return x.MacAddress
+	}
+	return ""
+}
+
+func (x *MacLookupResult) GetMatches() []*MacMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *MacLookupResult) GetCollision() bool {
+	if x != nil {
+		return x.Collision
+	}
+	return false
+}
+
+type GetBomByMacRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MacAddress    string                 `protobuf:"bytes,1,opt,name=mac_address,json=macAddress,proto3" json:"mac_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBomByMacRequest) Reset() {
+	*x = GetBomByMacRequest{}
+	mi := &file_bomservice_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBomByMacRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBomByMacRequest) ProtoMessage() {}
+
+func (x *GetBomByMacRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBomByMacRequest.ProtoReflect.Descriptor instead.
+func (*GetBomByMacRequest) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBomByMacRequest) GetMacAddress() string {
+	if x != nil {
+		return x.MacAddress
+	}
+	return ""
+}
+
+type GetBomBySerialRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SerialNum     string                 `protobuf:"bytes,1,opt,name=serial_num,json=serialNum,proto3" json:"serial_num,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBomBySerialRequest) Reset() {
+	*x = GetBomBySerialRequest{}
+	mi := &file_bomservice_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBomBySerialRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBomBySerialRequest) ProtoMessage() {}
+
+func (x *GetBomBySerialRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBomBySerialRequest.ProtoReflect.Descriptor instead.
+func (*GetBomBySerialRequest) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBomBySerialRequest) GetSerialNum() string {
+	if x != nil {
+		return x.SerialNum
+	}
+	return ""
+}
+
+type BulkLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MacAddresses  []string               `protobuf:"bytes,1,rep,name=mac_addresses,json=macAddresses,proto3" json:"mac_addresses,omitempty"`
+	SerialNums    []string               `protobuf:"bytes,2,rep,name=serial_nums,json=serialNums,proto3" json:"serial_nums,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkLookupRequest) Reset() {
+	*x = BulkLookupRequest{}
+	mi := &file_bomservice_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkLookupRequest) ProtoMessage() {}
+
+func (x *BulkLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkLookupRequest.ProtoReflect.Descriptor instead.
+func (*BulkLookupRequest) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BulkLookupRequest) GetMacAddresses() []string {
+	if x != nil {
+		return x.MacAddresses
+	}
+	return nil
+}
+
+func (x *BulkLookupRequest) GetSerialNums() []string {
+	if x != nil {
+		return x.SerialNums
+	}
+	return nil
+}
+
+// BulkLookupResponse holds the records found in request order, and the keys not found.
+type BulkLookupResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	MacAddresses         []*MacLookupResult     `protobuf:"bytes,1,rep,name=mac_addresses,json=macAddresses,proto3" json:"mac_addresses,omitempty"`
+	SerialNums           []*Bom                 `protobuf:"bytes,2,rep,name=serial_nums,json=serialNums,proto3" json:"serial_nums,omitempty"`
+	NotFoundMacAddresses []string               `protobuf:"bytes,3,rep,name=not_found_mac_addresses,json=notFoundMacAddresses,proto3" json:"not_found_mac_addresses,omitempty"`
+	NotFoundSerialNums   []string               `protobuf:"bytes,4,rep,name=not_found_serial_nums,json=notFoundSerialNums,proto3" json:"not_found_serial_nums,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *BulkLookupResponse) Reset() {
+	*x = BulkLookupResponse{}
+	mi := &file_bomservice_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkLookupResponse) ProtoMessage() {}
+
+func (x *BulkLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkLookupResponse.ProtoReflect.Descriptor instead.
+func (*BulkLookupResponse) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BulkLookupResponse) GetMacAddresses() []*MacLookupResult {
+	if x != nil {
+		return x.MacAddresses
+	}
+	return nil
+}
+
+func (x *BulkLookupResponse) GetSerialNums() []*Bom {
+	if x != nil {
+		return x.SerialNums
+	}
+	return nil
+}
+
+func (x *BulkLookupResponse) GetNotFoundMacAddresses() []string {
+	if x != nil {
+		return x.NotFoundMacAddresses
+	}
+	return nil
+}
+
+func (x *BulkLookupResponse) GetNotFoundSerialNums() []string {
+	if x != nil {
+		return x.NotFoundSerialNums
+	}
+	return nil
+}
+
+// UploadOptions are the options of an upload, as the query parameters of the HTTP upload.
+type UploadOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// metro tags the uploaded BOMs, clients granted metro scopes may only upload to their metros.
+	Metro string `protobuf:"bytes,1,opt,name=metro,proto3" json:"metro,omitempty"`
+	// flag_mac_collisions stores BOMs with MAC addresses claimed by more than one serial number
+	// in different roles and lists them in the response, instead of rejecting the upload.
+	FlagMacCollisions bool `protobuf:"varint,2,opt,name=flag_mac_collisions,json=flagMacCollisions,proto3" json:"flag_mac_collisions,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UploadOptions) Reset() {
+	*x = UploadOptions{}
+	mi := &file_bomservice_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadOptions) ProtoMessage() {}
+
+func (x *UploadOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadOptions.ProtoReflect.Descriptor instead.
+func (*UploadOptions) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UploadOptions) GetMetro() string {
+	if x != nil {
+		return x.Metro
+	}
+	return ""
+}
+
+func (x *UploadOptions) GetFlagMacCollisions() bool {
+	if x != nil {
+		return x.FlagMacCollisions
+	}
+	return false
+}
+
+type UploadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*UploadRequest_Options
+	//	*UploadRequest_Chunk
+	Payload       isUploadRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadRequest) Reset() {
+	*x = UploadRequest{}
+	mi := &file_bomservice_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadRequest) ProtoMessage() {}
+
+func (x *UploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadRequest.ProtoReflect.Descriptor instead.
+func (*UploadRequest) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UploadRequest) GetPayload() isUploadRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *UploadRequest) GetOptions() *UploadOptions {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadRequest_Options); ok {
+			return x.Options
+		}
+	}
+	return nil
+}
+
+func (x *UploadRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadRequest_Payload interface {
+	isUploadRequest_Payload()
+}
+
+type UploadRequest_Options struct {
+	Options *UploadOptions `protobuf:"bytes,1,opt,name=options,proto3,oneof"`
+}
+
+type UploadRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*UploadRequest_Options) isUploadRequest_Payload() {}
+
+func (*UploadRequest_Chunk) isUploadRequest_Payload() {}
+
+// MacCollision is a MAC address claimed by more than one serial number.
+type MacCollision struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	MacAddress string                 `protobuf:"bytes,1,opt,name=mac_address,json=macAddress,proto3" json:"mac_address,omitempty"`
+	// serials are the serial numbers claiming the address in the upload.
+	Serials []string `protobuf:"bytes,2,rep,name=serials,proto3" json:"serials,omitempty"`
+	// existing_serials are the other serial numbers claiming the address in the stored BOMs.
+	ExistingSerials []string `protobuf:"bytes,3,rep,name=existing_serials,json=existingSerials,proto3" json:"existing_serials,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *MacCollision) Reset() {
+	*x = MacCollision{}
+	mi := &file_bomservice_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MacCollision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MacCollision) ProtoMessage() {}
+
+func (x *MacCollision) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MacCollision.ProtoReflect.Descriptor instead.
+func (*MacCollision) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MacCollision) GetMacAddress() string {
+	if x != nil {
+		return x.MacAddress
+	}
+	return ""
+}
+
+func (x *MacCollision) GetSerials() []string {
+	if x != nil {
+		return x.Serials
+	}
+	return nil
+}
+
+func (x *MacCollision) GetExistingSerials() []string {
+	if x != nil {
+		return x.ExistingSerials
+	}
+	return nil
+}
+
+// EnrolledServer is a fleetdb server record for an uploaded BOM.
+type EnrolledServer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SerialNum     string                 `protobuf:"bytes,1,opt,name=serial_num,json=serialNum,proto3" json:"serial_num,omitempty"`
+	ServerUuid    string                 `protobuf:"bytes,2,opt,name=server_uuid,json=serverUuid,proto3" json:"server_uuid,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrolledServer) Reset() {
+	*x = EnrolledServer{}
+	mi := &file_bomservice_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrolledServer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrolledServer) ProtoMessage() {}
+
+func (x *EnrolledServer) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrolledServer.ProtoReflect.Descriptor instead.
+func (*EnrolledServer) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EnrolledServer) GetSerialNum() string {
+	if x != nil {
+		return x.SerialNum
+	}
+	return ""
+}
+
+func (x *EnrolledServer) GetServerUuid() string {
+	if x != nil {
+		return x.ServerUuid
+	}
+	return ""
+}
+
+func (x *EnrolledServer) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Enrollment lists the fleetdb server records for the uploaded BOMs.
+type Enrollment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Created       []*EnrolledServer      `protobuf:"bytes,1,rep,name=created,proto3" json:"created,omitempty"`
+	Existing      []*EnrolledServer      `protobuf:"bytes,2,rep,name=existing,proto3" json:"existing,omitempty"`
+	Failed        []*EnrolledServer      `protobuf:"bytes,3,rep,name=failed,proto3" json:"failed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enrollment) Reset() {
+	*x = Enrollment{}
+	mi := &file_bomservice_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enrollment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enrollment) ProtoMessage() {}
+
+func (x *Enrollment) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enrollment.ProtoReflect.Descriptor instead.
+func (*Enrollment) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Enrollment) GetCreated() []*EnrolledServer {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Enrollment) GetExisting() []*EnrolledServer {
+	if x != nil {
+		return x.Existing
+	}
+	return nil
+}
+
+func (x *Enrollment) GetFailed() []*EnrolledServer {
+	if x != nil {
+		return x.Failed
+	}
+	return nil
+}
+
+type UploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metro         string                 `protobuf:"bytes,1,opt,name=metro,proto3" json:"metro,omitempty"`
+	SerialNums    []string               `protobuf:"bytes,2,rep,name=serial_nums,json=serialNums,proto3" json:"serial_nums,omitempty"`
+	MacCollisions []*MacCollision        `protobuf:"bytes,3,rep,name=mac_collisions,json=macCollisions,proto3" json:"mac_collisions,omitempty"`
+	// enrollment is set when enrollment is enabled.
+	Enrollment    *Enrollment `protobuf:"bytes,4,opt,name=enrollment,proto3" json:"enrollment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadResponse) Reset() {
+	*x = UploadResponse{}
+	mi := &file_bomservice_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadResponse) ProtoMessage() {}
+
+func (x *UploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadResponse.ProtoReflect.Descriptor instead.
+func (*UploadResponse) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UploadResponse) GetMetro() string {
+	if x != nil {
+		return x.Metro
+	}
+	return ""
+}
+
+func (x *UploadResponse) GetSerialNums() []string {
+	if x != nil {
+		return x.SerialNums
+	}
+	return nil
+}
+
+func (x *UploadResponse) GetMacCollisions() []*MacCollision {
+	if x != nil {
+		return x.MacCollisions
+	}
+	return nil
+}
+
+func (x *UploadResponse) GetEnrollment() *Enrollment {
+	if x != nil {
+		return x.Enrollment
+	}
+	return nil
+}
+
+type WatchBomsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// metro streams only the changes to the BOMs tagged with the metro.
+	Metro         string `protobuf:"bytes,1,opt,name=metro,proto3" json:"metro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchBomsRequest) Reset() {
+	*x = WatchBomsRequest{}
+	mi := &file_bomservice_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchBomsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBomsRequest) ProtoMessage() {}
+
+func (x *WatchBomsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBomsRequest.ProtoReflect.Descriptor instead.
+func (*WatchBomsRequest) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchBomsRequest) GetMetro() string {
+	if x != nil {
+		return x.Metro
+	}
+	return ""
+}
+
+// BomEvent is a change to a stored BOM.
+type BomEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          BomEvent_Type          `protobuf:"varint,1,opt,name=type,proto3,enum=bomservice.v1.BomEvent_Type" json:"type,omitempty"`
+	Bom           *Bom                   `protobuf:"bytes,2,opt,name=bom,proto3" json:"bom,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BomEvent) Reset() {
+	*x = BomEvent{}
+	mi := &file_bomservice_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BomEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BomEvent) ProtoMessage() {}
+
+func (x *BomEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_bomservice_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BomEvent.ProtoReflect.Descriptor instead.
+func (*BomEvent) Descriptor() ([]byte, []int) {
+	return file_bomservice_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BomEvent) GetType() BomEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return BomEvent_TYPE_UNSPECIFIED
+}
+
+func (x *BomEvent) GetBom() *Bom {
+	if x != nil {
+		return x.Bom
+	}
+	return nil
+}
+
+func (x *BomEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_bomservice_proto protoreflect.FileDescriptor
+
+var file_bomservice_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0d, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0xd4, 0x01, 0x0a, 0x03, 0x42, 0x6f, 0x6d, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65,
+	0x72, 0x69, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x12, 0x2a, 0x0a, 0x11, 0x61, 0x6f, 0x63,
+	0x5f, 0x6d, 0x61, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x61, 0x6f, 0x63, 0x4d, 0x61, 0x63, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x62, 0x6d, 0x63, 0x5f, 0x6d, 0x61, 0x63,
+	0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0f, 0x62, 0x6d, 0x63, 0x4d, 0x61, 0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65,
+	0x73, 0x12, 0x20, 0x0a, 0x0c, 0x6e, 0x75, 0x6d, 0x5f, 0x64, 0x65, 0x66, 0x69, 0x5f, 0x70, 0x6d,
+	0x69, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6e, 0x75, 0x6d, 0x44, 0x65, 0x66, 0x69,
+	0x50, 0x6d, 0x69, 0x12, 0x1e, 0x0a, 0x0b, 0x6e, 0x75, 0x6d, 0x5f, 0x64, 0x65, 0x66, 0x5f, 0x70,
+	0x77, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x75, 0x6d, 0x44, 0x65, 0x66,
+	0x50, 0x77, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x65, 0x74, 0x72, 0x6f, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6d, 0x65, 0x74, 0x72, 0x6f, 0x22, 0x5c, 0x0a, 0x08, 0x4d, 0x61, 0x63,
+	0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2a, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61, 0x63, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x04, 0x72, 0x6f, 0x6c,
+	0x65, 0x12, 0x24, 0x0a, 0x03, 0x62, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x6f, 0x6d, 0x52, 0x03, 0x62, 0x6f, 0x6d, 0x22, 0x83, 0x01, 0x0a, 0x0f, 0x4d, 0x61, 0x63, 0x4c,
+	0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6d,
+	0x61, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x6d, 0x61, 0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x31, 0x0a, 0x07,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61,
+	0x63, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x09, 0x63, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x35, 0x0a,
+	0x12, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6d, 0x42, 0x79, 0x4d, 0x61, 0x63, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x61, 0x63, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x22, 0x36, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6d, 0x42, 0x79,
+	0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x22, 0x59, 0x0a, 0x11,
+	0x42, 0x75, 0x6c, 0x6b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x61, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x61, 0x63, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x5f, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x72,
+	0x69, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x73, 0x22, 0xf8, 0x01, 0x0a, 0x12, 0x42, 0x75, 0x6c, 0x6b,
+	0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43,
+	0x0a, 0x0d, 0x6d, 0x61, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61, 0x63, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x0c, 0x6d, 0x61, 0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x12, 0x33, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x5f, 0x6e, 0x75,
+	0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x6d, 0x52, 0x0a, 0x73, 0x65,
+	0x72, 0x69, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x73, 0x12, 0x35, 0x0a, 0x17, 0x6e, 0x6f, 0x74, 0x5f,
+	0x66, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x6d, 0x61, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x14, 0x6e, 0x6f, 0x74, 0x46, 0x6f,
+	0x75, 0x6e, 0x64, 0x4d, 0x61, 0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12,
+	0x31, 0x0a, 0x15, 0x6e, 0x6f, 0x74, 0x5f, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x73, 0x65, 0x72,
+	0x69, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x12,
+	0x6e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4e, 0x75,
+	0x6d, 0x73, 0x22, 0x55, 0x0a, 0x0d, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x65, 0x74, 0x72, 0x6f, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6d, 0x65, 0x74, 0x72, 0x6f, 0x12, 0x2e, 0x0a, 0x13, 0x66, 0x6c, 0x61,
+	0x67, 0x5f, 0x6d, 0x61, 0x63, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x66, 0x6c, 0x61, 0x67, 0x4d, 0x61, 0x63, 0x43,
+	0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x6c, 0x0a, 0x0d, 0x55, 0x70, 0x6c,
+	0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a, 0x07, 0x6f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x6f,
+	0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x6c, 0x6f,
+	0x61, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x48, 0x00, 0x52, 0x07, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x09, 0x0a, 0x07,
+	0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x74, 0x0a, 0x0c, 0x4d, 0x61, 0x63, 0x43, 0x6f,
+	0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x63, 0x5f, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x61,
+	0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x65, 0x78, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x73,
+	0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x65, 0x78,
+	0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x22, 0x66, 0x0a,
+	0x0e, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x65, 0x64, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x12,
+	0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x12, 0x1f,
+	0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x55, 0x75, 0x69, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xb7, 0x01, 0x0a, 0x0a, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x65, 0x64, 0x53, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x39, 0x0a,
+	0x08, 0x65, 0x78, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1d, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x65, 0x64, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x52, 0x08,
+	0x65, 0x78, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x35, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c,
+	0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x65,
+	0x64, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x22,
+	0xc6, 0x01, 0x0a, 0x0e, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x65, 0x74, 0x72, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x6d, 0x65, 0x74, 0x72, 0x6f, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x73,
+	0x65, 0x72, 0x69, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x73, 0x12, 0x42, 0x0a, 0x0e, 0x6d, 0x61, 0x63,
+	0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1b, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x4d, 0x61, 0x63, 0x43, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0d,
+	0x6d, 0x61, 0x63, 0x43, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x39, 0x0a,
+	0x0a, 0x65, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0a, 0x65, 0x6e,
+	0x72, 0x6f, 0x6c, 0x6c, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x28, 0x0a, 0x10, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x42, 0x6f, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x6d, 0x65, 0x74, 0x72, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x65, 0x74,
+	0x72, 0x6f, 0x22, 0xd5, 0x01, 0x0a, 0x08, 0x42, 0x6f, 0x6d, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x30, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c, 0x2e,
+	0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f,
+	0x6d, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x12, 0x24, 0x0a, 0x03, 0x62, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x6f, 0x6d, 0x52, 0x03, 0x62, 0x6f, 0x6d, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x41, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x50,
+	0x53, 0x45, 0x52, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x02, 0x2a, 0x47, 0x0a, 0x07, 0x4d, 0x61,
+	0x63, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x14, 0x4d, 0x41, 0x43, 0x5f, 0x52, 0x4f, 0x4c,
+	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x10, 0x0a, 0x0c, 0x4d, 0x41, 0x43, 0x5f, 0x52, 0x4f, 0x4c, 0x45, 0x5f, 0x41, 0x4f, 0x43, 0x10,
+	0x01, 0x12, 0x10, 0x0a, 0x0c, 0x4d, 0x41, 0x43, 0x5f, 0x52, 0x4f, 0x4c, 0x45, 0x5f, 0x42, 0x4d,
+	0x43, 0x10, 0x02, 0x32, 0x8f, 0x03, 0x0a, 0x0a, 0x42, 0x6f, 0x6d, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x50, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6d, 0x42, 0x79, 0x4d, 0x61,
+	0x63, 0x12, 0x21, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6d, 0x42, 0x79, 0x4d, 0x61, 0x63, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61, 0x63, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x4a, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6d, 0x42, 0x79,
+	0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x24, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x6d, 0x42, 0x79, 0x53,
+	0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x62,
+	0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x6d,
+	0x12, 0x51, 0x0a, 0x0a, 0x42, 0x75, 0x6c, 0x6b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x12, 0x20,
+	0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x75, 0x6c, 0x6b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x21, 0x2e, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31,
+	0x2e, 0x42, 0x75, 0x6c, 0x6b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x06, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x2e,
+	0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70,
+	0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x62, 0x6f,
+	0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x6c, 0x6f,
+	0x61, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x47, 0x0a, 0x09,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x6f, 0x6d, 0x73, 0x12, 0x1f, 0x2e, 0x62, 0x6f, 0x6d, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42,
+	0x6f, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x62, 0x6f, 0x6d,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x6d, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x30, 0x01, 0x42, 0x4c, 0x5a, 0x4a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x65, 0x74, 0x61, 0x6c, 0x2d, 0x74, 0x6f, 0x6f, 0x6c, 0x62, 0x6f,
+	0x78, 0x2f, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x6b, 0x67,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x76, 0x31, 0x3b, 0x62, 0x6f, 0x6d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_bomservice_proto_rawDescOnce sync.Once
+	file_bomservice_proto_rawDescData = file_bomservice_proto_rawDesc
+)
+
+func file_bomservice_proto_rawDescGZIP() []byte {
+	file_bomservice_proto_rawDescOnce.Do(func() {
+		file_bomservice_proto_rawDescData = protoimpl.X.CompressGZIP(file_bomservice_proto_rawDescData)
+	})
+	return file_bomservice_proto_rawDescData
+}
+
+var file_bomservice_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_bomservice_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_bomservice_proto_goTypes = []any{
+	(MacRole)(0),                  // 0: bomservice.v1.MacRole
+	(BomEvent_Type)(0),            // 1: bomservice.v1.BomEvent.Type
+	(*Bom)(nil),                   // 2: bomservice.v1.Bom
+	(*MacMatch)(nil),              // 3: bomservice.v1.MacMatch
+	(*MacLookupResult)(nil),       // 4: bomservice.v1.MacLookupResult
+	(*GetBomByMacRequest)(nil),    // 5: bomservice.v1.GetBomByMacRequest
+	(*GetBomBySerialRequest)(nil), // 6: bomservice.v1.GetBomBySerialRequest
+	(*BulkLookupRequest)(nil),     // 7: bomservice.v1.BulkLookupRequest
+	(*BulkLookupResponse)(nil),    // 8: bomservice.v1.BulkLookupResponse
+	(*UploadOptions)(nil),         // 9: bomservice.v1.UploadOptions
+	(*UploadRequest)(nil),         // 10: bomservice.v1.UploadRequest
+	(*MacCollision)(nil),          // 11: bomservice.v1.MacCollision
+	(*EnrolledServer)(nil),        // 12: bomservice.v1.EnrolledServer
+	(*Enrollment)(nil),            // 13: bomservice.v1.Enrollment
+	(*UploadResponse)(nil),        // 14: bomservice.v1.UploadResponse
+	(*WatchBomsRequest)(nil),      // 15: bomservice.v1.WatchBomsRequest
+	(*BomEvent)(nil),              // 16: bomservice.v1.BomEvent
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_bomservice_proto_depIdxs = []int32{
+	0,  // 0: bomservice.v1.MacMatch.role:type_name -> bomservice.v1.MacRole
+	2,  // 1: bomservice.v1.MacMatch.bom:type_name -> bomservice.v1.Bom
+	3,  // 2: bomservice.v1.MacLookupResult.matches:type_name -> bomservice.v1.MacMatch
+	4,  // 3: bomservice.v1.BulkLookupResponse.mac_addresses:type_name -> bomservice.v1.MacLookupResult
+	2,  // 4: bomservice.v1.BulkLookupResponse.serial_nums:type_name -> bomservice.v1.Bom
+	9,  // 5: bomservice.v1.UploadRequest.options:type_name -> bomservice.v1.UploadOptions
+	12, // 6: bomservice.v1.Enrollment.created:type_name -> bomservice.v1.EnrolledServer
+	12, // 7: bomservice.v1.Enrollment.existing:type_name -> bomservice.v1.EnrolledServer
+	12, // 8: bomservice.v1.Enrollment.failed:type_name -> bomservice.v1.EnrolledServer
+	11, // 9: bomservice.v1.UploadResponse.mac_collisions:type_name -> bomservice.v1.MacCollision
+	13, // 10: bomservice.v1.UploadResponse.enrollment:type_name -> bomservice.v1.Enrollment
+	1,  // 11: bomservice.v1.BomEvent.type:type_name -> bomservice.v1.BomEvent.Type
+	2,  // 12: bomservice.v1.BomEvent.bom:type_name -> bomservice.v1.Bom
+	17, // 13: bomservice.v1.BomEvent.time:type_name -> google.protobuf.Timestamp
+	5,  // 14: bomservice.v1.BomService.GetBomByMac:input_type -> bomservice.v1.GetBomByMacRequest
+	6,  // 15: bomservice.v1.BomService.GetBomBySerial:input_type -> bomservice.v1.GetBomBySerialRequest
+	7,  // 16: bomservice.v1.BomService.BulkLookup:input_type -> bomservice.v1.BulkLookupRequest
+	10, // 17: bomservice.v1.BomService.Upload:input_type -> bomservice.v1.UploadRequest
+	15, // 18: bomservice.v1.BomService.WatchBoms:input_type -> bomservice.v1.WatchBomsRequest
+	4,  // 19: bomservice.v1.BomService.GetBomByMac:output_type -> bomservice.v1.MacLookupResult
+	2,  // 20: bomservice.v1.BomService.GetBomBySerial:output_type -> bomservice.v1.Bom
+	8,  // 21: bomservice.v1.BomService.BulkLookup:output_type -> bomservice.v1.BulkLookupResponse
+	14, // 22: bomservice.v1.BomService.Upload:output_type -> bomservice.v1.UploadResponse
+	16, // 23: bomservice.v1.BomService.WatchBoms:output_type -> bomservice.v1.BomEvent
+	19, // [19:24] is the sub-list for method output_type
+	14, // [14:19] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_bomservice_proto_init() }
+func file_bomservice_proto_init() {
+	if File_bomservice_proto != nil {
+		return
+	}
+	file_bomservice_proto_msgTypes[8].OneofWrappers = []any{
+		(*UploadRequest_Options)(nil),
+		(*UploadRequest_Chunk)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_bomservice_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bomservice_proto_goTypes,
+		DependencyIndexes: file_bomservice_proto_depIdxs,
+		EnumInfos:         file_bomservice_proto_enumTypes,
+		MessageInfos:      file_bomservice_proto_msgTypes,
+	}.Build()
+	File_bomservice_proto = out.File
+	file_bomservice_proto_rawDesc = nil
+	file_bomservice_proto_goTypes = nil
+	file_bomservice_proto_depIdxs = nil
+}
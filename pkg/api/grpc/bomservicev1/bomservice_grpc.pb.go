@@ -0,0 +1,292 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: bomservice.proto
+
+package bomservicev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BomService_GetBomByMac_FullMethodName    = "/bomservice.v1.BomService/GetBomByMac"
+	BomService_GetBomBySerial_FullMethodName = "/bomservice.v1.BomService/GetBomBySerial"
+	BomService_BulkLookup_FullMethodName     = "/bomservice.v1.BomService/BulkLookup"
+	BomService_Upload_FullMethodName         = "/bomservice.v1.BomService/Upload"
+	BomService_WatchBoms_FullMethodName      = "/bomservice.v1.BomService/WatchBoms"
+)
+
+// BomServiceClient is the client API for BomService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BomService looks up, uploads and watches the bill of materials of servers.
+//
+// Requests are authenticated as HTTP API requests, with the credentials in the request metadata,
+// and the methods require the scopes of the matching HTTP routes, metro scopes included.
+type BomServiceClient interface {
+	// GetBomByMac returns the BOMs listing the MAC address as an AOC or BMC address.
+	GetBomByMac(ctx context.Context, in *GetBomByMacRequest, opts ...grpc.CallOption) (*MacLookupResult, error)
+	// GetBomBySerial returns the BOM with the serial number.
+	GetBomBySerial(ctx context.Context, in *GetBomBySerialRequest, opts ...grpc.CallOption) (*Bom, error)
+	// BulkLookup looks up the MAC addresses and serial numbers, the keys not found are listed in the response.
+	BulkLookup(ctx context.Context, in *BulkLookupRequest, opts ...grpc.CallOption) (*BulkLookupResponse, error)
+	// Upload stores the BOMs in an xlsx file streamed in chunks,
+	// the options are sent in the first message and the file in the messages that follow.
+	Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadRequest, UploadResponse], error)
+	// WatchBoms streams the changes to the stored BOMs from the time of the call.
+	WatchBoms(ctx context.Context, in *WatchBomsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BomEvent], error)
+}
+
+type bomServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBomServiceClient(cc grpc.ClientConnInterface) BomServiceClient {
+	return &bomServiceClient{cc}
+}
+
+func (c *bomServiceClient) GetBomByMac(ctx context.Context, in *GetBomByMacRequest, opts ...grpc.CallOption) (*MacLookupResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MacLookupResult)
+	err := c.cc.Invoke(ctx, BomService_GetBomByMac_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bomServiceClient) GetBomBySerial(ctx context.Context, in *GetBomBySerialRequest, opts ...grpc.CallOption) (*Bom, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Bom)
+	err := c.cc.Invoke(ctx, BomService_GetBomBySerial_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bomServiceClient) BulkLookup(ctx context.Context, in *BulkLookupRequest, opts ...grpc.CallOption) (*BulkLookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BulkLookupResponse)
+	err := c.cc.Invoke(ctx, BomService_BulkLookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bomServiceClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadRequest, UploadResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BomService_ServiceDesc.Streams[0], BomService_Upload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadRequest, UploadResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BomService_UploadClient = grpc.ClientStreamingClient[UploadRequest, UploadResponse]
+
+func (c *bomServiceClient) WatchBoms(ctx context.Context, in *WatchBomsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BomEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BomService_ServiceDesc.Streams[1], BomService_WatchBoms_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchBomsRequest, BomEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BomService_WatchBomsClient = grpc.ServerStreamingClient[BomEvent]
+
+// BomServiceServer is the server API for BomService service.
+// All implementations must embed UnimplementedBomServiceServer
+// for forward compatibility.
+//
+// BomService looks up, uploads and watches the bill of materials of servers.
+//
+// Requests are authenticated as HTTP API requests, with the credentials in the request metadata,
+// and the methods require the scopes of the matching HTTP routes, metro scopes included.
+type BomServiceServer interface {
+	// GetBomByMac returns the BOMs listing the MAC address as an AOC or BMC address.
+	GetBomByMac(context.Context, *GetBomByMacRequest) (*MacLookupResult, error)
+	// GetBomBySerial returns the BOM with the serial number.
+	GetBomBySerial(context.Context, *GetBomBySerialRequest) (*Bom, error)
+	// BulkLookup looks up the MAC addresses and serial numbers, the keys not found are listed in the response.
+	BulkLookup(context.Context, *BulkLookupRequest) (*BulkLookupResponse, error)
+	// Upload stores the BOMs in an xlsx file streamed in chunks,
+	// the options are sent in the first message and the file in the messages that follow.
+	Upload(grpc.ClientStreamingServer[UploadRequest, UploadResponse]) error
+	// WatchBoms streams the changes to the stored BOMs from the time of the call.
+	WatchBoms(*WatchBomsRequest, grpc.ServerStreamingServer[BomEvent]) error
+	mustEmbedUnimplementedBomServiceServer()
+}
+
+// UnimplementedBomServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBomServiceServer struct{}
+
+func (UnimplementedBomServiceServer) GetBomByMac(context.Context, *GetBomByMacRequest) (*MacLookupResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBomByMac not implemented")
+}
+func (UnimplementedBomServiceServer) GetBomBySerial(context.Context, *GetBomBySerialRequest) (*Bom, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBomBySerial not implemented")
+}
+func (UnimplementedBomServiceServer) BulkLookup(context.Context, *BulkLookupRequest) (*BulkLookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BulkLookup not implemented")
+}
+func (UnimplementedBomServiceServer) Upload(grpc.ClientStreamingServer[UploadRequest, UploadResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedBomServiceServer) WatchBoms(*WatchBomsRequest, grpc.ServerStreamingServer[BomEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBoms not implemented")
+}
+func (UnimplementedBomServiceServer) mustEmbedUnimplementedBomServiceServer() {}
+func (UnimplementedBomServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeBomServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BomServiceServer will
+// result in compilation errors.
+type UnsafeBomServiceServer interface {
+	mustEmbedUnimplementedBomServiceServer()
+}
+
+func RegisterBomServiceServer(s grpc.ServiceRegistrar, srv BomServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBomServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BomService_ServiceDesc, srv)
+}
+
+func _BomService_GetBomByMac_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBomByMacRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BomServiceServer).GetBomByMac(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BomService_GetBomByMac_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BomServiceServer).GetBomByMac(ctx, req.(*GetBomByMacRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BomService_GetBomBySerial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBomBySerialRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BomServiceServer).GetBomBySerial(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BomService_GetBomBySerial_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BomServiceServer).GetBomBySerial(ctx, req.(*GetBomBySerialRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BomService_BulkLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BomServiceServer).BulkLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BomService_BulkLookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BomServiceServer).BulkLookup(ctx, req.(*BulkLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BomService_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BomServiceServer).Upload(&grpc.GenericServerStream[UploadRequest, UploadResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BomService_UploadServer = grpc.ClientStreamingServer[UploadRequest, UploadResponse]
+
+func _BomService_WatchBoms_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBomsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BomServiceServer).WatchBoms(m, &grpc.GenericServerStream[WatchBomsRequest, BomEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BomService_WatchBomsServer = grpc.ServerStreamingServer[BomEvent]
+
+// BomService_ServiceDesc is the grpc.ServiceDesc for BomService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BomService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bomservice.v1.BomService",
+	HandlerType: (*BomServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBomByMac",
+			Handler:    _BomService_GetBomByMac_Handler,
+		},
+		{
+			MethodName: "GetBomBySerial",
+			Handler:    _BomService_GetBomBySerial_Handler,
+		},
+		{
+			MethodName: "BulkLookup",
+			Handler:    _BomService_BulkLookup_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Upload",
+			Handler:       _BomService_Upload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchBoms",
+			Handler:       _BomService_WatchBoms_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bomservice.proto",
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"github.com/metal-toolbox/bomservice/pkg/api/grpc/bomservicev1"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/metal-toolbox/rivets/v2/ginjwt"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// methodJWT identifies principals authenticated by a JWT.
+const methodJWT = "jwt"
+
+// methodScopes are the scopes required by a method, or a metro scope for one of the actions,
+// as the matching HTTP routes require them.
+type methodScopes struct {
+	scopes  []string
+	actions []string
+}
+
+var methods = map[string]methodScopes{
+	bomservicev1.BomService_GetBomByMac_FullMethodName:    {ginjwt.ReadScopes("mac-address"), []string{"read"}},
+	bomservicev1.BomService_GetBomBySerial_FullMethodName: {ginjwt.ReadScopes("boms"), []string{"read"}},
+	bomservicev1.BomService_BulkLookup_FullMethodName:     {ginjwt.ReadScopes("lookup"), []string{"read"}},
+	bomservicev1.BomService_Upload_FullMethodName:         {ginjwt.CreateScopes("upload-xlsx-file"), []string{"write", "create"}},
+	bomservicev1.BomService_WatchBoms_FullMethodName:      {ginjwt.ReadScopes("watch"), []string{"read"}},
+}
+
+type grantKey struct{}
+
+// grant is the authorization of a call.
+type grant struct {
+	subject string
+	// metros are the metros a call restricted by metro scopes may access.
+	metros     []string
+	restricted bool
+}
+
+// grantFromContext returns the call authorization, calls are unrestricted when auth is disabled.
+func grantFromContext(ctx context.Context) *grant {
+	g, ok := ctx.Value(grantKey{}).(*grant)
+	if !ok {
+		return &grant{}
+	}
+
+	return g
+}
+
+// allowed returns true when the call may access the BOM, BOMs in other metros are reported as not found.
+func (g *grant) allowed(bom *fleetdbapi.Bom) bool {
+	return !g.restricted || (bom != nil && slices.Contains(g.metros, bom.Metro))
+}
+
+// uploadMetro returns the metro to tag the uploaded BOMs with, calls restricted by metro scopes
+// upload to one of their metros, which is the default when a single one is granted.
+func (g *grant) uploadMetro(metro string) (string, error) {
+	if !g.restricted {
+		return metro, nil
+	}
+
+	switch {
+	case metro == "" && len(g.metros) == 1:
+		return g.metros[0], nil
+	case metro == "":
+		return "", errors.Wrapf(v1routes.ErrMetroRequired, "granted metros: %s", strings.Join(g.metros, ","))
+	case !slices.Contains(g.metros, metro):
+		return "", errors.Wrapf(v1routes.ErrMetroScope, "metro %s, granted metros: %s", metro, strings.Join(g.metros, ","))
+	}
+
+	return metro, nil
+}
+
+func (s *Service) authEnabled() bool {
+	return s.authMW != nil || len(s.authenticators) > 0
+}
+
+// authorize authenticates the call, verifies the principal has the method scopes and limits the call rate,
+// returning the context carrying the call authorization.
+func (s *Service) authorize(ctx context.Context, method string) (context.Context, error) {
+	g := &grant{}
+
+	if s.authEnabled() {
+		principal, err := s.authenticate(ctx, method)
+		if err != nil {
+			return nil, err
+		}
+
+		required := methods[method]
+		g.subject = principal.Subject
+
+		if !auth.HasAnyScope(principal.Scopes, required.scopes) {
+			g.metros = auth.ScopedMetros(principal.Scopes, required.actions...)
+			if len(g.metros) == 0 {
+				return nil, ErrScope
+			}
+
+			g.restricted = true
+		}
+	}
+
+	if s.rateLimiter != nil {
+		key := "subject:" + g.subject
+		if g.subject == "" {
+			key = "ip:" + peerIP(ctx)
+		}
+
+		if ok, retryAfter := s.rateLimiter.Allow(key); !ok {
+			metrics.Throttled(method, metrics.LimitRate)
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(retryAfter)))
+
+			return nil, v1routes.ErrRateLimited
+		}
+	}
+
+	return context.WithValue(ctx, grantKey{}, g), nil
+}
+
+// authenticate returns the principal from the first authenticator the call carries credentials for,
+// calls without them are verified as JWT bearer tokens.
+func (s *Service) authenticate(ctx context.Context, method string) (*auth.Principal, error) {
+	req := callRequest(ctx, method)
+
+	for _, authenticator := range s.authenticators {
+		principal, err := authenticator.Authenticate(req)
+		if err != nil {
+			return nil, errors.Wrap(auth.ErrUnauthorized, err.Error())
+		}
+
+		if principal != nil {
+			return principal, nil
+		}
+	}
+
+	if s.authMW == nil {
+		return nil, errors.Wrap(auth.ErrUnauthorized, "authentication required")
+	}
+
+	// the token is verified from the request headers only.
+	claims, err := s.authMW.VerifyToken(&gin.Context{Request: req})
+	if err != nil {
+		return nil, errors.Wrap(auth.ErrUnauthorized, err.Error())
+	}
+
+	return &auth.Principal{Subject: claims.Subject, Scopes: claims.Roles, Method: methodJWT}, nil
+}
+
+// callRequest returns an HTTP request with the call metadata as headers and the peer TLS state,
+// for the HTTP API authenticators to verify the call credentials.
+func callRequest(ctx context.Context, method string) *http.Request {
+	req := (&http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: method},
+		Header: http.Header{},
+	}).WithContext(ctx)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			req.RemoteAddr = p.Addr.String()
+		}
+
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &info.State
+		}
+	}
+
+	return req
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(1, int((d+time.Second-1)/time.Second)))
+}
+
+// withRequestID returns the context carrying the call request ID, from the x-request-id metadata when valid,
+// and sends it back in the response header.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(requestid.Header); len(values) > 0 && requestid.Valid(values[0]) {
+		id = values[0]
+	}
+
+	if id == "" {
+		id = requestid.New()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+
+	return requestid.NewContext(ctx, id)
+}
+
+func (s *Service) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = withRequestID(ctx)
+
+	authorized, err := s.authorize(ctx, info.FullMethod)
+	if err == nil {
+		var resp any
+
+		ctx = authorized
+
+		resp, err = handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+	}
+
+	return nil, s.callError(ctx, info.FullMethod, err)
+}
+
+func (s *Service) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := withRequestID(ss.Context())
+
+	authorized, err := s.authorize(ctx, info.FullMethod)
+	if err == nil {
+		ctx = authorized
+		err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+
+	return s.callError(ctx, info.FullMethod, err)
+}
+
+// callError returns the call error as a status error, logging internal and backend errors.
+func (s *Service) callError(ctx context.Context, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if code, _ := errorStatus(err); code == codes.Internal || code == codes.Unavailable {
+		s.logger.WithError(err).WithFields(map[string]any{
+			"method":     method,
+			"request_id": requestid.FromContext(ctx),
+		}).Warn("grpc call failed")
+	}
+
+	return statusError(ctx, err)
+}
+
+// serverStream is a grpc.ServerStream with the context set by the interceptor.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
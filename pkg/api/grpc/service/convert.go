@@ -0,0 +1,87 @@
+package service
+
+import (
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	"github.com/metal-toolbox/bomservice/pkg/api/grpc/bomservicev1"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	macRoles = map[lookup.Role]bomservicev1.MacRole{
+		lookup.RoleAOC: bomservicev1.MacRole_MAC_ROLE_AOC,
+		lookup.RoleBMC: bomservicev1.MacRole_MAC_ROLE_BMC,
+	}
+
+	eventTypes = map[store.ChangeType]bomservicev1.BomEvent_Type{
+		store.ChangeUpserted: bomservicev1.BomEvent_TYPE_UPSERTED,
+		store.ChangeRemoved:  bomservicev1.BomEvent_TYPE_REMOVED,
+	}
+)
+
+func newBom(bom *fleetdbapi.Bom) *bomservicev1.Bom {
+	return &bomservicev1.Bom{
+		SerialNum:       bom.SerialNum,
+		AocMacAddresses: model.SplitMacAddrs(bom.AocMacAddress),
+		BmcMacAddresses: model.SplitMacAddrs(bom.BmcMacAddress),
+		NumDefiPmi:      bom.NumDefiPmi,
+		NumDefPwd:       bom.NumDefPWD,
+		Metro:           bom.Metro,
+	}
+}
+
+func newMacLookupResult(result *lookup.Result) *bomservicev1.MacLookupResult {
+	converted := &bomservicev1.MacLookupResult{MacAddress: result.MacAddress, Collision: result.Collision}
+
+	for _, m := range result.Matches {
+		converted.Matches = append(converted.Matches, &bomservicev1.MacMatch{Role: macRoles[m.Role], Bom: newBom(m.Bom)})
+	}
+
+	return converted
+}
+
+func newMacCollisions(collisions []lookup.Collision) []*bomservicev1.MacCollision {
+	converted := make([]*bomservicev1.MacCollision, 0, len(collisions))
+
+	for _, c := range collisions {
+		converted = append(converted, &bomservicev1.MacCollision{
+			MacAddress:      c.MacAddress,
+			Serials:         c.Serials,
+			ExistingSerials: c.ExistingSerials,
+		})
+	}
+
+	return converted
+}
+
+func newEnrollment(result *enroll.Result) *bomservicev1.Enrollment {
+	if result == nil {
+		return nil
+	}
+
+	servers := func(enrolled []enroll.Server) []*bomservicev1.EnrolledServer {
+		converted := make([]*bomservicev1.EnrolledServer, 0, len(enrolled))
+		for _, s := range enrolled {
+			converted = append(converted, &bomservicev1.EnrolledServer{SerialNum: s.SerialNum, ServerUuid: s.ServerUUID, Error: s.Error})
+		}
+
+		return converted
+	}
+
+	return &bomservicev1.Enrollment{
+		Created:  servers(result.Created),
+		Existing: servers(result.Existing),
+		Failed:   servers(result.Failed),
+	}
+}
+
+func newBomEvent(change *store.Change) *bomservicev1.BomEvent {
+	return &bomservicev1.BomEvent{
+		Type: eventTypes[change.Type],
+		Bom:  newBom(&change.Bom),
+		Time: timestamppb.New(change.Time),
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail of the service error statuses,
+// its reason is the error code of the matching HTTP API error response.
+const ErrorDomain = "bomservice"
+
+var (
+	ErrScope         = errors.New("not authorized, missing required scope")
+	ErrWatchOverflow = errors.New("watch fell behind the bom changes")
+	ErrWatchDisabled = errors.New("bom changes are not published")
+	ErrStopping      = errors.New("server stopping")
+)
+
+// statusCodes are the gRPC status codes for the HTTP API response statuses.
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// errorStatus returns the gRPC status code and error code for the error,
+// errors are matched as the HTTP API matches them.
+func errorStatus(err error) (codes.Code, v1routes.ErrorCode) {
+	switch {
+	case errors.Is(err, ErrScope):
+		return codes.PermissionDenied, v1routes.CodeForbidden
+	case errors.Is(err, ErrWatchOverflow):
+		return codes.Aborted, v1routes.CodeRateLimited
+	case errors.Is(err, ErrWatchDisabled):
+		return codes.Unimplemented, v1routes.CodeNotFound
+	case errors.Is(err, ErrStopping):
+		return codes.Unavailable, v1routes.CodeNotReady
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled, v1routes.CodeInternal
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded, v1routes.CodeBackendUnavailable
+	}
+
+	httpStatus, code := v1routes.ErrorStatus(err)
+
+	grpcCode, ok := statusCodes[httpStatus]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+
+	return grpcCode, code
+}
+
+// statusError returns the error as a gRPC status error, with the error code and request ID in an ErrorInfo detail.
+func statusError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	grpcCode, code := errorStatus(err)
+	st := status.New(grpcCode, err.Error())
+
+	info := &errdetails.ErrorInfo{Reason: string(code), Domain: ErrorDomain}
+	if id := requestid.FromContext(ctx); id != "" {
+		info.Metadata = map[string]string{"request_id": id}
+	}
+
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// ErrorCode returns the error code of a status error returned by the service, empty when it carries none.
+func ErrorCode(err error) v1routes.ErrorCode {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return v1routes.ErrorCode(info.GetReason())
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	"github.com/metal-toolbox/bomservice/pkg/api/grpc/bomservicev1"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+// GetBomByMac returns the BOMs listing the MAC address as an AOC or BMC address.
+func (s *Service) GetBomByMac(ctx context.Context, req *bomservicev1.GetBomByMacRequest) (*bomservicev1.MacLookupResult, error) {
+	if req.GetMacAddress() == "" {
+		return nil, errors.Wrap(v1routes.ErrInvalidRequest, "mac_address is required")
+	}
+
+	result, err := lookup.Mac(ctx, s.repository, req.GetMacAddress())
+	if err != nil {
+		return nil, err
+	}
+
+	if result = filterMacResult(grantFromContext(ctx), result); result == nil {
+		return nil, errors.Wrap(store.ErrBomNotFound, "mac address "+req.GetMacAddress())
+	}
+
+	return newMacLookupResult(result), nil
+}
+
+// GetBomBySerial returns the BOM with the serial number.
+func (s *Service) GetBomBySerial(ctx context.Context, req *bomservicev1.GetBomBySerialRequest) (*bomservicev1.Bom, error) {
+	if req.GetSerialNum() == "" {
+		return nil, errors.Wrap(v1routes.ErrInvalidRequest, "serial_num is required")
+	}
+
+	bom, err := lookup.Serial(ctx, s.repository, req.GetSerialNum())
+	if err != nil {
+		return nil, err
+	}
+
+	if !grantFromContext(ctx).allowed(bom) {
+		return nil, errors.Wrap(store.ErrBomNotFound, "serial "+req.GetSerialNum())
+	}
+
+	return newBom(bom), nil
+}
+
+// BulkLookup looks up the MAC addresses and serial numbers, the keys not found are listed in the response.
+func (s *Service) BulkLookup(ctx context.Context, req *bomservicev1.BulkLookupRequest) (*bomservicev1.BulkLookupResponse, error) {
+	result, err := lookup.Bulk(ctx, s.repository, &lookup.BulkRequest{
+		MacAddresses: req.GetMacAddresses(),
+		SerialNums:   req.GetSerialNums(),
+	}, s.lookupWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	g := grantFromContext(ctx)
+
+	macs := map[string]*lookup.Result{}
+	for _, r := range result.MacAddresses {
+		if filtered := filterMacResult(g, r); filtered != nil {
+			macs[r.MacAddress] = filtered
+		}
+	}
+
+	serials := map[string]*fleetdbapi.Bom{}
+	for i := range result.SerialNums {
+		if g.allowed(&result.SerialNums[i]) {
+			serials[result.SerialNums[i].SerialNum] = &result.SerialNums[i]
+		}
+	}
+
+	// keys are walked in request order, records the call may not access are reported as not found.
+	resp := &bomservicev1.BulkLookupResponse{}
+
+	for _, mac := range req.GetMacAddresses() {
+		r, ok := macs[mac]
+		if !ok {
+			resp.NotFoundMacAddresses = append(resp.NotFoundMacAddresses, mac)
+			continue
+		}
+
+		resp.MacAddresses = append(resp.MacAddresses, newMacLookupResult(r))
+	}
+
+	for _, serial := range req.GetSerialNums() {
+		bom, ok := serials[serial]
+		if !ok {
+			resp.NotFoundSerialNums = append(resp.NotFoundSerialNums, serial)
+			continue
+		}
+
+		resp.SerialNums = append(resp.SerialNums, newBom(bom))
+	}
+
+	return resp, nil
+}
+
+// Upload stores the BOMs in the streamed xlsx file, as the HTTP API upload does.
+func (s *Service) Upload(stream bomservicev1.BomService_UploadServer) error {
+	ctx := stream.Context()
+
+	if s.uploadLimiter != nil {
+		if ok, _ := s.uploadLimiter.Acquire(); !ok {
+			metrics.Throttled(bomservicev1.BomService_Upload_FullMethodName, metrics.LimitUploadConcurrency)
+			return v1routes.ErrUploadConcurrency
+		}
+
+		defer s.uploadLimiter.Release()
+	}
+
+	options, data, err := s.receiveUpload(stream)
+	if err != nil {
+		return err
+	}
+
+	g := grantFromContext(ctx)
+
+	metro, err := g.uploadMetro(options.GetMetro())
+	if err != nil {
+		return err
+	}
+
+	boms, stats, err := parse.ParseXlsxFileContext(ctx, data, s.mapping())
+	metrics.UploadRowsParsed(stats.Rows)
+
+	if err != nil {
+		metrics.UploadParseError(parse.ErrorCode(err))
+		return err
+	}
+
+	if metro != "" {
+		for i := range boms {
+			boms[i].Metro = metro
+		}
+	}
+
+	if err := s.checkUploadOverwrites(ctx, g, boms); err != nil {
+		return err
+	}
+
+	collisions, err := lookup.Collisions(ctx, s.repository, boms, s.lookupWorkers)
+	if err != nil {
+		return err
+	}
+
+	if err := v1routes.CheckCollisions(collisions, options.GetFlagMacCollisions()); err != nil {
+		return err
+	}
+
+	if _, err := s.repository.BillOfMaterialsBatchUpload(ctx, boms); err != nil {
+		return err
+	}
+
+	metrics.UploadBomsWritten(len(boms))
+
+	resp := &bomservicev1.UploadResponse{Metro: metro, MacCollisions: newMacCollisions(collisions)}
+	for i := range boms {
+		resp.SerialNums = append(resp.SerialNums, boms[i].SerialNum)
+	}
+
+	// the boms are stored at this point, enroll failures are reported in the response.
+	if s.enroller != nil {
+		resp.Enrollment = newEnrollment(s.enroller.Enroll(ctx, boms))
+	}
+
+	// credentials are pushed after enrollment so newly enrolled servers get theirs.
+	if s.credentials != nil {
+		s.credentials.Push(ctx, boms)
+	}
+
+	return stream.SendAndClose(resp)
+}
+
+// receiveUpload reads the upload options, sent in the first message, and the file chunks up to the maximum upload size.
+func (s *Service) receiveUpload(stream bomservicev1.BomService_UploadServer) (*bomservicev1.UploadOptions, []byte, error) {
+	var (
+		options *bomservicev1.UploadOptions
+		data    []byte
+	)
+
+	for first := true; ; first = false {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch payload := req.GetPayload().(type) {
+		case *bomservicev1.UploadRequest_Options:
+			if !first {
+				return nil, nil, errors.Wrap(v1routes.ErrInvalidRequest, "upload options must be sent in the first message")
+			}
+
+			options = payload.Options
+		case *bomservicev1.UploadRequest_Chunk:
+			if len(data)+len(payload.Chunk) > s.maxUploadSize {
+				return nil, nil, errors.Wrapf(v1routes.ErrInvalidRequest, "upload exceeds the maximum size of %d bytes", s.maxUploadSize)
+			}
+
+			data = append(data, payload.Chunk...)
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, nil, errors.Wrap(v1routes.ErrInvalidRequest, "no file chunks received")
+	}
+
+	return options, data, nil
+}
+
+// checkUploadOverwrites returns ErrMetroScope when the call is restricted by metro scopes
+// and an uploaded serial number is stored tagged with a metro it may not access.
+func (s *Service) checkUploadOverwrites(ctx context.Context, g *grant, boms []fleetdbapi.Bom) error {
+	if !g.restricted {
+		return nil
+	}
+
+	stored, err := lookup.Stored(ctx, s.repository, boms, s.lookupWorkers)
+	if err != nil {
+		return err
+	}
+
+	var denied []string
+
+	for i := range stored {
+		if !g.allowed(&stored[i]) {
+			denied = append(denied, stored[i].SerialNum)
+		}
+	}
+
+	if len(denied) > 0 {
+		slices.Sort(denied)
+		return errors.Wrapf(v1routes.ErrMetroScope, "serial numbers stored in another metro: %s", strings.Join(denied, ","))
+	}
+
+	return nil
+}
+
+// WatchBoms streams the changes to the stored BOMs the call may access, optionally of a single metro.
+//
+// The stream ends with ErrWatchOverflow when the client falls behind the changes, or ErrStopping when the server stops,
+// it should then watch again and look up the BOMs it may have missed.
+func (s *Service) WatchBoms(req *bomservicev1.WatchBomsRequest, stream bomservicev1.BomService_WatchBomsServer) error {
+	if s.feed == nil {
+		return ErrWatchDisabled
+	}
+
+	ctx := stream.Context()
+	g := grantFromContext(ctx)
+
+	// watches end when the server stops, clients should then watch another instance.
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer context.AfterFunc(s.stopping, cancel)()
+
+	changes := s.feed.Subscribe(watchCtx)
+
+	// the header is sent once subscribed, so clients know the changes from then on are streamed.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for change := range changes {
+		if (req.GetMetro() != "" && change.Bom.Metro != req.GetMetro()) || !g.allowed(&change.Bom) {
+			continue
+		}
+
+		if err := stream.Send(newBomEvent(&change)); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case watchCtx.Err() != nil:
+		return ErrStopping
+	}
+
+	return ErrWatchOverflow
+}
+
+// filterMacResult removes the matches the call may not access from the lookup result,
+// returning nil when none remain.
+func filterMacResult(g *grant, result *lookup.Result) *lookup.Result {
+	if !g.restricted {
+		return result
+	}
+
+	filtered := &lookup.Result{MacAddress: result.MacAddress}
+	serials := map[string]struct{}{}
+
+	for _, m := range result.Matches {
+		if g.allowed(m.Bom) {
+			filtered.Matches = append(filtered.Matches, m)
+			serials[m.Bom.SerialNum] = struct{}{}
+		}
+	}
+
+	if len(filtered.Matches) == 0 {
+		return nil
+	}
+
+	// collisions with BOMs in other metros are not disclosed.
+	filtered.Collision = len(serials) > 1
+
+	return filtered
+}
@@ -0,0 +1,192 @@
+// Package service serves the bomservice gRPC API, alongside the HTTP API and backed by the same store,
+// parser, credentials and scopes.
+package service
+
+import (
+	"context"
+	"net"
+
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/ratelimit"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	"github.com/metal-toolbox/bomservice/pkg/api/grpc/bomservicev1"
+	"github.com/metal-toolbox/rivets/v2/ginjwt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// DefaultMaxUploadSize is the maximum size of a streamed xlsx file when none is configured.
+const DefaultMaxUploadSize = 64 << 20
+
+var (
+	ErrStore = errors.New("store error")
+)
+
+// Service implements the bomservice.v1.BomService gRPC service.
+type Service struct {
+	bomservicev1.UnimplementedBomServiceServer
+
+	authMW *ginjwt.Middleware
+	// authenticators authenticate calls carrying credentials other than a JWT.
+	authenticators []auth.Authenticator
+	repository     store.Repository
+	// feed publishes the bom changes streamed by WatchBoms.
+	feed   *store.Feed
+	logger *logrus.Logger
+	// mapping returns the column mapping in effect for an upload.
+	mapping  func() *parse.Mapping
+	enroller *enroll.Enroller
+	// credentials pushes the BOM default BMC credentials of uploaded BOMs.
+	credentials *enroll.CredentialPusher
+	// lookupWorkers is the number of concurrent store lookups for a bulk lookup.
+	lookupWorkers int
+	rateLimiter   *ratelimit.Limiter
+	uploadLimiter *ratelimit.UploadLimiter
+	maxUploadSize int
+
+	// stopping is canceled when Serve stops, ending the watches.
+	stopping context.Context
+	stop     context.CancelFunc
+}
+
+// Option type sets a parameter on the Service type.
+type Option func(*Service)
+
+// WithStore sets the storage repository on the Service type.
+func WithStore(repository store.Repository) Option {
+	return func(s *Service) {
+		s.repository = repository
+	}
+}
+
+// WithFeed sets the feed of bom changes streamed by WatchBoms, the method is unavailable without it.
+func WithFeed(feed *store.Feed) Option {
+	return func(s *Service) {
+		s.feed = feed
+	}
+}
+
+// WithLogger sets the logger on the Service type.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithMappingFunc sets the function returning the column mapping for each upload.
+func WithMappingFunc(mapping func() *parse.Mapping) Option {
+	return func(s *Service) {
+		s.mapping = mapping
+	}
+}
+
+// WithEnroller sets the enroller that creates fleetdb server records for uploaded BOMs.
+func WithEnroller(enroller *enroll.Enroller) Option {
+	return func(s *Service) {
+		s.enroller = enroller
+	}
+}
+
+// WithCredentialPusher sets the pusher that writes the BOM default BMC credentials of uploaded BOMs to fleetdb,
+// the push results are logged.
+func WithCredentialPusher(pusher *enroll.CredentialPusher) Option {
+	return func(s *Service) {
+		s.credentials = pusher
+	}
+}
+
+// WithLookupWorkers sets the number of concurrent store lookups for a bulk lookup.
+func WithLookupWorkers(workers int) Option {
+	return func(s *Service) {
+		s.lookupWorkers = workers
+	}
+}
+
+// WithAuthMiddleware sets the JWT verification of calls not carrying other credentials.
+func WithAuthMiddleware(authMW *ginjwt.Middleware) Option {
+	return func(s *Service) {
+		s.authMW = authMW
+	}
+}
+
+// WithAuthenticators sets the authenticators tried before JWT verification,
+// calls are not authenticated when neither authenticators nor the JWT middleware are set.
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(s *Service) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithRateLimiter limits the call rate of each client.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(s *Service) {
+		s.rateLimiter = limiter
+	}
+}
+
+// WithUploadLimiter limits the number of concurrent uploads.
+func WithUploadLimiter(limiter *ratelimit.UploadLimiter) Option {
+	return func(s *Service) {
+		s.uploadLimiter = limiter
+	}
+}
+
+// WithMaxUploadSize sets the maximum size in bytes of a streamed xlsx file.
+func WithMaxUploadSize(size int) Option {
+	return func(s *Service) {
+		s.maxUploadSize = size
+	}
+}
+
+// New returns the gRPC service.
+func New(options ...Option) (*Service, error) {
+	s := &Service{
+		logger:        logrus.New(),
+		mapping:       parse.DefaultMapping,
+		lookupWorkers: lookup.DefaultWorkers,
+		maxUploadSize: DefaultMaxUploadSize,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if s.repository == nil {
+		return nil, errors.Wrap(ErrStore, "no store repository defined")
+	}
+
+	s.stopping, s.stop = context.WithCancel(context.Background())
+
+	return s, nil
+}
+
+// NewServer returns a gRPC server serving the service, calls are authenticated and rate limited by its interceptors.
+func (s *Service) NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+
+	server := grpc.NewServer(opts...)
+	bomservicev1.RegisterBomServiceServer(server, s)
+
+	return server
+}
+
+// Serve serves the service on the listener until the context is done, the watches are then ended
+// and the server stopped once the other calls complete.
+func (s *Service) Serve(ctx context.Context, listener net.Listener, opts ...grpc.ServerOption) error {
+	server := s.NewServer(opts...)
+
+	go func() {
+		<-ctx.Done()
+		s.stop()
+		server.GracefulStop()
+	}()
+
+	return server.Serve(listener)
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/ratelimit"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	"github.com/metal-toolbox/bomservice/pkg/api/grpc/bomservicev1"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// headerAuthenticator grants the scopes keyed by the X-Test-Client request header.
+type headerAuthenticator map[string][]string
+
+func (a headerAuthenticator) Authenticate(r *http.Request) (*auth.Principal, error) {
+	client := r.Header.Get("X-Test-Client")
+	if client == "" {
+		return nil, nil
+	}
+
+	return &auth.Principal{Subject: client, Scopes: a[client], Method: "test"}, nil
+}
+
+var testClients = headerAuthenticator{
+	"admin":     {"read", "write"},
+	"dc13-team": {auth.MetroScope("read", "dc13"), auth.MetroScope("write", "dc13")},
+	"nobody":    {},
+}
+
+var storedBoms = []fleetdbapi.Bom{
+	{SerialNum: "serial-1", AocMacAddress: "aa:aa:aa:aa:aa:01", BmcMacAddress: "bb:bb:bb:bb:bb:01", Metro: "dc13"},
+	{SerialNum: "serial-2", AocMacAddress: "aa:aa:aa:aa:aa:02", BmcMacAddress: "bb:bb:bb:bb:bb:02", Metro: "am6"},
+}
+
+// mockserver serves the service over an in-memory listener and returns a client connected to it.
+func mockserver(t *testing.T, repository store.Repository, opts ...Option) bomservicev1.BomServiceClient {
+	t.Helper()
+
+	client, _ := serve(t, repository, opts...)
+
+	return client
+}
+
+// serve returns a client of the service served over an in-memory listener, and the function stopping the server.
+func serve(t *testing.T, repository store.Repository, opts ...Option) (bomservicev1.BomServiceClient, context.CancelFunc) {
+	t.Helper()
+
+	service, err := New(append([]Option{WithStore(repository), WithAuthenticators(testClients)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	ctx, stop := context.WithCancel(context.Background())
+
+	go func() {
+		_ = service.Serve(ctx, listener)
+	}()
+
+	t.Cleanup(stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return bomservicev1.NewBomServiceClient(conn), stop
+}
+
+// as returns the context of a call from the test client.
+func as(client string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-test-client", client)
+}
+
+// assertStatus asserts the error is a status error with the code and error code.
+func assertStatus(t *testing.T, err error, code codes.Code, errCode v1routes.ErrorCode) {
+	t.Helper()
+
+	assert.Equal(t, code, status.Code(err), err)
+	assert.Equal(t, errCode, ErrorCode(err))
+}
+
+func expectStoredBoms(repository *mockstore.MockRepository) {
+	repository.EXPECT().
+		ListBoms(gomock.Any(), gomock.Any()).
+		Return(storedBoms, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+
+	expectSerialLookups(repository, storedBoms)
+
+	for i := range storedBoms {
+		bom := storedBoms[i]
+
+		repository.EXPECT().
+			GetBomInfoByAOCMacAddr(gomock.Any(), bom.AocMacAddress).
+			Return(&bom, &fleetdbapi.ServerResponse{}, nil).
+			AnyTimes()
+	}
+
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+}
+
+// expectSerialLookups answers serial number lookups from the boms.
+func expectSerialLookups(repository *mockstore.MockRepository, boms []fleetdbapi.Bom) {
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			for i := range boms {
+				if boms[i].SerialNum == serial {
+					bom := boms[i]
+					return &bom, &fleetdbapi.ServerResponse{Record: &bom}, nil
+				}
+			}
+
+			return nil, nil, store.ErrBomNotFound
+		}).
+		AnyTimes()
+}
+
+func TestLookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	expectStoredBoms(repository)
+
+	client := mockserver(t, repository)
+
+	t.Run("mac", func(t *testing.T) {
+		var header metadata.MD
+
+		ctx := metadata.AppendToOutgoingContext(as("admin"), requestid.Header, "lookup-1")
+
+		result, err := client.GetBomByMac(ctx, &bomservicev1.GetBomByMacRequest{MacAddress: "aa:aa:aa:aa:aa:01"}, grpc.Header(&header))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, bomservicev1.MacRole_MAC_ROLE_AOC, result.GetMatches()[0].GetRole())
+		assert.Equal(t, "serial-1", result.GetMatches()[0].GetBom().GetSerialNum())
+		assert.Equal(t, []string{"bb:bb:bb:bb:bb:01"}, result.GetMatches()[0].GetBom().GetBmcMacAddresses())
+		assert.Equal(t, []string{"lookup-1"}, header.Get(requestid.Header))
+
+		_, err = client.GetBomByMac(as("admin"), &bomservicev1.GetBomByMacRequest{MacAddress: "cc:cc:cc:cc:cc:01"})
+		assertStatus(t, err, codes.NotFound, v1routes.CodeNotFound)
+
+		_, err = client.GetBomByMac(as("admin"), &bomservicev1.GetBomByMacRequest{})
+		assertStatus(t, err, codes.InvalidArgument, v1routes.CodeInvalidRequest)
+	})
+
+	t.Run("serial", func(t *testing.T) {
+		bom, err := client.GetBomBySerial(as("admin"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "am6", bom.GetMetro())
+
+		_, err = client.GetBomBySerial(as("admin"), &bomservicev1.GetBomBySerialRequest{SerialNum: "unknown"})
+		assertStatus(t, err, codes.NotFound, v1routes.CodeNotFound)
+	})
+
+	t.Run("metro scopes", func(t *testing.T) {
+		_, err := client.GetBomBySerial(as("dc13-team"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-1"})
+		assert.Nil(t, err)
+
+		// BOMs in other metros are not found.
+		_, err = client.GetBomBySerial(as("dc13-team"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-2"})
+		assertStatus(t, err, codes.NotFound, v1routes.CodeNotFound)
+
+		_, err = client.GetBomByMac(as("dc13-team"), &bomservicev1.GetBomByMacRequest{MacAddress: "aa:aa:aa:aa:aa:02"})
+		assertStatus(t, err, codes.NotFound, v1routes.CodeNotFound)
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		req := &bomservicev1.BulkLookupRequest{
+			MacAddresses: []string{"aa:aa:aa:aa:aa:02", "cc:cc:cc:cc:cc:01", "aa:aa:aa:aa:aa:01"},
+			SerialNums:   []string{"serial-2", "serial-1", "unknown"},
+		}
+
+		resp, err := client.BulkLookup(as("admin"), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, resp.GetMacAddresses(), 2)
+		assert.Equal(t, []string{"cc:cc:cc:cc:cc:01"}, resp.GetNotFoundMacAddresses())
+		assert.Equal(t, []string{"unknown"}, resp.GetNotFoundSerialNums())
+
+		// records in other metros are not found, in request order.
+		resp, err = client.BulkLookup(as("dc13-team"), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "aa:aa:aa:aa:aa:01", resp.GetMacAddresses()[0].GetMacAddress())
+		assert.Equal(t, []string{"aa:aa:aa:aa:aa:02", "cc:cc:cc:cc:cc:01"}, resp.GetNotFoundMacAddresses())
+		assert.Equal(t, "serial-1", resp.GetSerialNums()[0].GetSerialNum())
+		assert.Equal(t, []string{"serial-2", "unknown"}, resp.GetNotFoundSerialNums())
+
+		_, err = client.BulkLookup(as("admin"), &bomservicev1.BulkLookupRequest{})
+		assertStatus(t, err, codes.InvalidArgument, v1routes.CodeInvalidRequest)
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		_, err := client.GetBomBySerial(context.Background(), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-1"})
+		assertStatus(t, err, codes.Unauthenticated, v1routes.CodeUnauthorized)
+
+		_, err = client.GetBomBySerial(as("nobody"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-1"})
+		assertStatus(t, err, codes.PermissionDenied, v1routes.CodeForbidden)
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	expectStoredBoms(repository)
+
+	limiter := ratelimit.NewLimiter(&app.RateLimitOptions{Enabled: true, RequestsPerSecond: 0.001, Burst: 1})
+	client := mockserver(t, repository, WithRateLimiter(limiter))
+
+	_, err := client.GetBomBySerial(as("admin"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-1"})
+	assert.Nil(t, err)
+
+	_, err = client.GetBomBySerial(as("admin"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-1"})
+	assertStatus(t, err, codes.ResourceExhausted, v1routes.CodeRateLimited)
+
+	// clients are limited separately.
+	_, err = client.GetBomBySerial(as("dc13-team"), &bomservicev1.GetBomBySerialRequest{SerialNum: "serial-1"})
+	assert.Nil(t, err)
+}
+
+// upload streams the file in chunks after the options.
+func upload(client bomservicev1.BomServiceClient, ctx context.Context, options *bomservicev1.UploadOptions, data []byte) (*bomservicev1.UploadResponse, error) {
+	stream, err := client.Upload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if options != nil {
+		if err := stream.Send(&bomservicev1.UploadRequest{Payload: &bomservicev1.UploadRequest_Options{Options: options}}); err != nil {
+			return nil, err
+		}
+	}
+
+	const chunkSize = 1024
+
+	for start := 0; start < len(data); start += chunkSize {
+		chunk := data[start:min(start+chunkSize, len(data))]
+		if err := stream.Send(&bomservicev1.UploadRequest{Payload: &bomservicev1.UploadRequest_Chunk{Chunk: chunk}}); err != nil {
+			return nil, err
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+func TestUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	data, err := os.ReadFile("../../../../internal/parse/testdata/test_valid_multiple_boms.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	// the BMC address FakeMac3 of the uploaded test-serial-2 is stored as the AOC address of another serial.
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "FakeMac3").
+		Return(&fleetdbapi.Bom{SerialNum: "other-serial", Metro: "dc13"}, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	expectSerialLookups(repository, []fleetdbapi.Bom{{SerialNum: "test-serial-2", Metro: "dc13"}})
+
+	client := mockserver(t, repository, WithMaxUploadSize(len(data)))
+
+	t.Run("collision", func(t *testing.T) {
+		_, err := upload(client, as("admin"), nil, data)
+		assertStatus(t, err, codes.AlreadyExists, v1routes.CodeMacCollision)
+	})
+
+	t.Run("metro not granted", func(t *testing.T) {
+		_, err := upload(client, as("dc13-team"), &bomservicev1.UploadOptions{Metro: "am6"}, data)
+		assertStatus(t, err, codes.PermissionDenied, v1routes.CodeForbidden)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := upload(client, as("admin"), &bomservicev1.UploadOptions{}, nil)
+		assertStatus(t, err, codes.InvalidArgument, v1routes.CodeInvalidRequest)
+
+		_, err = upload(client, as("admin"), &bomservicev1.UploadOptions{}, append(data, 0))
+		assertStatus(t, err, codes.InvalidArgument, v1routes.CodeInvalidRequest)
+
+		_, err = upload(client, as("admin"), &bomservicev1.UploadOptions{}, []byte("not a spreadsheet"))
+		assertStatus(t, err, codes.InvalidArgument, v1routes.CodeValidationFailed)
+	})
+
+	t.Run("uploaded", func(t *testing.T) {
+		repository.EXPECT().
+			BillOfMaterialsBatchUpload(gomock.Any(), gomock.Len(2)).
+			Return(&fleetdbapi.ServerResponse{}, nil).
+			Times(1)
+
+		resp, err := upload(client, as("dc13-team"), &bomservicev1.UploadOptions{FlagMacCollisions: true}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "dc13", resp.GetMetro())
+		assert.ElementsMatch(t, []string{"test-serial-1", "test-serial-2"}, resp.GetSerialNums())
+		assert.Equal(t, "fakemac3", resp.GetMacCollisions()[0].GetMacAddress())
+		assert.Equal(t, []string{"other-serial"}, resp.GetMacCollisions()[0].GetExistingSerials())
+		assert.Nil(t, resp.GetEnrollment())
+	})
+}
+
+func TestWatchBoms(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	feed := store.NewFeed(repository, 0)
+
+	t.Run("disabled", func(t *testing.T) {
+		client := mockserver(t, feed)
+
+		stream, err := client.WatchBoms(as("admin"), &bomservicev1.WatchBomsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	client := mockserver(t, feed, WithFeed(feed))
+
+	ctx, cancel := context.WithCancel(as("dc13-team"))
+	defer cancel()
+
+	stream, err := client.WatchBoms(ctx, &bomservicev1.WatchBomsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the header is sent once the watch is subscribed.
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	feed.Publish(
+		store.Change{Type: store.ChangeUpserted, Bom: storedBoms[1], Time: now},
+		store.Change{Type: store.ChangeUpserted, Bom: storedBoms[0], Time: now},
+		store.Change{Type: store.ChangeRemoved, Bom: storedBoms[0], Time: now},
+	)
+
+	// changes to BOMs in other metros are not streamed.
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, bomservicev1.BomEvent_TYPE_UPSERTED, event.GetType())
+	assert.Equal(t, "serial-1", event.GetBom().GetSerialNum())
+	assert.Equal(t, now, event.GetTime().AsTime())
+
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, bomservicev1.BomEvent_TYPE_REMOVED, event.GetType())
+
+	cancel()
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestWatchBomsStopping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	feed := store.NewFeed(mockstore.NewMockRepository(ctrl), 0)
+	client, stop := serve(t, feed, WithFeed(feed))
+
+	stream, err := client.WatchBoms(as("admin"), &bomservicev1.WatchBomsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server stops once the watch ends.
+	stop()
+
+	_, err = stream.Recv()
+	assertStatus(t, err, codes.Unavailable, v1routes.CodeNotReady)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 
+	"github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
 )
 
@@ -13,6 +15,11 @@ const (
 	uploadFileEndpoint         = "upload-xlsx-file"
 	bomByMacAOCAddressEndpoint = "aoc-mac-address"
 	bomByMacBMCAddressEndpoint = "bmc-mac-address"
+	bomByMacAddressEndpoint    = "mac"
+	bulkLookupEndpoint         = "lookup"
+
+	// requestIDHeader is the response header carrying the server request ID.
+	requestIDHeader = "X-Request-ID"
 )
 
 // Doer performs HTTP requests.
@@ -79,6 +86,13 @@ func (c *Client) XlsxFileUpload(ctx context.Context, fileBytes []byte) (*fleetdb
 	return c.postRawBytes(ctx, path, fileBytes)
 }
 
+// XlsxFileUploadToMetro uploads the xlsx file with the BOMs tagged with the metro,
+// clients granted metro scopes may only upload to their metros.
+func (c *Client) XlsxFileUploadToMetro(ctx context.Context, fileBytes []byte, metro string) (*fleetdbapi.ServerResponse, error) {
+	path := fmt.Sprintf("%s/%s?metro=%s", bomInfoEndpoint, uploadFileEndpoint, url.QueryEscape(metro))
+	return c.postRawBytes(ctx, path, fileBytes)
+}
+
 func (c *Client) GetBomInfoByAOCMacAddr(ctx context.Context, aocMacAddr string) (*fleetdbapi.ServerResponse, error) {
 	path := fmt.Sprintf("%s/%s/%s", bomInfoEndpoint, bomByMacAOCAddressEndpoint, aocMacAddr)
 	return c.get(ctx, path)
@@ -88,3 +102,17 @@ func (c *Client) GetBomInfoByBMCMacAddr(ctx context.Context, bmcMacAddr string)
 	path := fmt.Sprintf("servers/%s/condition/%s", bomByMacBMCAddressEndpoint, bmcMacAddr)
 	return c.get(ctx, path)
 }
+
+// GetBomInfoByMacAddr looks up the MAC address in both the AOC and BMC indexes,
+// the response record lists the boms and the role the MAC address plays in each.
+func (c *Client) GetBomInfoByMacAddr(ctx context.Context, macAddr string) (*fleetdbapi.ServerResponse, error) {
+	path := fmt.Sprintf("%s/%s/%s", bomInfoEndpoint, bomByMacAddressEndpoint, macAddr)
+	return c.get(ctx, path)
+}
+
+// BulkLookup looks up the MAC addresses and serial numbers in a single request,
+// the response record is a routes.BulkLookupResult.
+func (c *Client) BulkLookup(ctx context.Context, req *routes.BulkLookupRequest) (*fleetdbapi.ServerResponse, error) {
+	path := fmt.Sprintf("%s/%s", bomInfoEndpoint, bulkLookupEndpoint)
+	return c.post(ctx, path, req)
+}
@@ -1,6 +1,10 @@
 package client
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+)
 
 // Error holds the cause of a client error and implements the Error interface.
 type Error struct {
@@ -16,10 +20,22 @@ func (c Error) Error() string {
 type RequestError struct {
 	Message    string `json:"message"`
 	StatusCode int    `json:"statusCode"`
+	// RequestID is the X-Request-ID of the failed request, to quote when reporting the error.
+	RequestID string `json:"requestID,omitempty"`
+	// Code is the error code in the response, clients should match on it rather than the message.
+	Code routes.ErrorCode `json:"code,omitempty"`
 }
 
 // Error returns the RequestError in string format
 func (e RequestError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("bom-service client request error, statusCode: %d, code: %s, requestID: %s, message: %s", e.StatusCode, e.Code, e.RequestID, e.Message)
+	}
+
+	if e.RequestID != "" {
+		return fmt.Sprintf("bom-service client request error, statusCode: %d, requestID: %s, message: %s", e.StatusCode, e.RequestID, e.Message)
+	}
+
 	return fmt.Sprintf("bom-service client request error, statusCode: %d, message: %s", e.StatusCode, e.Message)
 }
 
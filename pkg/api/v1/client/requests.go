@@ -27,6 +27,15 @@ func (c *Client) get(ctx context.Context, path string) (*fleetdbapi.ServerRespon
 	return c.do(req)
 }
 
+func (c *Client) post(ctx context.Context, path string, body interface{}) (*fleetdbapi.ServerResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, Error{Cause: "error encoding POST request body: " + err.Error()}
+	}
+
+	return c.postRawBytes(ctx, path, data)
+}
+
 func (c *Client) postRawBytes(ctx context.Context, path string, body []byte) (*fleetdbapi.ServerResponse, error) {
 	requestURL, err := url.Parse(fmt.Sprintf("%s%s/%s", c.serverAddress, routes.PathPrefix, path))
 	if err != nil {
@@ -50,42 +59,49 @@ func (c *Client) do(req *http.Request) (*fleetdbapi.ServerResponse, error) {
 
 	response, err := c.client.Do(req)
 	if err != nil {
-		return nil, RequestError{err.Error(), c.statusCode(response)}
+		return nil, requestError(err.Error(), response)
 	}
 
 	if response == nil {
-		return nil, RequestError{"got empty response body", 0}
+		return nil, requestError("got empty response body", nil)
 	}
 
+	defer response.Body.Close()
+
 	if response.StatusCode >= http.StatusMultiStatus {
-		return nil, RequestError{"got bad request", c.statusCode(response)}
+		reqErr := requestError("got bad request", response)
+
+		// the error code is best effort, the response may not come from the bomservice.
+		var body struct {
+			Code routes.ErrorCode `json:"code"`
+		}
+
+		if data, err := io.ReadAll(response.Body); err == nil && json.Unmarshal(data, &body) == nil {
+			reqErr.Code = body.Code
+		}
+
+		return nil, reqErr
 	}
-	defer response.Body.Close()
 
 	data, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, RequestError{
-			"failed to read response body: " + err.Error(),
-			c.statusCode(response),
-		}
+		return nil, requestError("failed to read response body: "+err.Error(), response)
 	}
 
 	serverResponse := &fleetdbapi.ServerResponse{}
 
 	if err := json.Unmarshal(data, &serverResponse); err != nil {
-		return nil, RequestError{
-			"failed to unmarshal response from server: " + err.Error(),
-			c.statusCode(response),
-		}
+		return nil, requestError("failed to unmarshal response from server: "+err.Error(), response)
 	}
 
 	return serverResponse, nil
 }
 
-func (c *Client) statusCode(response *http.Response) int {
-	if response != nil {
-		return response.StatusCode
+// requestError returns a RequestError with the status code and request ID of the response, when there is one.
+func requestError(message string, response *http.Response) RequestError {
+	if response == nil {
+		return RequestError{Message: message}
 	}
 
-	return 0
+	return RequestError{Message: message, StatusCode: response.StatusCode, RequestID: response.Header.Get(requestIDHeader)}
 }
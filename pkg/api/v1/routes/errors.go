@@ -1,9 +1,125 @@
 package routes
 
-import "github.com/pkg/errors"
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
 
 var (
-	ErrStore              = errors.New("store error")
-	ErrRoutes             = errors.New("error in routes")
+	ErrStore          = errors.New("store error")
+	ErrRoutes         = errors.New("error in routes")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrNotFound       = errors.New("not found")
+	// ErrServerserviceQuery is kept for clients matching on it, store errors are now returned as is.
 	ErrServerserviceQuery = errors.New("Serverservice query error")
+	ErrMacCollision       = errors.New("mac address collision")
+	ErrMetroRequired      = errors.New("metro query parameter required")
+	ErrMetroScope         = errors.New("metro not granted")
+	ErrRateLimited        = errors.New("rate limit exceeded")
+	ErrUploadConcurrency  = errors.New("too many concurrent uploads")
 )
+
+// ErrorCode is the stable code in error responses, clients should match on it rather than the message.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest     ErrorCode = "invalid_request"
+	CodeValidationFailed   ErrorCode = "validation_failed"
+	CodeUnauthorized       ErrorCode = "unauthorized"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeConflict           ErrorCode = "conflict"
+	CodeMacCollision       ErrorCode = "mac_collision"
+	CodeRateLimited        ErrorCode = "rate_limited"
+	CodeBackendUnavailable ErrorCode = "backend_unavailable"
+	CodeNotReady           ErrorCode = "not_ready"
+	CodeNotImplemented     ErrorCode = "not_implemented"
+	CodeInternal           ErrorCode = "internal_error"
+)
+
+// contextKeyErrorCode holds the code of the error the request failed with.
+const contextKeyErrorCode = "bomservice.error_code"
+
+// errorKind is the response status and code for errors matching err.
+type errorKind struct {
+	err    error
+	status int
+	code   ErrorCode
+}
+
+// errorKinds are matched in order, errors not matching any are internal errors.
+var errorKinds = []errorKind{
+	{ErrInvalidRequest, http.StatusBadRequest, CodeInvalidRequest},
+	{ErrMetroRequired, http.StatusBadRequest, CodeInvalidRequest},
+	{lookup.ErrBulkRequest, http.StatusBadRequest, CodeInvalidRequest},
+	{export.ErrFormat, http.StatusBadRequest, CodeInvalidRequest},
+	{export.ErrExport, http.StatusBadRequest, CodeInvalidRequest},
+	{export.ErrPoolExhausted, http.StatusBadRequest, CodeInvalidRequest},
+	{parse.ErrInvalidXslxFile, http.StatusBadRequest, CodeValidationFailed},
+	{parse.ErrEmptySerialNum, http.StatusBadRequest, CodeValidationFailed},
+	{parse.ErrEmptyAOCMacAddr, http.StatusBadRequest, CodeValidationFailed},
+	{parse.ErrEmptyBMCMacAddr, http.StatusBadRequest, CodeValidationFailed},
+	{parse.ErrMissingColumn, http.StatusBadRequest, CodeValidationFailed},
+	{store.ErrInvalidBom, http.StatusBadRequest, CodeValidationFailed},
+	{auth.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+	{ErrMetroScope, http.StatusForbidden, CodeForbidden},
+	{store.ErrBomNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrMacCollision, http.StatusConflict, CodeMacCollision},
+	{store.ErrConflict, http.StatusConflict, CodeConflict},
+	{ErrRateLimited, http.StatusTooManyRequests, CodeRateLimited},
+	{ErrUploadConcurrency, http.StatusTooManyRequests, CodeRateLimited},
+	{store.ErrBackendUnavailable, http.StatusServiceUnavailable, CodeBackendUnavailable},
+	{store.ErrListUnsupported, http.StatusNotImplemented, CodeNotImplemented},
+}
+
+// ErrorStatus returns the response status and code for the error.
+func ErrorStatus(err error) (int, ErrorCode) {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.err) {
+			return kind.status, kind.code
+		}
+	}
+
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// statusErrorCode returns the code for error responses not built from an error.
+func statusErrorCode(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeBackendUnavailable
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	default:
+		return CodeInternal
+	}
+}
+
+// failed returns the status and response record for the error, and records its code for the response.
+func failed(c *gin.Context, err error) (int, *fleetdbapi.ServerResponse) {
+	status, code := ErrorStatus(err)
+	c.Set(contextKeyErrorCode, code)
+
+	return status, &fleetdbapi.ServerResponse{Error: err.Error()}
+}
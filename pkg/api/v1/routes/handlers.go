@@ -1,49 +1,344 @@
 package routes
 
 import (
+	"bytes"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
 	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/reconcile"
+	"github.com/metal-toolbox/bomservice/internal/store"
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
 	"github.com/pkg/errors"
 )
 
+const (
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// annotateQueryParam requests the uploaded workbook annotated with errors when the upload fails validation.
+	annotateQueryParam = "annotate"
+
+	// macCollisionsQueryParam set to macCollisionsFlag uploads BOMs with MAC addresses claimed by
+	// more than one serial number in different roles and reports them, instead of rejecting the upload.
+	macCollisionsQueryParam = "mac_collisions"
+	macCollisionsFlag       = "flag"
+)
+
+// UploadResult is the record in the upload response describing the post-upload steps.
+type UploadResult struct {
+	// Enrolled lists the fleetdb server records created for the uploaded BOMs, when enrollment is enabled.
+	Enrolled *enroll.Result `json:"enrolled,omitempty"`
+	// Credentials lists the BMC credentials pushed for the uploaded BOMs, when credential push is enabled.
+	Credentials *enroll.CredentialResult `json:"credentials,omitempty"`
+	// Reconciled reports the uploaded BOMs that differ from or are missing in fleetdb, when a reconciler is set.
+	Reconciled *reconcile.Report `json:"reconciled,omitempty"`
+	// MacCollisions lists the MAC addresses claimed by more than one serial number,
+	// set when the upload was requested with mac_collisions=flag.
+	MacCollisions []lookup.Collision `json:"mac_collisions,omitempty"`
+}
+
+// annotateUploadErrors responds with the uploaded workbook annotated with the parser errors
+// when the upload is requested with the annotate query parameter and the file fails validation.
+//
+// Uploads that pass validation continue on to the next handler.
+func (r *Routes) annotateUploadErrors(c *gin.Context) {
+	if annotate, _ := strconv.ParseBool(c.Query(annotateQueryParam)); !annotate || c.Request.ContentLength == -1 {
+		return
+	}
+
+	start := time.Now()
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abortWithError(c, start, errors.Wrap(ErrInvalidRequest, err.Error()))
+		return
+	}
+
+	// restore the body for the upload handler.
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	cellErrs, err := parse.ValidateXlsxFileContext(c.Request.Context(), data, r.mapping())
+	if err != nil {
+		metrics.UploadParseError(parse.ErrorCode(err))
+		abortWithError(c, start, err)
+
+		return
+	}
+
+	if len(cellErrs) == 0 {
+		return
+	}
+
+	for _, cellErr := range cellErrs {
+		metrics.UploadParseError(parse.ErrorCode(cellErr))
+	}
+
+	annotated, err := parse.Annotate(data, cellErrs)
+	if err != nil {
+		abortWithError(c, start, err)
+		return
+	}
+
+	metrics.APICallEpilog(start, c.FullPath(), http.StatusBadRequest)
+	c.Header("Content-Disposition", `attachment; filename="errors.xlsx"`)
+	c.Data(http.StatusBadRequest, xlsxContentType, annotated)
+	c.Abort()
+}
+
+// abortWithError responds with the error for handlers writing their own response.
+func abortWithError(c *gin.Context, start time.Time, err error) {
+	status, resp := failed(c, err)
+	metrics.APICallEpilog(start, c.FullPath(), status)
+
+	if status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+
+	respond(c, status, resp)
+	c.Abort()
+}
+
 func (r *Routes) billOfMaterialsBatchUpload(c *gin.Context) (int, *fleetdbapi.ServerResponse) {
 	if c.Request.ContentLength == -1 {
-		return http.StatusBadRequest, &fleetdbapi.ServerResponse{Error: "reject the request since the file size unknown"}
+		return failed(c, errors.Wrap(ErrInvalidRequest, "reject the request since the file size unknown"))
 	}
 
 	data, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		return http.StatusBadRequest, &fleetdbapi.ServerResponse{Error: err.Error()}
+		return failed(c, errors.Wrap(ErrInvalidRequest, err.Error()))
 	}
-	boms, err := parse.ParseXlsxFile(data)
+
+	upload, err := r.UploadXlsx(c, data)
 	if err != nil {
-		return http.StatusBadRequest, &fleetdbapi.ServerResponse{Error: err.Error()}
+		status, resp := failed(c, err)
+		if errors.Is(err, ErrMacCollision) {
+			resp.Records = upload.Result.MacCollisions
+		}
+
+		return status, resp
 	}
 
-	resp, err := r.repository.BillOfMaterialsBatchUpload(c.Request.Context(), boms)
-	if err != nil {
-		return http.StatusBadRequest, &fleetdbapi.ServerResponse{Error: err.Error()}
+	resp := upload.Response
+	if resp != nil && (upload.Result.Enrolled != nil || upload.Result.Credentials != nil || upload.Result.Reconciled != nil || len(upload.Result.MacCollisions) > 0) {
+		resp.Record = upload.Result
 	}
 
 	return http.StatusOK, resp
 }
 
+// Upload is the outcome of an xlsx file upload.
+type Upload struct {
+	// Metro is the metro the BOMs are tagged with.
+	Metro string
+	// Boms are the BOMs parsed from the file.
+	Boms []fleetdbapi.Bom
+	// Result describes the post-upload steps, the MAC collisions are set when the upload is rejected for them.
+	Result *UploadResult
+	// Response is the store response for the written BOMs.
+	Response *fleetdbapi.ServerResponse
+}
+
+// UploadXlsx parses the BOMs in the xlsx file and stores them tagged with the request metro,
+// enrolling them, pushing their BMC credentials and reconciling them with fleetdb when enabled.
+//
+// The upload is rejected with ErrMacCollision when MAC addresses are claimed by more than one serial number,
+// unless the request sets mac_collisions=flag and the addresses are claimed in different roles, the returned
+// upload lists the collisions either way.
+func (r *Routes) UploadXlsx(c *gin.Context, data []byte) (*Upload, error) {
+	metro, err := uploadMetro(c)
+	if err != nil {
+		return nil, err
+	}
+
+	boms, stats, err := parse.ParseXlsxFileContext(c.Request.Context(), data, r.mapping())
+	metrics.UploadRowsParsed(stats.Rows)
+
+	if err != nil {
+		metrics.UploadParseError(parse.ErrorCode(err))
+		return nil, err
+	}
+
+	if metro != "" {
+		for i := range boms {
+			boms[i].Metro = metro
+		}
+	}
+
+	if err := r.checkUploadOverwrites(c, boms); err != nil {
+		return nil, err
+	}
+
+	collisions, err := lookup.Collisions(c.Request.Context(), r.repository, boms, r.lookupWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &Upload{Metro: metro, Boms: boms, Result: &UploadResult{MacCollisions: collisions}}
+
+	if err := CheckCollisions(collisions, c.Query(macCollisionsQueryParam) == macCollisionsFlag); err != nil {
+		return upload, err
+	}
+
+	upload.Response, err = r.repository.BillOfMaterialsBatchUpload(c.Request.Context(), boms)
+	if err != nil {
+		return upload, err
+	}
+
+	metrics.UploadBomsWritten(len(boms))
+
+	// the boms are stored at this point, enroll and credential failures are reported in the upload result.
+	if r.enroller != nil {
+		upload.Result.Enrolled = r.enroller.Enroll(c.Request.Context(), boms)
+	}
+
+	// credentials are pushed after enrollment so newly enrolled servers get theirs.
+	if r.credentials != nil {
+		upload.Result.Credentials = r.credentials.Push(c.Request.Context(), boms)
+	}
+
+	// reconciled last so the report reflects the servers enrolled above.
+	if r.reconciler != nil {
+		upload.Result.Reconciled, err = r.reconciler.Reconcile(c.Request.Context(), boms)
+		if err != nil {
+			r.logger.WithContext(c.Request.Context()).WithError(err).Warn("upload reconciliation failed")
+		}
+	}
+
+	return upload, nil
+}
+
+// CheckCollisions returns ErrMacCollision for the MAC address collisions of an upload, flagged collisions are
+// accepted unless an address is claimed in the same role, since fleetdb stores each address once per role.
+func CheckCollisions(collisions []lookup.Collision, flag bool) error {
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	if !flag {
+		return errors.Wrapf(ErrMacCollision, "%d mac addresses claimed by more than one serial number", len(collisions))
+	}
+
+	if same := lookup.SameRole(collisions); len(same) > 0 {
+		return errors.Wrapf(ErrMacCollision, "%d mac addresses claimed by more than one serial number in the same role", len(same))
+	}
+
+	return nil
+}
+
 func (r *Routes) getBomInfoByAOCMacAddr(c *gin.Context) (int, *fleetdbapi.ServerResponse) {
-	_, resp, err := r.repository.GetBomInfoByAOCMacAddr(c.Request.Context(), c.Param("aoc_mac_address"))
+	bom, resp, err := r.repository.GetBomInfoByAOCMacAddr(c.Request.Context(), c.Param("aoc_mac_address"))
+	if err == nil && !MetroAllowed(c, bom) {
+		// BOMs in metros the request may not access are reported as not found.
+		err = errors.Wrap(store.ErrBomNotFound, "aoc mac address "+c.Param("aoc_mac_address"))
+	}
+
 	if err != nil {
-		return http.StatusBadRequest, &fleetdbapi.ServerResponse{Error: (errors.Wrap(ErrServerserviceQuery, err.Error())).Error()}
+		return failed(c, err)
 	}
+
 	return http.StatusOK, resp
 }
 
 func (r *Routes) getBomInfoByBMCMacAddr(c *gin.Context) (int, *fleetdbapi.ServerResponse) {
-	_, resp, err := r.repository.GetBomInfoByBMCMacAddr(c.Request.Context(), c.Param("bmc_mac_address"))
+	bom, resp, err := r.repository.GetBomInfoByBMCMacAddr(c.Request.Context(), c.Param("bmc_mac_address"))
+	if err == nil && !MetroAllowed(c, bom) {
+		err = errors.Wrap(store.ErrBomNotFound, "bmc mac address "+c.Param("bmc_mac_address"))
+	}
+
 	if err != nil {
-		return http.StatusInternalServerError, &fleetdbapi.ServerResponse{Error: (errors.Wrap(ErrServerserviceQuery, err.Error())).Error()}
+		return failed(c, err)
 	}
+
 	return http.StatusOK, resp
 }
+
+// getBomInfoByMacAddr looks up the MAC address in both the AOC and BMC indexes.
+func (r *Routes) getBomInfoByMacAddr(c *gin.Context) (int, *fleetdbapi.ServerResponse) {
+	result, err := lookup.Mac(c.Request.Context(), r.repository, c.Param("mac_address"))
+	if err == nil {
+		if result = filterMacResult(c, result); result == nil {
+			err = errors.Wrap(store.ErrBomNotFound, "mac address "+c.Param("mac_address"))
+		}
+	}
+
+	if err != nil {
+		return failed(c, err)
+	}
+
+	return http.StatusOK, &fleetdbapi.ServerResponse{Record: result}
+}
+
+// bulkLookup looks up the MAC addresses and serial numbers in the request body.
+func (r *Routes) bulkLookup(c *gin.Context) (int, *fleetdbapi.ServerResponse) {
+	req := &BulkLookupRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		return failed(c, errors.Wrap(ErrInvalidRequest, err.Error()))
+	}
+
+	result, err := lookup.Bulk(c.Request.Context(), r.repository, req, r.lookupWorkers)
+	if err != nil {
+		return failed(c, err)
+	}
+
+	return http.StatusOK, &fleetdbapi.ServerResponse{Record: filterBulkResult(c, req, result)}
+}
+
+// xlsxTemplate responds with an xlsx upload template built from the active column mapping.
+func (r *Routes) xlsxTemplate(c *gin.Context) {
+	start := time.Now()
+
+	data, err := parse.Template(r.mapping())
+	if err != nil {
+		abortWithError(c, start, err)
+		return
+	}
+
+	metrics.APICallEpilog(start, c.FullPath(), http.StatusOK)
+	c.Header("Content-Disposition", `attachment; filename="template.xlsx"`)
+	c.Data(http.StatusOK, xlsxContentType, data)
+}
+
+// exportDHCP responds with the DHCP reservations for the BMC MAC addresses of the enrolled servers of a metro,
+// in the format and with addresses from the metro pool named by the query parameters.
+//
+// The serial query parameter may be repeated to export only those BOMs, requests scoped to other metros are forbidden.
+func (r *Routes) exportDHCP(c *gin.Context) {
+	start := time.Now()
+
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatISC)))
+	if err != nil {
+		abortWithError(c, start, err)
+		return
+	}
+
+	metro := c.Query("metro")
+	if !MetroGranted(c, metro) {
+		abortWithError(c, start, errors.Wrap(ErrMetroScope, metro))
+		return
+	}
+
+	data, err := r.exporter.Export(c.Request.Context(), metro, c.QueryArray("serial"), format)
+	if err != nil {
+		abortWithError(c, start, err)
+		return
+	}
+
+	metrics.APICallEpilog(start, c.FullPath(), http.StatusOK)
+	c.Data(http.StatusOK, format.ContentType(), data)
+}
+
+func (r *Routes) reconcileBoms(c *gin.Context) (int, *fleetdbapi.ServerResponse) {
+	report, err := r.reconciler.ReconcileAll(c.Request.Context())
+	if err != nil {
+		return failed(c, err)
+	}
+
+	return http.StatusOK, &fleetdbapi.ServerResponse{Record: report}
+}
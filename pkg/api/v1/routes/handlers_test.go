@@ -2,21 +2,33 @@ package routes
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/reconcile"
 	"github.com/metal-toolbox/bomservice/internal/store"
 	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
 	"github.com/metal-toolbox/rivets/v2/events"
+	rivets "github.com/metal-toolbox/rivets/v2/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -51,17 +63,19 @@ func mockserver(t *testing.T, logger *logrus.Logger, repository store.Repository
 	return g, nil
 }
 
-func TestUploadXlsxFile(t *testing.T) {
-	// mock repository
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	repository := mockstore.NewMockRepository(ctrl)
-	server, err := mockserver(t, logrus.New(), repository, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
+// expectNoStoredMacs sets up the mock repository to find no stored bom for any MAC address.
+func expectNoStoredMacs(r *mockstore.MockRepository) {
+	r.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	r.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+}
 
+func TestUploadXlsxFile(t *testing.T) {
 	validBoms :=
 		[]fleetdbapi.Bom{
 			{
@@ -80,16 +94,37 @@ func TestUploadXlsxFile(t *testing.T) {
 			},
 		}
 
+	// storedMacs finds the uploaded BMC address FakeMac3 stored as the AOC address of another serial.
+	storedMacs := func(r *mockstore.MockRepository) {
+		r.EXPECT().
+			GetBomInfoByAOCMacAddr(gomock.Any(), "FakeMac3").
+			Return(&fleetdbapi.Bom{SerialNum: "other-serial"}, &fleetdbapi.ServerResponse{}, nil).
+			AnyTimes()
+		expectNoStoredMacs(r)
+	}
+
+	// storedBMCMacs finds FakeMac3 stored as the BMC address of another serial.
+	storedBMCMacs := func(r *mockstore.MockRepository) {
+		r.EXPECT().
+			GetBomInfoByBMCMacAddr(gomock.Any(), "FakeMac3").
+			Return(&fleetdbapi.Bom{SerialNum: "other-serial"}, &fleetdbapi.ServerResponse{}, nil).
+			AnyTimes()
+		expectNoStoredMacs(r)
+	}
+
 	testcases := []struct {
 		name           string
 		fileName       string
+		query          string
 		mockStore      func(r *mockstore.MockRepository)
 		assertResponse func(t *testing.T, r *httptest.ResponseRecorder)
 	}{
 		{
 			"valid file with 2 boms",
 			"test_valid_multiple_boms.xlsx",
+			"",
 			func(r *mockstore.MockRepository) {
+				expectNoStoredMacs(r)
 				r.EXPECT().
 					BillOfMaterialsBatchUpload(
 						gomock.Any(),
@@ -102,10 +137,67 @@ func TestUploadXlsxFile(t *testing.T) {
 				assert.Equal(t, http.StatusOK, r.Code)
 			},
 		},
+		{
+			"mac collision with stored bom rejected",
+			"test_valid_multiple_boms.xlsx",
+			"",
+			storedMacs,
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, r.Code)
+
+				var resp struct {
+					Records []lookup.Collision `json:"records"`
+				}
+
+				if err := json.Unmarshal(r.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+
+				expected := []lookup.Collision{
+					{MacAddress: "fakemac3", Serials: []string{"test-serial-2"}, ExistingSerials: []string{"other-serial"}},
+				}
+				assert.Equal(t, expected, resp.Records)
+			},
+		},
+		{
+			"mac collision with stored bom flagged",
+			"test_valid_multiple_boms.xlsx",
+			"?mac_collisions=flag",
+			func(r *mockstore.MockRepository) {
+				storedMacs(r)
+				r.EXPECT().
+					BillOfMaterialsBatchUpload(gomock.Any(), gomock.Any()).
+					Return(&fleetdbapi.ServerResponse{}, nil).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, r.Code)
+				assert.Contains(t, r.Body.String(), `"existing_serials":["other-serial"]`)
+			},
+		},
+		{
+			"mac collision in the same role flagged rejected",
+			"test_valid_multiple_boms.xlsx",
+			"?mac_collisions=flag",
+			storedBMCMacs,
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, r.Code)
+				assert.Contains(t, r.Body.String(), `"same_role":true`)
+			},
+		},
 	}
 
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repository := mockstore.NewMockRepository(ctrl)
+			server, err := mockserver(t, logrus.New(), repository, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
 			if tc.mockStore != nil {
 				tc.mockStore(repository)
 			}
@@ -116,7 +208,7 @@ func TestUploadXlsxFile(t *testing.T) {
 				t.Fatalf("os.Open(%v) failed to open file %v\n", filePath, err)
 			}
 			reader := bufio.NewReader(file)
-			request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/api/v1/bomservice/upload-xlsx-file", reader)
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/api/v1/bomservice/upload-xlsx-file"+tc.query, reader)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -129,6 +221,21 @@ func TestUploadXlsxFile(t *testing.T) {
 	}
 }
 
+// assertErrorResponse checks the response status and the error code in the response body.
+func assertErrorResponse(t *testing.T, r *httptest.ResponseRecorder, status int, code ErrorCode) {
+	t.Helper()
+
+	assert.Equal(t, status, r.Code)
+
+	var resp errorResponse
+	if err := json.Unmarshal(r.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, code, resp.Code)
+	assert.NotEmpty(t, resp.Error)
+}
+
 func TestGetBomInfoByAocMacAddr(t *testing.T) {
 	// mock repository
 	ctrl := gomock.NewController(t)
@@ -184,6 +291,32 @@ func TestGetBomInfoByAocMacAddr(t *testing.T) {
 				}
 			},
 		},
+		{
+			"aoc mac address not stored",
+			"unknown",
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Eq("unknown")).
+					Return(nil, nil, store.ErrBomNotFound).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assertErrorResponse(t, r, http.StatusNotFound, CodeNotFound)
+			},
+		},
+		{
+			"store backend unavailable",
+			"test-serial-2",
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Eq("test-serial-2")).
+					Return(nil, nil, store.ErrBackendUnavailable).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assertErrorResponse(t, r, http.StatusServiceUnavailable, CodeBackendUnavailable)
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -260,6 +393,32 @@ func TestGetBomInfoByBmcMacAddr(t *testing.T) {
 				}
 			},
 		},
+		{
+			"bmc mac address not stored",
+			"unknown",
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Eq("unknown")).
+					Return(nil, nil, store.ErrBomNotFound).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assertErrorResponse(t, r, http.StatusNotFound, CodeNotFound)
+			},
+		},
+		{
+			"store backend unavailable",
+			"test-serial-2",
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Eq("test-serial-2")).
+					Return(nil, nil, store.ErrBackendUnavailable).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assertErrorResponse(t, r, http.StatusServiceUnavailable, CodeBackendUnavailable)
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -280,3 +439,576 @@ func TestGetBomInfoByBmcMacAddr(t *testing.T) {
 		})
 	}
 }
+
+func TestXlsxTemplate(t *testing.T) {
+	// mock repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	server, err := mockserver(t, logrus.New(), repository, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/api/v1/bomservice/template.xlsx", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, xlsxContentType, recorder.Header().Get("Content-Type"))
+
+	boms, err := parse.ParseXlsxFile(recorder.Body.Bytes())
+	assert.NoError(t, err, "template failed to parse")
+	assert.Len(t, boms, 1)
+}
+
+func TestUploadXlsxFileAnnotated(t *testing.T) {
+	// mock repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	server, err := mockserver(t, logrus.New(), repository, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name           string
+		fileName       string
+		mockStore      func(r *mockstore.MockRepository)
+		assertResponse func(t *testing.T, r *httptest.ResponseRecorder)
+	}{
+		{
+			"invalid file returned annotated",
+			"test_empty_bmcMacAddress.xlsx",
+			nil,
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, r.Code)
+				assert.Equal(t, xlsxContentType, r.Header().Get("Content-Type"))
+
+				cellErrs, err := parse.ValidateXlsxFileWithMapping(r.Body.Bytes(), parse.DefaultMapping())
+				assert.NoError(t, err, "annotated file failed to open")
+				assert.Len(t, cellErrs, 2)
+			},
+		},
+		{
+			"valid file is uploaded",
+			"test_valid_one_bom.xlsx",
+			func(r *mockstore.MockRepository) {
+				expectNoStoredMacs(r)
+				r.EXPECT().
+					BillOfMaterialsBatchUpload(gomock.Any(), gomock.Any()).
+					Return(&fleetdbapi.ServerResponse{}, nil).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, r.Code)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockStore != nil {
+				tc.mockStore(repository)
+			}
+
+			filePath := fmt.Sprintf("%v/%v", testDatapath, tc.fileName)
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("os.ReadFile(%v) failed to read file %v\n", filePath, err)
+			}
+
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/api/v1/bomservice/upload-xlsx-file?annotate=true", bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			tc.assertResponse(t, recorder)
+		})
+	}
+}
+
+// emptyFleetDB has no servers enrolled.
+type emptyFleetDB struct{}
+
+func (emptyFleetDB) List(context.Context, *fleetdbapi.ServerListParams) ([]fleetdbapi.Server, *fleetdbapi.ServerResponse, error) {
+	return nil, &fleetdbapi.ServerResponse{}, nil
+}
+
+func (emptyFleetDB) GetServerInventory(context.Context, uuid.UUID, bool) (*rivets.Server, *fleetdbapi.ServerResponse, error) {
+	return nil, nil, errors.New("unexpected inventory lookup")
+}
+
+// unknownServerFleetDB has a single server enrolled without an inventory or BOM attributes.
+type unknownServerFleetDB struct{}
+
+func (unknownServerFleetDB) List(_ context.Context, params *fleetdbapi.ServerListParams) ([]fleetdbapi.Server, *fleetdbapi.ServerResponse, error) {
+	if params.PaginationParams != nil && params.PaginationParams.Page > 1 {
+		return nil, &fleetdbapi.ServerResponse{}, nil
+	}
+
+	return []fleetdbapi.Server{{UUID: uuid.New()}}, &fleetdbapi.ServerResponse{}, nil
+}
+
+func (unknownServerFleetDB) GetServerInventory(context.Context, uuid.UUID, bool) (*rivets.Server, *fleetdbapi.ServerResponse, error) {
+	return nil, nil, fleetdbapi.ServerError{StatusCode: http.StatusNotFound}
+}
+
+func TestReconcileBoms(t *testing.T) {
+	// mock repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := NewRoutes(
+		WithLogger(logrus.New()),
+		WithStore(repository),
+		WithReconciler(reconcile.New(unknownServerFleetDB{}, repository, logrus.New())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Router.Routes(g.Group("/api/v1"))
+
+	request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/api/v1/bomservice/reconcile", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	g.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Record reconcile.Report `json:"record"`
+	}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, resp.Record.Servers)
+	assert.Equal(t, 0, resp.Record.Boms)
+	assert.Equal(t, 1, resp.Record.Count(reconcile.FindingExtra))
+}
+
+func TestUploadReconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	expectNoStoredMacs(repository)
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), gomock.Any()).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := NewRoutes(
+		WithLogger(logrus.New()),
+		WithStore(repository),
+		WithReconciler(reconcile.New(emptyFleetDB{}, repository, logrus.New())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Router.Routes(g.Group("/api/v1"))
+
+	data, err := os.ReadFile(fmt.Sprintf("%v/%v", testDatapath, "test_valid_multiple_boms.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/api/v1/bomservice/upload-xlsx-file", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	g.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Record UploadResult `json:"record"`
+	}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	// no servers are enrolled, so every uploaded bom is reported missing.
+	assert.NotNil(t, resp.Record.Reconciled)
+	assert.Equal(t, 2, resp.Record.Reconciled.Boms)
+	assert.Equal(t, 2, resp.Record.Reconciled.Count(reconcile.FindingMissing))
+}
+
+// exportSource serves each BOM as an enrolled server without recorded allocations.
+type exportSource []fleetdbapi.Bom
+
+func (s exportSource) Hosts(context.Context) ([]export.Host, error) {
+	hosts := make([]export.Host, 0, len(s))
+	for i := range s {
+		hosts = append(hosts, export.Host{ServerUUID: uuid.New(), Bom: s[i]})
+	}
+
+	return hosts, nil
+}
+
+func (exportSource) Record(context.Context, *export.Host) error {
+	return nil
+}
+
+func TestExportDHCP(t *testing.T) {
+	pool, err := export.NewPool("dc13", "10.0.0.10", "10.0.0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	am6Pool, err := export.NewPool("am6", "10.1.0.10", "10.1.0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boms := []fleetdbapi.Bom{
+		{SerialNum: "fakeSerial1", BmcMacAddress: "aa:aa:aa:aa:aa:01", Metro: "am6"},
+		{SerialNum: "fakeSerial2", BmcMacAddress: "aa:aa:aa:aa:aa:02", Metro: "dc13"},
+		{SerialNum: "fakeSerial3", BmcMacAddress: "aa:aa:aa:aa:aa:03", Metro: "am6"},
+	}
+
+	var testCases = []struct {
+		testName     string
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"dnsmasq reservation",
+			"?metro=dc13&format=dnsmasq&serial=fakeSerial2",
+			http.StatusOK,
+			"dhcp-host=aa:aa:aa:aa:aa:02,10.0.0.10,bmc-fakeserial2\n",
+		},
+		{
+			"metro boms only",
+			"?metro=dc13&format=dnsmasq",
+			http.StatusOK,
+			"dhcp-host=aa:aa:aa:aa:aa:02,10.0.0.10,bmc-fakeserial2\n",
+		},
+		{
+			// addresses are allocated over every bom of the metro, not just the selected serials.
+			"pool exhausted",
+			"?metro=am6&format=dnsmasq&serial=fakeSerial1",
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"unknown format",
+			"?metro=dc13&format=bind",
+			http.StatusBadRequest,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repository := mockstore.NewMockRepository(ctrl)
+
+			gin.SetMode(gin.ReleaseMode)
+			g := gin.New()
+
+			exporter := export.New(
+				map[string]*export.Pool{"dc13": pool, "am6": am6Pool},
+				"",
+				export.WithSource(exportSource(boms)),
+			)
+
+			v1Router, err := NewRoutes(
+				WithLogger(logrus.New()),
+				WithStore(repository),
+				WithExporter(exporter),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v1Router.Routes(g.Group("/api/v1"))
+
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/api/v1/bomservice/export/dhcp"+tc.query, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			g.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetBomInfoByMacAddr(t *testing.T) {
+	bom := &fleetdbapi.Bom{SerialNum: "fakeSerial", AocMacAddress: "fakeMac", BmcMacAddress: "fakeBmc"}
+
+	testcases := []struct {
+		name           string
+		mac            string
+		mockStore      func(r *mockstore.MockRepository)
+		assertResponse func(t *testing.T, r *httptest.ResponseRecorder)
+	}{
+		{
+			"bmc mac address",
+			"fakeBmc",
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByBMCMacAddr(gomock.Any(), "fakeBmc").
+					Return(bom, &fleetdbapi.ServerResponse{}, nil).
+					Times(1)
+				expectNoStoredMacs(r)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, r.Code)
+
+				var resp struct {
+					Record lookup.Result `json:"record"`
+				}
+
+				if err := json.Unmarshal(r.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+
+				assert.Len(t, resp.Record.Matches, 1)
+				assert.Equal(t, lookup.RoleBMC, resp.Record.Matches[0].Role)
+				assert.False(t, resp.Record.Collision)
+			},
+		},
+		{
+			"mac address claimed by two serials",
+			"fakeMac",
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByAOCMacAddr(gomock.Any(), "fakeMac").
+					Return(bom, &fleetdbapi.ServerResponse{}, nil).
+					Times(1)
+				r.EXPECT().
+					GetBomInfoByBMCMacAddr(gomock.Any(), "fakeMac").
+					Return(&fleetdbapi.Bom{SerialNum: "otherSerial"}, &fleetdbapi.ServerResponse{}, nil).
+					Times(1)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, r.Code)
+				assert.Contains(t, r.Body.String(), `"collision":true`)
+			},
+		},
+		{
+			"unknown mac address",
+			"unknown",
+			expectNoStoredMacs,
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, r.Code)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repository := mockstore.NewMockRepository(ctrl)
+			tc.mockStore(repository)
+
+			server, err := mockserver(t, logrus.New(), repository, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/api/v1/bomservice/mac/"+tc.mac, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			tc.assertResponse(t, recorder)
+		})
+	}
+}
+
+func TestBulkLookup(t *testing.T) {
+	testcases := []struct {
+		name           string
+		body           string
+		mockStore      func(r *mockstore.MockRepository)
+		assertResponse func(t *testing.T, r *httptest.ResponseRecorder)
+	}{
+		{
+			"found and not found mac addresses",
+			`{"mac_addresses": ["fakeBmc", "unknown"]}`,
+			func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					GetBomInfoByBMCMacAddr(gomock.Any(), "fakeBmc").
+					Return(&fleetdbapi.Bom{SerialNum: "fakeSerial"}, &fleetdbapi.ServerResponse{}, nil).
+					Times(1)
+				expectNoStoredMacs(r)
+			},
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, r.Code)
+
+				var resp struct {
+					Record BulkLookupResult `json:"record"`
+				}
+
+				if err := json.Unmarshal(r.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+
+				assert.Len(t, resp.Record.MacAddresses, 1)
+				assert.Equal(t, "fakeSerial", resp.Record.MacAddresses[0].Matches[0].Bom.SerialNum)
+				assert.Equal(t, []string{"unknown"}, resp.Record.NotFound.MacAddresses)
+			},
+		},
+		{
+			"empty request",
+			`{}`,
+			nil,
+			func(t *testing.T, r *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, r.Code)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repository := mockstore.NewMockRepository(ctrl)
+			if tc.mockStore != nil {
+				tc.mockStore(repository)
+			}
+
+			server, err := mockserver(t, logrus.New(), repository, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/api/v1/bomservice/lookup", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			tc.assertResponse(t, recorder)
+		})
+	}
+}
+
+func TestUploadMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics.Use(metrics.New(registry))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	expectNoStoredMacs(repository)
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), gomock.Any()).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	server, err := mockserver(t, logrus.New(), repository, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fileName := range []string{"test_valid_multiple_boms.xlsx", "test_empty_serial.xlsx"} {
+		data, err := os.ReadFile(fmt.Sprintf("%v/%v", testDatapath, fileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/api/v1/bomservice/upload-xlsx-file", bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		server.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/api/v1/bomservice/mac/aa:bb:cc", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.ServeHTTP(httptest.NewRecorder(), request)
+
+	expected := `
+# HELP bomservice_upload_boms_written_total boms written to the store by uploads
+# TYPE bomservice_upload_boms_written_total counter
+bomservice_upload_boms_written_total 2
+# HELP bomservice_upload_parse_errors_total uploaded bom file parse errors by error code
+# TYPE bomservice_upload_parse_errors_total counter
+bomservice_upload_parse_errors_total{code="empty_serial_num"} 1
+`
+
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"bomservice_upload_boms_written_total", "bomservice_upload_parse_errors_total"); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints := map[string]bool{}
+
+	for _, family := range families {
+		if family.GetName() != "bomservice_api_latency_seconds" {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "endpoint" {
+					endpoints[label.GetValue()] = true
+				}
+			}
+		}
+	}
+
+	// endpoints are labeled by route template, not the request path.
+	assert.Equal(t, map[string]bool{
+		"/api/v1/bomservice/upload-xlsx-file": true,
+		"/api/v1/bomservice/mac/:mac_address": true,
+	}, endpoints)
+}
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// contextKeyMetros holds the metros a request restricted by metro scopes may access.
+	contextKeyMetros = "bomservice.metros"
+
+	// metroQueryParam tags the uploaded BOMs with the metro.
+	metroQueryParam = "metro"
+)
+
+// composeMetroAuthHandler verifies the request principal has one of the scopes,
+// or a metro scope for one of the actions, e.g. read:bom:metro:dc13,
+// in which case the request is restricted to the BOMs tagged with the granted metros.
+func (r *Routes) composeMetroAuthHandler(scopes []string, actions ...string) gin.HandlerFunc {
+	required := r.composeAuthHandler(scopes)
+	if r.authMW == nil && len(r.authenticators) == 0 {
+		return required
+	}
+
+	return func(c *gin.Context) {
+		roles := c.GetStringSlice(auth.ContextKeyRoles)
+
+		if !auth.HasAnyScope(roles, scopes) {
+			if metros := auth.ScopedMetros(roles, actions...); len(metros) > 0 {
+				c.Set(contextKeyMetros, metros)
+				return
+			}
+		}
+
+		required(c)
+	}
+}
+
+// scopedMetros returns the metros the request is restricted to, ok is false for unrestricted requests.
+func scopedMetros(c *gin.Context) (metros []string, ok bool) {
+	v, ok := c.Get(contextKeyMetros)
+	if !ok {
+		return nil, false
+	}
+
+	metros, ok = v.([]string)
+
+	return metros, ok
+}
+
+// MetroAllowed returns true when the request may access the BOM.
+func MetroAllowed(c *gin.Context, bom *fleetdbapi.Bom) bool {
+	metros, restricted := scopedMetros(c)
+
+	return !restricted || (bom != nil && slices.Contains(metros, bom.Metro))
+}
+
+// MetroGranted returns true when the request may access the records of the metro.
+func MetroGranted(c *gin.Context, metro string) bool {
+	metros, restricted := scopedMetros(c)
+
+	return !restricted || slices.Contains(metros, metro)
+}
+
+// FilterBoms returns the BOMs the request may access.
+func FilterBoms(c *gin.Context, boms []fleetdbapi.Bom) []fleetdbapi.Bom {
+	if _, restricted := scopedMetros(c); !restricted {
+		return boms
+	}
+
+	allowed := make([]fleetdbapi.Bom, 0, len(boms))
+
+	for i := range boms {
+		if MetroAllowed(c, &boms[i]) {
+			allowed = append(allowed, boms[i])
+		}
+	}
+
+	return allowed
+}
+
+// filterMacResult removes the matches the request may not access from the lookup result,
+// returning nil when none remain.
+func filterMacResult(c *gin.Context, result *lookup.Result) *lookup.Result {
+	if _, restricted := scopedMetros(c); !restricted {
+		return result
+	}
+
+	filtered := &lookup.Result{MacAddress: result.MacAddress, Matches: []lookup.Match{}}
+	serials := map[string]struct{}{}
+
+	for _, m := range result.Matches {
+		if MetroAllowed(c, m.Bom) {
+			filtered.Matches = append(filtered.Matches, m)
+			serials[m.Bom.SerialNum] = struct{}{}
+		}
+	}
+
+	if len(filtered.Matches) == 0 {
+		return nil
+	}
+
+	// collisions with BOMs in other metros are not disclosed.
+	filtered.Collision = len(serials) > 1
+
+	return filtered
+}
+
+// filterBulkResult moves the records the request may not access to the not found keys.
+func filterBulkResult(c *gin.Context, req *BulkLookupRequest, result *BulkLookupResult) *BulkLookupResult {
+	if _, restricted := scopedMetros(c); !restricted {
+		return result
+	}
+
+	filtered := &BulkLookupResult{
+		MacAddresses: []*lookup.Result{},
+		SerialNums:   []fleetdbapi.Bom{},
+		NotFound:     BulkLookupRequest{MacAddresses: []string{}, SerialNums: []string{}},
+	}
+
+	found := map[string]*lookup.Result{}
+	for _, r := range result.MacAddresses {
+		found[r.MacAddress] = r
+	}
+
+	// keys are walked in request order so the not found keys keep it.
+	for _, mac := range req.MacAddresses {
+		r, ok := found[mac]
+		if ok {
+			r = filterMacResult(c, r)
+		}
+
+		if r == nil {
+			filtered.NotFound.MacAddresses = append(filtered.NotFound.MacAddresses, mac)
+			continue
+		}
+
+		filtered.MacAddresses = append(filtered.MacAddresses, r)
+	}
+
+	bySerial := map[string]fleetdbapi.Bom{}
+	for _, bom := range FilterBoms(c, result.SerialNums) {
+		bySerial[bom.SerialNum] = bom
+	}
+
+	for _, serial := range req.SerialNums {
+		bom, ok := bySerial[serial]
+		if !ok {
+			filtered.NotFound.SerialNums = append(filtered.NotFound.SerialNums, serial)
+			continue
+		}
+
+		filtered.SerialNums = append(filtered.SerialNums, bom)
+	}
+
+	return filtered
+}
+
+// uploadMetro returns the metro to tag the uploaded BOMs with from the metro query parameter.
+//
+// Requests restricted by metro scopes upload to one of their metros, which is the default when a single one is granted.
+func uploadMetro(c *gin.Context) (string, error) {
+	metro := c.Query(metroQueryParam)
+
+	metros, restricted := scopedMetros(c)
+	if !restricted {
+		return metro, nil
+	}
+
+	switch {
+	case metro == "" && len(metros) == 1:
+		return metros[0], nil
+	case metro == "":
+		return "", errors.Wrapf(ErrMetroRequired, "granted metros: %s", strings.Join(metros, ","))
+	case !slices.Contains(metros, metro):
+		return "", errors.Wrapf(ErrMetroScope, "metro %s, granted metros: %s", metro, strings.Join(metros, ","))
+	}
+
+	return metro, nil
+}
+
+// checkUploadOverwrites returns ErrMetroScope when the request is restricted by metro scopes
+// and an uploaded serial number is stored tagged with a metro it may not access.
+func (r *Routes) checkUploadOverwrites(c *gin.Context, boms []fleetdbapi.Bom) error {
+	if _, restricted := scopedMetros(c); !restricted {
+		return nil
+	}
+
+	stored, err := lookup.Stored(c.Request.Context(), r.repository, boms, r.lookupWorkers)
+	if err != nil {
+		return err
+	}
+
+	var denied []string
+
+	for i := range stored {
+		if !MetroAllowed(c, &stored[i]) {
+			denied = append(denied, stored[i].SerialNum)
+		}
+	}
+
+	if len(denied) > 0 {
+		slices.Sort(denied)
+		return errors.Wrapf(ErrMetroScope, "serial numbers stored in another metro: %s", strings.Join(denied, ","))
+	}
+
+	return nil
+}
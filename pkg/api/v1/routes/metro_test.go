@@ -0,0 +1,217 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetroScopes(t *testing.T) {
+	dc13 := fleetdbapi.Bom{SerialNum: "stored-serial-1", AocMacAddress: "aa:aa:aa:aa:aa:01", Metro: "dc13"}
+	am6 := fleetdbapi.Bom{SerialNum: "stored-serial-2", AocMacAddress: "aa:aa:aa:aa:aa:02", Metro: "am6"}
+
+	clients := headerAuthenticator{
+		"admin":      {"read", "write"},
+		"dc13-team":  {auth.MetroScope("read", "dc13"), auth.MetroScope("write", "dc13")},
+		"multi-team": {auth.MetroScope("write", "dc13"), auth.MetroScope("write", "am6")},
+		"am6-reader": {auth.MetroScope("read", "am6")},
+	}
+
+	// request serves the request from the client against a repository storing the boms.
+	request := func(t *testing.T, client, method, path string, body []byte, mockStore func(r *mockstore.MockRepository)) *httptest.ResponseRecorder {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repository := mockstore.NewMockRepository(ctrl)
+
+		// expectations set first take precedence over the stored boms.
+		if mockStore != nil {
+			mockStore(repository)
+		}
+
+		repository.EXPECT().
+			GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, mac string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+				for _, bom := range []fleetdbapi.Bom{dc13, am6} {
+					if bom.AocMacAddress == mac {
+						return &bom, &fleetdbapi.ServerResponse{Record: &bom}, nil
+					}
+				}
+
+				return nil, nil, store.ErrBomNotFound
+			}).
+			AnyTimes()
+		repository.EXPECT().
+			GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+			Return(nil, nil, store.ErrBomNotFound).
+			AnyTimes()
+		repository.EXPECT().
+			GetBomInfoBySerial(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+				for _, bom := range []fleetdbapi.Bom{dc13, am6} {
+					if bom.SerialNum == serial {
+						return &bom, &fleetdbapi.ServerResponse{Record: &bom}, nil
+					}
+				}
+
+				return nil, nil, store.ErrBomNotFound
+			}).
+			AnyTimes()
+		repository.EXPECT().
+			ListBoms(gomock.Any(), gomock.Any()).
+			Return([]fleetdbapi.Bom{dc13, am6}, &fleetdbapi.ServerResponse{}, nil).
+			AnyTimes()
+
+		gin.SetMode(gin.ReleaseMode)
+		g := gin.New()
+
+		v1Router, err := NewRoutes(WithLogger(logrus.New()), WithStore(repository), WithAuthenticators(clients))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v1Router.Routes(g.Group("/api/v1"))
+
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("X-Test-Client", client)
+
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("lookups", func(t *testing.T) {
+		testcases := []struct {
+			name   string
+			client string
+			path   string
+			want   int
+		}{
+			{"unscoped read", "admin", "/api/v1/bomservice/aoc-mac-address/aa:aa:aa:aa:aa:02", http.StatusOK},
+			{"metro granted", "dc13-team", "/api/v1/bomservice/aoc-mac-address/aa:aa:aa:aa:aa:01", http.StatusOK},
+			// the response matches the one for a mac address not stored.
+			{"metro not granted", "dc13-team", "/api/v1/bomservice/aoc-mac-address/aa:aa:aa:aa:aa:02", http.StatusNotFound},
+			{"mac metro granted", "am6-reader", "/api/v1/bomservice/mac/aa:aa:aa:aa:aa:02", http.StatusOK},
+			{"mac metro not granted", "am6-reader", "/api/v1/bomservice/mac/aa:aa:aa:aa:aa:01", http.StatusNotFound},
+			{"write metro scope does not grant reads", "multi-team", "/api/v1/bomservice/mac/aa:aa:aa:aa:aa:01", http.StatusForbidden},
+			{"unscoped read only route", "dc13-team", "/api/v1/bomservice/template.xlsx", http.StatusForbidden},
+		}
+
+		for _, tc := range testcases {
+			t.Run(tc.name, func(t *testing.T) {
+				w := request(t, tc.client, http.MethodGet, tc.path, nil, nil)
+				assert.Equal(t, tc.want, w.Code)
+			})
+		}
+	})
+
+	t.Run("bulk lookup", func(t *testing.T) {
+		body, _ := json.Marshal(&BulkLookupRequest{
+			MacAddresses: []string{"aa:aa:aa:aa:aa:02", "aa:aa:aa:aa:aa:01"},
+			SerialNums:   []string{"stored-serial-2", "stored-serial-1"},
+		})
+
+		w := request(t, "dc13-team", http.MethodPost, "/api/v1/bomservice/lookup", body, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Record BulkLookupResult `json:"record"`
+		}
+
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, resp.Record.MacAddresses, 1)
+		assert.Equal(t, "aa:aa:aa:aa:aa:01", resp.Record.MacAddresses[0].MacAddress)
+		assert.Equal(t, []fleetdbapi.Bom{dc13}, resp.Record.SerialNums)
+		assert.Equal(t, BulkLookupRequest{MacAddresses: []string{"aa:aa:aa:aa:aa:02"}, SerialNums: []string{"stored-serial-2"}}, resp.Record.NotFound)
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		data, err := os.ReadFile(testDatapath + "/test_valid_multiple_boms.xlsx")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// uploaded expects the uploaded boms tagged with the metro.
+		uploaded := func(metro string) func(r *mockstore.MockRepository) {
+			return func(r *mockstore.MockRepository) {
+				r.EXPECT().
+					BillOfMaterialsBatchUpload(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, boms []fleetdbapi.Bom) (*fleetdbapi.ServerResponse, error) {
+						for _, bom := range boms {
+							assert.Equal(t, metro, bom.Metro)
+						}
+
+						return &fleetdbapi.ServerResponse{}, nil
+					}).
+					Times(1)
+			}
+		}
+
+		testcases := []struct {
+			name      string
+			client    string
+			query     string
+			mockStore func(r *mockstore.MockRepository)
+			want      int
+			wantErr   string
+		}{
+			{"unscoped write tags the metro", "admin", "?metro=sv15", uploaded("sv15"), http.StatusOK, ""},
+			{"unscoped write without metro", "admin", "", uploaded(""), http.StatusOK, ""},
+			// the uploaded serial numbers are not stored.
+			{"single metro granted is the default", "dc13-team", "", uploaded("dc13"), http.StatusOK, ""},
+			{"metro granted", "multi-team", "?metro=am6", uploaded("am6"), http.StatusOK, ""},
+			{"metro required with several granted", "multi-team", "", nil, http.StatusBadRequest, ErrMetroRequired.Error()},
+			{"metro not granted", "dc13-team", "?metro=am6", nil, http.StatusForbidden, ErrMetroScope.Error()},
+			{"read metro scope does not grant writes", "am6-reader", "?metro=am6", nil, http.StatusForbidden, ""},
+		}
+
+		for _, tc := range testcases {
+			t.Run(tc.name, func(t *testing.T) {
+				w := request(t, tc.client, http.MethodPost, "/api/v1/bomservice/upload-xlsx-file"+tc.query, data, tc.mockStore)
+				assert.Equal(t, tc.want, w.Code)
+
+				if tc.wantErr != "" {
+					assert.Contains(t, w.Body.String(), tc.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("upload overwriting another metro", func(t *testing.T) {
+		data, err := os.ReadFile(testDatapath + "/test_valid_one_bom.xlsx")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored := func(r *mockstore.MockRepository) {
+			bom := fleetdbapi.Bom{SerialNum: "test-serial-1", Metro: "am6"}
+			r.EXPECT().
+				GetBomInfoBySerial(gomock.Any(), "test-serial-1").
+				Return(&bom, &fleetdbapi.ServerResponse{Record: &bom}, nil).
+				Times(1)
+		}
+
+		w := request(t, "dc13-team", http.MethodPost, "/api/v1/bomservice/upload-xlsx-file", data, stored)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.True(t, strings.Contains(w.Body.String(), "test-serial-1"), w.Body.String())
+	})
+}
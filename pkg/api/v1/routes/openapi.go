@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// OpenAPIPath is the path of the OpenAPI document, relative to PathPrefix.
+const OpenAPIPath = "/openapi.json"
+
+var (
+	ErrOpenAPI = errors.New("invalid OpenAPI document")
+
+	//go:embed openapi.json
+	openAPIDocument []byte
+
+	// loadOpenAPI parses the document once, the routes of each API version share it.
+	loadOpenAPI = sync.OnceValues(func() (*openapi3.T, error) {
+		doc, err := openapi3.NewLoader().LoadFromData(openAPIDocument)
+		if err != nil {
+			return nil, errors.Wrap(ErrOpenAPI, err.Error())
+		}
+
+		if err := doc.Validate(context.Background()); err != nil {
+			return nil, errors.Wrap(ErrOpenAPI, err.Error())
+		}
+
+		return doc, nil
+	})
+)
+
+// OpenAPIDocument returns the OpenAPI 3 document describing the API.
+func OpenAPIDocument() []byte {
+	return openAPIDocument
+}
+
+// LoadOpenAPI returns the parsed OpenAPI document describing the API.
+func LoadOpenAPI() (*openapi3.T, error) {
+	return loadOpenAPI()
+}
+
+// OpenAPIPathTemplate returns the OpenAPI path template for a gin route path,
+// :name path parameters become {name}.
+func OpenAPIPathTemplate(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// openAPI responds with the OpenAPI document.
+func (r *Routes) openAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", openAPIDocument)
+}
+
+// validateRequest rejects requests with parameters or JSON bodies not matching the operation
+// in the OpenAPI document with 400 Bad Request, operations are found by the gin route path
+// relative to basePath. Credentials are checked by the auth middleware and not validated here.
+func (r *Routes) validateRequest(basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := OpenAPIPathTemplate(strings.TrimPrefix(c.FullPath(), basePath))
+
+		pathItem := r.openapi.Paths.Value(path)
+		if pathItem == nil {
+			return
+		}
+
+		operation := pathItem.GetOperation(c.Request.Method)
+		if operation == nil {
+			return
+		}
+
+		// handlers bind JSON bodies regardless of the content type, untyped bodies are validated as JSON.
+		if jsonRequestBody(operation) && c.ContentType() == "" {
+			c.Request.Header.Set("Content-Type", "application/json")
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Route: &routers.Route{
+				Spec:      r.openapi,
+				Path:      path,
+				PathItem:  pathItem,
+				Method:    c.Request.Method,
+				Operation: operation,
+			},
+			Options: &openapi3filter.Options{
+				// uploaded files are validated by the parser.
+				ExcludeRequestBody: !jsonRequestBody(operation),
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			abortWithError(c, time.Now(), errors.Wrap(ErrInvalidRequest, validationMessage(err)))
+		}
+	}
+}
+
+// jsonRequestBody returns true when the operation takes a JSON request body.
+func jsonRequestBody(operation *openapi3.Operation) bool {
+	return operation.RequestBody != nil && operation.RequestBody.Value.Content.Get("application/json") != nil
+}
+
+// validationMessage returns the request validation error without the schema details.
+func validationMessage(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return err.Error()
+	}
+
+	reason := reqErr.Reason
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		reason = schemaErr.Reason
+	} else if reqErr.Err != nil {
+		reason = reqErr.Err.Error()
+	}
+
+	switch {
+	case reqErr.Parameter != nil:
+		return "parameter " + reqErr.Parameter.Name + " in " + reqErr.Parameter.In + ": " + reason
+	case reqErr.RequestBody != nil:
+		return "request body: " + reason
+	default:
+		return reason
+	}
+}
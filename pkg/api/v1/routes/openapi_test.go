@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/reconcile"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	doc, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	// all optional routes are registered.
+	v1Router, err := NewRoutes(
+		WithLogger(logrus.New()),
+		WithStore(repository),
+		WithExporter(export.New(nil, "")),
+		WithReconciler(reconcile.New(emptyFleetDB{}, repository, logrus.New())),
+		WithAuthenticators(headerAuthenticator{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Router.Routes(g.Group(PathPrefix))
+
+	registered := map[string]bool{}
+
+	for _, route := range g.Routes() {
+		path := OpenAPIPathTemplate(strings.TrimPrefix(route.Path, PathPrefix))
+		registered[route.Method+" "+path] = true
+
+		pathItem := doc.Paths.Value(path)
+		if !assert.NotNil(t, pathItem, "route %s %s is not in the OpenAPI document", route.Method, route.Path) {
+			continue
+		}
+
+		assert.NotNil(t, pathItem.GetOperation(route.Method), "route %s %s is not in the OpenAPI document", route.Method, route.Path)
+	}
+
+	for path, pathItem := range doc.Paths.Map() {
+		for method := range pathItem.Operations() {
+			assert.True(t, registered[method+" "+path], "operation %s %s is not a registered route", method, path)
+		}
+	}
+
+	// error responses carry the codes in the document.
+	codes := doc.Components.Schemas["ErrorResponse"].Value.Properties["code"].Value.Enum
+	for _, kind := range errorKinds {
+		assert.Contains(t, codes, string(kind.code))
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := NewRoutes(
+		WithLogger(logrus.New()),
+		WithStore(repository),
+		WithExporter(export.New(nil, "")),
+		WithAuthenticators(headerAuthenticator{"reader": {"read"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Router.Routes(g.Group(PathPrefix))
+
+	testcases := []struct {
+		name   string
+		client string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"document served without credentials", "", http.MethodGet, "/api/v1/openapi.json", "", http.StatusOK},
+		{"credentials checked first", "", http.MethodGet, "/api/v1/bomservice/export/dhcp?metro=dc13&format=bind", "", http.StatusUnauthorized},
+		{"query parameter not in enum", "reader", http.MethodGet, "/api/v1/bomservice/export/dhcp?metro=dc13&format=bind", "", http.StatusBadRequest},
+		{"required query parameter", "reader", http.MethodGet, "/api/v1/bomservice/export/dhcp", "", http.StatusBadRequest},
+		{"request body type", "reader", http.MethodPost, "/api/v1/bomservice/lookup", `{"mac_addresses": "aa:aa:aa:aa:aa:01"}`, http.StatusBadRequest},
+		{"request body not json", "reader", http.MethodPost, "/api/v1/bomservice/lookup", `mac_addresses`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequestWithContext(context.TODO(), tc.method, tc.path, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.client != "" {
+				request.Header.Set("X-Test-Client", tc.client)
+			}
+
+			recorder := httptest.NewRecorder()
+			g.ServeHTTP(recorder, request)
+
+			if tc.want == http.StatusBadRequest {
+				assertErrorResponse(t, recorder, tc.want, CodeInvalidRequest)
+				return
+			}
+
+			assert.Equal(t, tc.want, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+)
+
+// rateLimit rejects requests from clients over their request rate with 429 Too Many Requests,
+// clients are identified by the authenticated subject, or the client IP when the request is not authenticated.
+func (r *Routes) rateLimit(c *gin.Context) {
+	key := "ip:" + c.ClientIP()
+	if subject := c.GetString(auth.ContextKeySubject); subject != "" {
+		key = "subject:" + subject
+	}
+
+	if ok, retryAfter := r.rateLimiter.Allow(key); !ok {
+		tooManyRequests(c, metrics.LimitRate, retryAfter, ErrRateLimited)
+	}
+}
+
+// limitUploads rejects uploads over the concurrency limit with 429 Too Many Requests,
+// since each upload is read fully into memory.
+func (r *Routes) limitUploads(c *gin.Context) {
+	ok, retryAfter := r.uploadLimiter.Acquire()
+	if !ok {
+		tooManyRequests(c, metrics.LimitUploadConcurrency, retryAfter, ErrUploadConcurrency)
+		return
+	}
+
+	defer r.uploadLimiter.Release()
+
+	c.Next()
+}
+
+// tooManyRequests aborts the request with 429 Too Many Requests and the Retry-After header in whole seconds.
+func tooManyRequests(c *gin.Context, limit string, retryAfter time.Duration, err error) {
+	start := time.Now()
+	seconds := max(1, int(math.Ceil(retryAfter.Seconds())))
+
+	metrics.Throttled(c.FullPath(), limit)
+	metrics.APICallEpilog(start, c.FullPath(), http.StatusTooManyRequests)
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	status, resp := failed(c, err)
+	respond(c, status, resp)
+	c.Abort()
+}
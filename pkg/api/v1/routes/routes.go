@@ -2,11 +2,21 @@ package routes
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/export"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
 	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/ratelimit"
+	"github.com/metal-toolbox/bomservice/internal/reconcile"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
 	"github.com/metal-toolbox/bomservice/internal/store"
 	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
 	"github.com/metal-toolbox/rivets/v2/ginjwt"
@@ -32,6 +42,13 @@ type Bom struct {
 	Metro         string `json:"metro"`
 }
 
+// BulkLookupRequest lists the MAC addresses and serial numbers to look up in a single request.
+type BulkLookupRequest = lookup.BulkRequest
+
+// BulkLookupResult is the response record for a BulkLookupRequest,
+// the found records are in request order and the keys not found are listed separately.
+type BulkLookupResult = lookup.BulkResult
+
 // AocMacAddressBom provides a struct to map the aoc_mac_address table.
 type AocMacAddressBom struct {
 	AocMacAddress string `json:"aoc_mac_address"`
@@ -40,9 +57,24 @@ type AocMacAddressBom struct {
 
 // Routes type sets up the bomservice API  router routes.
 type Routes struct {
-	authMW     *ginjwt.Middleware
-	repository store.Repository
-	logger     *logrus.Logger
+	authMW *ginjwt.Middleware
+	// authenticators authenticate requests carrying credentials other than a JWT.
+	authenticators []auth.Authenticator
+	repository     store.Repository
+	logger         *logrus.Logger
+	// mapping returns the column mapping in effect for a request.
+	mapping    func() *parse.Mapping
+	reconciler *reconcile.Reconciler
+	enroller   *enroll.Enroller
+	// credentials pushes the BOM default BMC credentials of uploaded BOMs.
+	credentials *enroll.CredentialPusher
+	exporter    *export.Exporter
+	// lookupWorkers is the number of concurrent store lookups for a bulk lookup request.
+	lookupWorkers int
+	rateLimiter   *ratelimit.Limiter
+	uploadLimiter *ratelimit.UploadLimiter
+	// openapi is the document requests are validated against.
+	openapi *openapi3.T
 }
 
 // Option type sets a parameter on the Routes type.
@@ -62,6 +94,70 @@ func WithLogger(logger *logrus.Logger) Option {
 	}
 }
 
+// WithMapping sets the column mapping used to parse and generate xlsx files.
+func WithMapping(mapping *parse.Mapping) Option {
+	return func(r *Routes) {
+		r.mapping = func() *parse.Mapping { return mapping }
+	}
+}
+
+// WithMappingFunc sets the function returning the column mapping for each request,
+// for the mapping to change on configuration reload.
+func WithMappingFunc(mapping func() *parse.Mapping) Option {
+	return func(r *Routes) {
+		r.mapping = mapping
+	}
+}
+
+// WithReconciler sets the reconciler for on-demand BOM and fleetdb inventory reconciliation.
+func WithReconciler(reconciler *reconcile.Reconciler) Option {
+	return func(r *Routes) {
+		r.reconciler = reconciler
+	}
+}
+
+// WithEnroller sets the enroller that creates fleetdb server records for uploaded BOMs.
+func WithEnroller(enroller *enroll.Enroller) Option {
+	return func(r *Routes) {
+		r.enroller = enroller
+	}
+}
+
+// WithCredentialPusher sets the pusher that writes the BOM default BMC credentials of uploaded BOMs to fleetdb.
+func WithCredentialPusher(pusher *enroll.CredentialPusher) Option {
+	return func(r *Routes) {
+		r.credentials = pusher
+	}
+}
+
+// WithExporter sets the DHCP reservation exporter.
+func WithExporter(exporter *export.Exporter) Option {
+	return func(r *Routes) {
+		r.exporter = exporter
+	}
+}
+
+// WithLookupWorkers sets the number of concurrent store lookups for a bulk lookup request.
+func WithLookupWorkers(workers int) Option {
+	return func(r *Routes) {
+		r.lookupWorkers = workers
+	}
+}
+
+// WithRateLimiter limits the request rate of each client.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(r *Routes) {
+		r.rateLimiter = limiter
+	}
+}
+
+// WithUploadLimiter limits the number of concurrent uploads.
+func WithUploadLimiter(limiter *ratelimit.UploadLimiter) Option {
+	return func(r *Routes) {
+		r.uploadLimiter = limiter
+	}
+}
+
 // WithAuthMiddleware sets the auth middleware on the routes type.
 func WithAuthMiddleware(authMW *ginjwt.Middleware) Option {
 	return func(r *Routes) {
@@ -69,6 +165,14 @@ func WithAuthMiddleware(authMW *ginjwt.Middleware) Option {
 	}
 }
 
+// WithAuthenticators sets the authenticators tried before JWT verification,
+// the principals they return are held to the same route scopes.
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(r *Routes) {
+		r.authenticators = append(r.authenticators, authenticators...)
+	}
+}
+
 // apiHandler is a function that performs real work for the bomservice API
 type apiHandler func(c *gin.Context) (int, *fleetdbapi.ServerResponse)
 
@@ -77,17 +181,48 @@ type apiHandler func(c *gin.Context) (int, *fleetdbapi.ServerResponse)
 // directly
 func wrapAPICall(fn apiHandler) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		endpoint := ctx.Request.URL.Path
+		// the route template keeps path parameters such as mac addresses out of the metric labels.
+		endpoint := ctx.FullPath()
 		start := time.Now()
 		responseCode, obj := fn(ctx)
 		metrics.APICallEpilog(start, endpoint, responseCode)
-		ctx.JSON(responseCode, obj)
+
+		// server errors are logged by the request logger with the request ID.
+		if responseCode >= http.StatusInternalServerError && obj != nil && obj.Error != "" {
+			_ = ctx.Error(errors.New(obj.Error))
+		}
+
+		respond(ctx, responseCode, obj)
 	}
 }
 
+// errorResponse is an error response record carrying the error code
+// and the request ID to quote when reporting the error.
+type errorResponse struct {
+	*fleetdbapi.ServerResponse
+	Code      ErrorCode `json:"code,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// respond writes the response record, error responses carry the error code and request ID.
+func respond(c *gin.Context, code int, resp *fleetdbapi.ServerResponse) {
+	if code >= http.StatusBadRequest && resp != nil {
+		errCode, ok := c.Value(contextKeyErrorCode).(ErrorCode)
+		if !ok {
+			errCode = statusErrorCode(code)
+		}
+
+		c.JSON(code, &errorResponse{ServerResponse: resp, Code: errCode, RequestID: requestid.FromContext(c.Request.Context())})
+
+		return
+	}
+
+	c.JSON(code, resp)
+}
+
 // NewRoutes returns a new bomservice API routes with handlers registered.
 func NewRoutes(options ...Option) (*Routes, error) {
-	routes := &Routes{}
+	routes := &Routes{mapping: parse.DefaultMapping, lookupWorkers: lookup.DefaultWorkers}
 
 	for _, opt := range options {
 		opt(routes)
@@ -99,6 +234,13 @@ func NewRoutes(options ...Option) (*Routes, error) {
 		return nil, errors.Wrap(ErrStore, "no store repository defined")
 	}
 
+	openapi, err := loadOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+
+	routes.openapi = openapi
+
 	routes.logger.Debug(
 		"routes initialized with support for bomservice: ",
 		strings.Join(supported, ","),
@@ -108,30 +250,136 @@ func NewRoutes(options ...Option) (*Routes, error) {
 }
 
 func (r *Routes) composeAuthHandler(scopes []string) gin.HandlerFunc {
-	if r.authMW == nil {
+	switch {
+	case r.authMW != nil:
+		return r.authMW.RequiredScopes(scopes)
+	case len(r.authenticators) > 0:
+		return auth.RequiredScopes(scopes)
+	default:
 		return ginNoOp
 	}
-	return r.authMW.RequiredScopes(scopes)
 }
 
-func (r *Routes) Routes(g *gin.RouterGroup) {
-	// JWT token verification.
+// authenticate sets the principal from the first authenticator the request carries credentials for,
+// requests without them are verified by the JWT middleware.
+func (r *Routes) authenticate() gin.HandlerFunc {
+	jwtRequired := func(c *gin.Context) {
+		respond(c, http.StatusUnauthorized, &fleetdbapi.ServerResponse{Error: "authentication required"})
+		c.Abort()
+	}
+
 	if r.authMW != nil {
-		g.Use(r.authMW.AuthRequired())
+		jwtRequired = r.authMW.AuthRequired()
 	}
 
+	return func(c *gin.Context) {
+		for _, authenticator := range r.authenticators {
+			principal, err := authenticator.Authenticate(c.Request)
+			if err != nil {
+				respond(c, http.StatusUnauthorized, &fleetdbapi.ServerResponse{Error: err.Error()})
+				c.Abort()
+
+				return
+			}
+
+			if principal != nil {
+				auth.SetPrincipal(c, principal)
+				return
+			}
+		}
+
+		jwtRequired(c)
+	}
+}
+
+func (r *Routes) Routes(g *gin.RouterGroup) {
+	// the API document is registered ahead of the middleware so it is served without credentials.
+	g.GET(OpenAPIPath, r.openAPI)
+
+	g.Use(r.Middleware()...)
+
+	// requests are validated against the API document before the route scopes are checked.
+	g.Use(r.validateRequest(g.BasePath()))
+
+	// BOM routes also accept metro scopes such as read:bom:metro:dc13 and write:bom:metro:dc13,
+	// which restrict the request to the BOMs tagged with the metro.
 	bomService := g.Group("/bomservice")
+	upload := []gin.HandlerFunc{r.composeMetroAuthHandler(createScopes("upload-xlsx-file"), "write", "create")}
+	if r.uploadLimiter != nil {
+		upload = append(upload, r.limitUploads)
+	}
+
 	bomService.POST("/upload-xlsx-file",
-		r.composeAuthHandler(createScopes("upload-xlsx-file")),
-		wrapAPICall(r.billOfMaterialsBatchUpload))
+		append(upload, r.annotateUploadErrors, wrapAPICall(r.billOfMaterialsBatchUpload))...)
+
+	bomService.GET("/template.xlsx",
+		r.composeAuthHandler(readScopes("template")),
+		r.xlsxTemplate)
 
 	bomService.GET("/aoc-mac-address/:aoc_mac_address",
-		r.composeAuthHandler(readScopes("aoc-mac-address")),
+		r.composeMetroAuthHandler(readScopes("aoc-mac-address"), "read"),
 		wrapAPICall(r.getBomInfoByAOCMacAddr))
 
 	bomService.GET("/bmc-mac-address/:bmc_mac_address",
-		r.composeAuthHandler(readScopes("bmc-mac-address")),
+		r.composeMetroAuthHandler(readScopes("bmc-mac-address"), "read"),
 		wrapAPICall(r.getBomInfoByBMCMacAddr))
+
+	bomService.GET("/mac/:mac_address",
+		r.composeMetroAuthHandler(readScopes("mac-address"), "read"),
+		wrapAPICall(r.getBomInfoByMacAddr))
+
+	bomService.POST("/lookup",
+		r.composeMetroAuthHandler(readScopes("lookup"), "read"),
+		wrapAPICall(r.bulkLookup))
+
+	if r.exporter != nil {
+		bomService.GET("/export/dhcp",
+			r.composeMetroAuthHandler(readScopes("export"), "read"),
+			r.exportDHCP)
+	}
+
+	if r.reconciler != nil {
+		bomService.GET("/reconcile",
+			r.composeAuthHandler(readScopes("reconcile")),
+			wrapAPICall(r.reconcileBoms))
+	}
+}
+
+// Middleware returns the handlers authenticating requests and limiting their rate,
+// so other API versions share the credentials and limits of the routes.
+func (r *Routes) Middleware() []gin.HandlerFunc {
+	handlers := []gin.HandlerFunc{}
+
+	// JWT token verification, or one of the authenticators.
+	if r.authMW != nil || len(r.authenticators) > 0 {
+		handlers = append(handlers, r.authenticate())
+	}
+
+	// the request rate is limited once the client is authenticated.
+	if r.rateLimiter != nil {
+		handlers = append(handlers, r.rateLimit)
+	}
+
+	return handlers
+}
+
+// RequireScopes returns a handler verifying the request principal has one of the scopes,
+// or a metro scope for one of the actions which restricts the request to the metro BOMs, see MetroAllowed.
+func (r *Routes) RequireScopes(scopes []string, actions ...string) gin.HandlerFunc {
+	if len(actions) == 0 {
+		return r.composeAuthHandler(scopes)
+	}
+
+	return r.composeMetroAuthHandler(scopes, actions...)
+}
+
+// LimitUploads returns a handler limiting the number of concurrent uploads.
+func (r *Routes) LimitUploads() gin.HandlerFunc {
+	if r.uploadLimiter == nil {
+		return ginNoOp
+	}
+
+	return r.limitUploads
 }
 
 func createScopes(items ...string) []string {
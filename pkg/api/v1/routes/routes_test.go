@@ -0,0 +1,159 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/app"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/parse"
+	"github.com/metal-toolbox/bomservice/internal/ratelimit"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// headerAuthenticator grants the scopes keyed by the X-Test-Client request header.
+type headerAuthenticator map[string][]string
+
+func (a headerAuthenticator) Authenticate(r *http.Request) (*auth.Principal, error) {
+	client := r.Header.Get("X-Test-Client")
+	if client == "" {
+		return nil, nil
+	}
+
+	scopes, ok := a[client]
+	if !ok {
+		return nil, errors.Wrap(auth.ErrUnauthorized, "client "+client)
+	}
+
+	return &auth.Principal{Subject: client, Scopes: scopes, Method: "test"}, nil
+}
+
+func TestAuthenticators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := NewRoutes(
+		WithLogger(logrus.New()),
+		WithStore(mockstore.NewMockRepository(ctrl)),
+		WithAuthenticators(headerAuthenticator{"reader": {"read:template"}, "writer": {"write"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Router.Routes(g.Group("/api/v1"))
+
+	testcases := []struct {
+		name   string
+		client string
+		want   int
+	}{
+		{"scope granted", "reader", http.StatusOK},
+		{"scope not granted", "writer", http.StatusForbidden},
+		{"unknown client", "other", http.StatusUnauthorized},
+		{"no credentials", "", http.StatusUnauthorized},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/bomservice/template.xlsx", http.NoBody)
+
+			if tc.client != "" {
+				req.Header.Set("X-Test-Client", tc.client)
+			}
+
+			g.ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	options := &app.RateLimitOptions{Enabled: true, RequestsPerSecond: 0.1, Burst: 1, UploadConcurrency: 1, UploadRetryAfter: 5 * time.Second}
+	uploadLimiter := ratelimit.NewUploadLimiter(options)
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := NewRoutes(
+		WithLogger(logrus.New()),
+		WithStore(mockstore.NewMockRepository(ctrl)),
+		WithAuthenticators(headerAuthenticator{"a": {"read", "write"}, "b": {"read", "write"}}),
+		WithRateLimiter(ratelimit.NewLimiter(options)),
+		WithUploadLimiter(uploadLimiter),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Router.Routes(g.Group("/api/v1"))
+
+	request := func(client, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, http.NoBody)
+		req.Header.Set("X-Test-Client", client)
+
+		g.ServeHTTP(w, req)
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("a", http.MethodGet, "/api/v1/bomservice/template.xlsx").Code)
+
+	w := request("a", http.MethodGet, "/api/v1/bomservice/template.xlsx")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	// an upload in flight holds the only upload slot.
+	ok, _ := uploadLimiter.Acquire()
+	assert.True(t, ok)
+
+	w = request("b", http.MethodPost, "/api/v1/bomservice/upload-xlsx-file")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), ErrUploadConcurrency.Error())
+}
+
+func TestErrorStatus(t *testing.T) {
+	testcases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{"parse validation", errors.Wrap(parse.ErrEmptySerialNum, "row 2"), http.StatusBadRequest, CodeValidationFailed},
+		{"rejected by the store", errors.Wrap(store.ErrInvalidBom, "upload"), http.StatusBadRequest, CodeValidationFailed},
+		{"bad request body", errors.Wrap(ErrInvalidRequest, "EOF"), http.StatusBadRequest, CodeInvalidRequest},
+		{"unauthorized", errors.Wrap(auth.ErrUnauthorized, "invalid api key"), http.StatusUnauthorized, CodeUnauthorized},
+		{"metro not granted", ErrMetroScope, http.StatusForbidden, CodeForbidden},
+		{"not found", errors.Wrap(store.ErrBomNotFound, "serial"), http.StatusNotFound, CodeNotFound},
+		{"conflict", errors.Wrap(store.ErrConflict, "upload"), http.StatusConflict, CodeConflict},
+		{"mac collision", ErrMacCollision, http.StatusConflict, CodeMacCollision},
+		{"rate limited", ErrUploadConcurrency, http.StatusTooManyRequests, CodeRateLimited},
+		{"backend unavailable", errors.Wrap(store.ErrBackendUnavailable, "timeout"), http.StatusServiceUnavailable, CodeBackendUnavailable},
+		{"unclassified", errors.New("unexpected"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, code := ErrorStatus(tc.err)
+			assert.Equal(t, tc.wantStatus, status)
+			assert.Equal(t, tc.wantCode, code)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package client is the typed client of the bomservice v2 API.
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/metal-toolbox/bomservice/pkg/api/v2/routes"
+)
+
+const (
+	bomsEndpoint    = "boms"
+	macsEndpoint    = "macs"
+	uploadsEndpoint = "uploads"
+
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// requestIDHeader is the response header carrying the server request ID.
+	requestIDHeader = "X-Request-ID"
+)
+
+// HTTPRequestDoer performs HTTP requests.
+//
+// The standard http.Client implements this interface.
+type HTTPRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client can perform queries against the bomservice v2 API.
+type Client struct {
+	// The server address with the schema
+	serverAddress string
+
+	// Authentication token
+	authToken string
+
+	// Doer for performing requests, typically a *http.Client with any
+	// customized settings, such as certificate chains.
+	client HTTPRequestDoer
+}
+
+// Option allows setting custom parameters during construction
+type Option func(*Client) error
+
+// NewClient returns a client of the API served at the server address.
+func NewClient(serverAddress string, opts ...Option) (*Client, error) {
+	client := Client{serverAddress: serverAddress}
+
+	for _, o := range opts {
+		if err := o(&client); err != nil {
+			return nil, err
+		}
+	}
+
+	if client.client == nil {
+		client.client = &http.Client{}
+	}
+
+	return &client, nil
+}
+
+// WithHTTPClient allows overriding the default Doer, which is
+// automatically created using http.Client. This is useful for tests.
+func WithHTTPClient(doer HTTPRequestDoer) Option {
+	return func(c *Client) error {
+		c.client = doer
+		return nil
+	}
+}
+
+// WithAuthToken sets the client auth token.
+func WithAuthToken(authToken string) Option {
+	return func(c *Client) error {
+		c.authToken = authToken
+		return nil
+	}
+}
+
+// ListParams selects the page of a list, zero values select the server defaults.
+type ListParams struct {
+	Page     int
+	PageSize int
+	// Metro lists only the BOMs tagged with the metro.
+	Metro string
+}
+
+func (p *ListParams) query() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+
+	if p.Page > 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+
+	if p.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+
+	if p.Metro != "" {
+		q.Set("metro", p.Metro)
+	}
+
+	return q
+}
+
+// UploadParams are the options of an upload.
+type UploadParams struct {
+	// Metro tags the uploaded BOMs, clients granted metro scopes may only upload to their metros.
+	Metro string
+	// FlagMacCollisions stores BOMs with MAC addresses claimed by more than one serial number
+	// and lists them in the upload, instead of rejecting the upload.
+	FlagMacCollisions bool
+}
+
+// ListBoms returns a page of the BOMs ordered by serial number.
+func (c *Client) ListBoms(ctx context.Context, params *ListParams) (*routes.BomList, error) {
+	list := &routes.BomList{}
+	if err := c.get(ctx, bomsEndpoint, params.query(), list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// GetBom returns the BOM with the serial number.
+func (c *Client) GetBom(ctx context.Context, serial string) (*routes.Bom, error) {
+	bom := &routes.Bom{}
+	if err := c.get(ctx, bomsEndpoint+"/"+url.PathEscape(serial), nil, bom); err != nil {
+		return nil, err
+	}
+
+	return bom, nil
+}
+
+// ListBomMacs returns the MAC addresses of the BOM with the serial number.
+func (c *Client) ListBomMacs(ctx context.Context, serial string) ([]routes.MacAddress, error) {
+	list := &routes.MacAddressList{}
+	if err := c.get(ctx, bomsEndpoint+"/"+url.PathEscape(serial)+"/"+macsEndpoint, nil, list); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// Upload uploads the BOMs in the xlsx file.
+//
+// Failed uploads of a parsed file are recorded, the returned *Error has the UploadID to get the record.
+func (c *Client) Upload(ctx context.Context, fileBytes []byte, params *UploadParams) (*routes.Upload, error) {
+	q := url.Values{}
+	if params != nil && params.Metro != "" {
+		q.Set("metro", params.Metro)
+	}
+
+	if params != nil && params.FlagMacCollisions {
+		q.Set("mac_collisions", "flag")
+	}
+
+	upload := &routes.Upload{}
+	if err := c.post(ctx, uploadsEndpoint, q, xlsxContentType, fileBytes, upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// GetUpload returns the upload with the ID.
+func (c *Client) GetUpload(ctx context.Context, id string) (*routes.Upload, error) {
+	upload := &routes.Upload{}
+	if err := c.get(ctx, uploadsEndpoint+"/"+url.PathEscape(id), nil, upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// ListUploads returns a page of the recent uploads, the most recent first.
+func (c *Client) ListUploads(ctx context.Context, params *ListParams) (*routes.UploadList, error) {
+	list := &routes.UploadList{}
+	if err := c.get(ctx, uploadsEndpoint, params.query(), list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	"github.com/metal-toolbox/bomservice/pkg/api/v2/routes"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockserver(t *testing.T, repository store.Repository) *Client {
+	t.Helper()
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := v1routes.NewRoutes(v1routes.WithLogger(logrus.New()), v1routes.WithStore(repository))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2Router, err := routes.NewRoutes(v1Router, routes.WithStore(repository))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2Router.Routes(g.Group(routes.PathPrefix))
+
+	server := httptest.NewServer(g)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// expectSerialLookups answers serial number lookups from the boms.
+func expectSerialLookups(repository *mockstore.MockRepository, boms []fleetdbapi.Bom) {
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			for i := range boms {
+				if boms[i].SerialNum == serial {
+					bom := boms[i]
+					return &bom, &fleetdbapi.ServerResponse{Record: &bom}, nil
+				}
+			}
+
+			return nil, nil, store.ErrBomNotFound
+		}).
+		AnyTimes()
+}
+
+func TestClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := []fleetdbapi.Bom{
+		{SerialNum: "serial-b", BmcMacAddress: "bb:bb:bb:bb:bb:02", Metro: "am6"},
+		{SerialNum: "serial-a", AocMacAddress: "aa:aa:aa:aa:aa:01", BmcMacAddress: "bb:bb:bb:bb:bb:01", Metro: "dc13"},
+	}
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		ListBoms(gomock.Any(), gomock.Any()).
+		Return(stored, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+	expectSerialLookups(repository, stored)
+
+	client := mockserver(t, repository)
+	ctx := context.Background()
+
+	list, err := client.ListBoms(ctx, &ListParams{PageSize: 1, Page: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "serial-b", list.Items[0].SerialNum)
+	assert.Equal(t, 2, list.Pagination.TotalItems)
+
+	list, err = client.ListBoms(ctx, &ListParams{Metro: "dc13"})
+	assert.Nil(t, err)
+	assert.Len(t, list.Items, 1)
+
+	bom, err := client.GetBom(ctx, "serial-a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"aa:aa:aa:aa:aa:01"}, bom.AOCMacAddresses)
+
+	macs, err := client.ListBomMacs(ctx, "serial-a")
+	assert.Nil(t, err)
+	assert.Equal(t, []routes.MacAddress{
+		{Address: "aa:aa:aa:aa:aa:01", Role: routes.MacRoleAOC},
+		{Address: "bb:bb:bb:bb:bb:01", Role: routes.MacRoleBMC},
+	}, macs)
+
+	_, err = client.GetBom(ctx, "unknown")
+
+	var e *Error
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+		assert.Equal(t, v1routes.CodeNotFound, e.Code)
+	}
+
+	_, err = client.Upload(ctx, []byte("not a spreadsheet"), &UploadParams{Metro: "dc13"})
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+		assert.Empty(t, e.UploadID)
+	}
+
+	uploads, err := client.ListUploads(ctx, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, uploads.Items)
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"fmt"
+
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+)
+
+// Error is returned for failed requests, the status code is zero when no response was received.
+type Error struct {
+	Message    string
+	StatusCode int
+	// Code is the error code of the response, clients should match on it rather than the message.
+	Code v1routes.ErrorCode
+	// RequestID is the X-Request-ID of the failed request, to quote when reporting the error.
+	RequestID string
+	// UploadID is the ID of the recorded upload, set for failed uploads of parsed files.
+	UploadID string
+}
+
+// Error returns the Error in string format.
+func (e *Error) Error() string {
+	if e.StatusCode == 0 {
+		return "bom-service client error - " + e.Message
+	}
+
+	return fmt.Sprintf("bom-service request error, statusCode: %d, code: %s, requestID: %s, message: %s",
+		e.StatusCode, e.Code, e.RequestID, e.Message)
+}
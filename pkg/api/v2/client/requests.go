@@ -0,0 +1,103 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/metal-toolbox/bomservice/pkg/api/v2/routes"
+)
+
+func (c *Client) get(ctx context.Context, endpoint string, query url.Values, out any) error {
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, query, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	return c.do(req, out)
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, query url.Values, contentType string, body []byte, out any) error {
+	req, err := c.newRequest(ctx, http.MethodPost, endpoint, query, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	return c.do(req, out)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, query url.Values, body io.Reader) (*http.Request, error) {
+	requestURL, err := url.Parse(fmt.Sprintf("%s%s/%s", c.serverAddress, routes.PathPrefix, endpoint))
+	if err != nil {
+		return nil, &Error{Message: err.Error()}
+	}
+
+	if len(query) > 0 {
+		requestURL.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), body)
+	if err != nil {
+		return nil, &Error{Message: "error in " + method + " request: " + err.Error()}
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if c.authToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("bearer %s", c.authToken))
+	}
+
+	return req, nil
+}
+
+// do performs the request and decodes the response body into out, error responses are returned as *Error.
+func (c *Client) do(req *http.Request, out any) error {
+	response, err := c.client.Do(req)
+	if err != nil {
+		return &Error{Message: err.Error()}
+	}
+
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return responseError(response, "failed to read response body: "+err.Error())
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		errResp := &routes.ErrorResponse{}
+		if err := json.Unmarshal(data, errResp); err != nil || errResp.Error == nil {
+			// responses from the shared v1 middleware and proxies are not v2 error responses.
+			return responseError(response, http.StatusText(response.StatusCode)+": "+string(data))
+		}
+
+		e := responseError(response, errResp.Error.Message)
+		e.Code = errResp.Error.Code
+
+		return e
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return responseError(response, "failed to unmarshal response from server: "+err.Error())
+	}
+
+	return nil
+}
+
+// responseError returns an Error with the status code, request ID and upload of the response.
+func responseError(response *http.Response, message string) *Error {
+	e := &Error{Message: message, StatusCode: response.StatusCode, RequestID: response.Header.Get(requestIDHeader)}
+
+	if location := response.Header.Get("Location"); location != "" && response.Request.Method == http.MethodPost {
+		e.UploadID = path.Base(location)
+	}
+
+	return e
+}
@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	"github.com/pkg/errors"
+)
+
+// listBoms responds with a page of the stored BOMs, optionally of the metro query parameter.
+//
+// The listing requires a store backend that lists BOMs, otherwise the request fails with 501 Not Implemented.
+func (r *Routes) listBoms(c *gin.Context) {
+	p, err := parsePage(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	stored, err := store.ListAllBoms(c.Request.Context(), r.repository)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	metro, filtered := c.GetQuery("metro")
+
+	boms := make([]Bom, 0, len(stored))
+
+	for i := range stored {
+		if (filtered && stored[i].Metro != metro) || !v1routes.MetroAllowed(c, &stored[i]) {
+			continue
+		}
+
+		boms = append(boms, newBom(&stored[i]))
+	}
+
+	sort.Slice(boms, func(i, j int) bool { return boms[i].SerialNum < boms[j].SerialNum })
+
+	start, end, pagination := paginate(c, p, len(boms))
+
+	respond(c, http.StatusOK, &BomList{Items: boms[start:end], Pagination: pagination})
+}
+
+// bom returns the BOM with the serial path parameter, BOMs the request may not access are not found.
+func (r *Routes) bom(c *gin.Context) (*Bom, error) {
+	serial := c.Param("serial")
+
+	stored, err := lookup.Serial(c.Request.Context(), r.repository, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v1routes.MetroAllowed(c, stored) {
+		return nil, errors.Wrap(store.ErrBomNotFound, "serial "+serial)
+	}
+
+	bom := newBom(stored)
+
+	return &bom, nil
+}
+
+func (r *Routes) getBom(c *gin.Context) {
+	bom, err := r.bom(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, bom)
+}
+
+func (r *Routes) listBomMacs(c *gin.Context) {
+	bom, err := r.bom(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, newMacAddressList(bom))
+}
+
+// createUpload stores the BOMs in the uploaded xlsx file, the metro and mac_collisions query parameters
+// are those of the v1 upload. Uploads are recorded once the file is parsed, failed uploads included,
+// and the Location header links to the record.
+func (r *Routes) createUpload(c *gin.Context) {
+	if c.Request.ContentLength == -1 {
+		respondError(c, errors.Wrap(v1routes.ErrInvalidRequest, "reject the request since the file size unknown"))
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondError(c, errors.Wrap(v1routes.ErrInvalidRequest, err.Error()))
+		return
+	}
+
+	uploaded, err := r.v1.UploadXlsx(c, data)
+	if uploaded == nil {
+		respondError(c, err)
+		return
+	}
+
+	upload := &Upload{
+		ID:            uuid.NewString(),
+		Status:        UploadSucceeded,
+		Metro:         uploaded.Metro,
+		UploadedBy:    c.GetString(auth.ContextKeySubject),
+		CreatedAt:     time.Now().UTC(),
+		Serials:       make([]string, 0, len(uploaded.Boms)),
+		MacCollisions: newMacCollisions(uploaded.Result.MacCollisions),
+		Enrolled:      newEnrollment(uploaded.Result.Enrolled),
+	}
+
+	for i := range uploaded.Boms {
+		upload.Serials = append(upload.Serials, uploaded.Boms[i].SerialNum)
+	}
+
+	if err != nil {
+		_, code := v1routes.ErrorStatus(err)
+		upload.Status = UploadFailed
+		upload.Error = &Error{Code: code, Message: err.Error(), RequestID: requestid.FromContext(c.Request.Context())}
+	}
+
+	r.uploads.add(upload)
+
+	c.Header("Location", c.Request.URL.Path+"/"+upload.ID)
+
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, upload)
+}
+
+func (r *Routes) listUploads(c *gin.Context) {
+	p, err := parsePage(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	uploads := r.uploads.list(func(u *Upload) bool {
+		return v1routes.MetroGranted(c, u.Metro)
+	})
+
+	start, end, pagination := paginate(c, p, len(uploads))
+
+	respond(c, http.StatusOK, &UploadList{Items: uploads[start:end], Pagination: pagination})
+}
+
+func (r *Routes) getUpload(c *gin.Context) {
+	upload := r.uploads.get(c.Param("id"))
+	if upload == nil || !v1routes.MetroGranted(c, upload.Metro) {
+		respondError(c, errors.Wrap(v1routes.ErrNotFound, "upload "+c.Param("id")))
+		return
+	}
+
+	respond(c, http.StatusOK, upload)
+}
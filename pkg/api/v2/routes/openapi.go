@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// OpenAPIPath is the path of the OpenAPI document, relative to PathPrefix.
+const OpenAPIPath = "/openapi.json"
+
+var (
+	ErrOpenAPI = errors.New("invalid OpenAPI document")
+
+	//go:embed openapi.json
+	openAPIDocument []byte
+
+	loadOpenAPI = sync.OnceValues(func() (*openapi3.T, error) {
+		doc, err := openapi3.NewLoader().LoadFromData(openAPIDocument)
+		if err != nil {
+			return nil, errors.Wrap(ErrOpenAPI, err.Error())
+		}
+
+		if err := doc.Validate(context.Background()); err != nil {
+			return nil, errors.Wrap(ErrOpenAPI, err.Error())
+		}
+
+		return doc, nil
+	})
+)
+
+// OpenAPIDocument returns the OpenAPI 3 document describing the v2 API.
+func OpenAPIDocument() []byte {
+	return openAPIDocument
+}
+
+// LoadOpenAPI returns the parsed OpenAPI document describing the v2 API.
+func LoadOpenAPI() (*openapi3.T, error) {
+	return loadOpenAPI()
+}
+
+// openAPI responds with the OpenAPI document.
+func (r *Routes) openAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", openAPIDocument)
+}
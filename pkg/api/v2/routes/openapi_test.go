@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	doc, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := mockserver(t, mockstore.NewMockRepository(ctrl))
+
+	registered := map[string]bool{}
+
+	for _, route := range g.Routes() {
+		path := v1routes.OpenAPIPathTemplate(strings.TrimPrefix(route.Path, PathPrefix))
+		registered[route.Method+" "+path] = true
+
+		pathItem := doc.Paths.Value(path)
+		if !assert.NotNil(t, pathItem, "route %s %s is not in the OpenAPI document", route.Method, route.Path) {
+			continue
+		}
+
+		assert.NotNil(t, pathItem.GetOperation(route.Method), "route %s %s is not in the OpenAPI document", route.Method, route.Path)
+	}
+
+	for path, pathItem := range doc.Paths.Map() {
+		for method := range pathItem.Operations() {
+			assert.True(t, registered[method+" "+path], "operation %s %s is not a registered route", method, path)
+		}
+	}
+
+	// errors carry the codes of the v1 document.
+	v1doc, err := v1routes.LoadOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := doc.Components.Schemas["Error"].Value.Properties["code"].Value.Enum
+	assert.ElementsMatch(t, v1doc.Components.Schemas["ErrorResponse"].Value.Properties["code"].Value.Enum, codes)
+
+	// the document is served without credentials.
+	w := request(t, g, "", http.MethodGet, PathPrefix+OpenAPIPath, nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, OpenAPIDocument(), w.Body.Bytes())
+}
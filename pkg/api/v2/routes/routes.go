@@ -0,0 +1,260 @@
+// Package routes serves the resource oriented v2 API, alongside v1 and sharing its
+// authentication, rate limits and metro scopes.
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metal-toolbox/bomservice/internal/metrics"
+	"github.com/metal-toolbox/bomservice/internal/requestid"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PathPrefix = "/api/v2"
+
+	// DefaultPageSize and MaxPageSize bound the page_size query parameter of list requests.
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+var (
+	ErrStore  = errors.New("store error")
+	ErrRoutes = errors.New("error in routes")
+)
+
+// Routes type sets up the bomservice v2 API router routes.
+type Routes struct {
+	// v1 authenticates and authorizes the requests, and uploads the BOMs.
+	v1         *v1routes.Routes
+	repository store.Repository
+	logger     *logrus.Logger
+	uploads    *uploadLog
+}
+
+// Option type sets a parameter on the Routes type.
+type Option func(*Routes)
+
+// WithStore sets the storage repository on the routes type.
+func WithStore(repository store.Repository) Option {
+	return func(r *Routes) {
+		r.repository = repository
+	}
+}
+
+// WithLogger sets the logger on the routes type.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(r *Routes) {
+		r.logger = logger
+	}
+}
+
+// WithUploadHistory sets the number of uploads listed by the uploads resource.
+func WithUploadHistory(size int) Option {
+	return func(r *Routes) {
+		r.uploads = newUploadLog(size)
+	}
+}
+
+// NewRoutes returns the v2 API routes, built on the v1 routes of the server.
+func NewRoutes(v1 *v1routes.Routes, options ...Option) (*Routes, error) {
+	routes := &Routes{v1: v1}
+
+	for _, opt := range options {
+		opt(routes)
+	}
+
+	if routes.v1 == nil {
+		return nil, errors.Wrap(ErrRoutes, "no v1 routes defined")
+	}
+
+	if routes.repository == nil {
+		return nil, errors.Wrap(ErrStore, "no store repository defined")
+	}
+
+	if routes.logger == nil {
+		routes.logger = logrus.New()
+	}
+
+	if routes.uploads == nil {
+		routes.uploads = newUploadLog(DefaultUploadHistory)
+	}
+
+	return routes, nil
+}
+
+func (r *Routes) Routes(g *gin.RouterGroup) {
+	// the API document is registered ahead of the middleware so it is served without credentials.
+	g.GET(OpenAPIPath, r.openAPI)
+
+	g.Use(r.v1.Middleware()...)
+	g.Use(observe)
+
+	// BOM routes also accept the metro scopes of v1, read:bom:metro:dc13 and write:bom:metro:dc13.
+	boms := g.Group("/boms")
+	boms.GET("", r.v1.RequireScopes(readScopes("boms"), "read"), r.listBoms)
+	boms.GET("/:serial", r.v1.RequireScopes(readScopes("boms"), "read"), r.getBom)
+	boms.GET("/:serial/macs", r.v1.RequireScopes(readScopes("boms"), "read"), r.listBomMacs)
+
+	uploads := g.Group("/uploads")
+	uploads.POST("", r.v1.RequireScopes(createScopes("uploads"), "write", "create"), r.v1.LimitUploads(), r.createUpload)
+	uploads.GET("", r.v1.RequireScopes(readScopes("uploads"), "read"), r.listUploads)
+	uploads.GET("/:id", r.v1.RequireScopes(readScopes("uploads"), "read"), r.getUpload)
+}
+
+// observe records the request metrics by route template.
+func observe(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	metrics.APICallEpilog(start, c.FullPath(), c.Writer.Status())
+}
+
+func createScopes(items ...string) []string {
+	s := []string{"write", "create"}
+	for _, i := range items {
+		s = append(s, fmt.Sprintf("create:%s", i))
+	}
+
+	return s
+}
+
+func readScopes(items ...string) []string {
+	s := []string{"read"}
+	for _, i := range items {
+		s = append(s, fmt.Sprintf("read:%s", i))
+	}
+
+	return s
+}
+
+// respond writes the response body, successful GET responses carry an ETag
+// and requests with a matching If-None-Match header get 304 Not Modified.
+func respond(c *gin.Context, status int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if status == http.StatusOK && c.Request.Method == http.MethodGet {
+		etag := etag(data)
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "no-cache")
+
+		if etagMatch(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(status, "application/json; charset=utf-8", data)
+}
+
+// respondError writes the error response with the status and code mapped from the error by v1.
+func respondError(c *gin.Context, err error) {
+	status, code := v1routes.ErrorStatus(err)
+
+	// server errors are logged by the request logger with the request ID.
+	if status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+
+	c.AbortWithStatusJSON(status, &ErrorResponse{Error: &Error{
+		Code:      code,
+		Message:   err.Error(),
+		RequestID: requestid.FromContext(c.Request.Context()),
+	}})
+}
+
+// etag returns a strong entity tag for the response body.
+func etag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch returns true when the If-None-Match header lists the entity tag.
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// page is the page of a list requested by the page and page_size query parameters.
+type page struct {
+	number int
+	size   int
+}
+
+// parsePage returns the requested page, the first one of DefaultPageSize items by default.
+func parsePage(c *gin.Context) (page, error) {
+	p := page{number: 1, size: DefaultPageSize}
+
+	for param, value := range map[string]*int{"page": &p.number, "page_size": &p.size} {
+		s := c.Query(param)
+		if s == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return p, errors.Wrapf(v1routes.ErrInvalidRequest, "%s must be a positive integer", param)
+		}
+
+		*value = n
+	}
+
+	if p.size > MaxPageSize {
+		return p, errors.Wrapf(v1routes.ErrInvalidRequest, "page_size exceeds the limit of %d", MaxPageSize)
+	}
+
+	return p, nil
+}
+
+// paginate returns the bounds of the page in a list of total items and describes it.
+func paginate(c *gin.Context, p page, total int) (start, end int, pagination Pagination) {
+	pagination = Pagination{
+		Page:       p.number,
+		PageSize:   p.size,
+		TotalItems: total,
+		TotalPages: (total + p.size - 1) / p.size,
+	}
+
+	start = min((p.number-1)*p.size, total)
+	end = min(start+p.size, total)
+
+	if p.number < pagination.TotalPages {
+		pagination.Next = pageLink(c, p.number+1)
+	}
+
+	if p.number > 1 && pagination.TotalPages > 0 {
+		pagination.Previous = pageLink(c, min(p.number-1, pagination.TotalPages))
+	}
+
+	return start, end, pagination
+}
+
+// pageLink returns the request path and query with the page number replaced.
+func pageLink(c *gin.Context, number int) string {
+	q := c.Request.URL.Query()
+	q.Set("page", strconv.Itoa(number))
+
+	return c.Request.URL.Path + "?" + q.Encode()
+}
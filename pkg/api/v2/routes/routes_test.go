@@ -0,0 +1,287 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/metal-toolbox/bomservice/internal/auth"
+	"github.com/metal-toolbox/bomservice/internal/store"
+	mockstore "github.com/metal-toolbox/bomservice/internal/store/mock"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// headerAuthenticator grants the scopes keyed by the X-Test-Client request header.
+type headerAuthenticator map[string][]string
+
+func (a headerAuthenticator) Authenticate(r *http.Request) (*auth.Principal, error) {
+	client := r.Header.Get("X-Test-Client")
+	if client == "" {
+		return nil, nil
+	}
+
+	return &auth.Principal{Subject: client, Scopes: a[client], Method: "test"}, nil
+}
+
+var testClients = headerAuthenticator{
+	"admin":     {"read", "write"},
+	"dc13-team": {auth.MetroScope("read", "dc13"), auth.MetroScope("write", "dc13")},
+}
+
+func mockserver(t *testing.T, repository store.Repository) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	v1Router, err := v1routes.NewRoutes(
+		v1routes.WithLogger(logrus.New()),
+		v1routes.WithStore(repository),
+		v1routes.WithAuthenticators(testClients),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2Router, err := NewRoutes(v1Router, WithStore(repository), WithUploadHistory(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2Router.Routes(g.Group(PathPrefix))
+
+	return g
+}
+
+func request(t *testing.T, g *gin.Engine, client, method, path string, body []byte, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.TODO(), method, path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	req.Header.Set("X-Test-Client", client)
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) *T {
+	t.Helper()
+
+	v := new(T)
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatal(err, w.Body.String())
+	}
+
+	return v
+}
+
+// expectSerialLookups answers serial number lookups from the boms.
+func expectSerialLookups(repository *mockstore.MockRepository, boms []fleetdbapi.Bom) {
+	repository.EXPECT().
+		GetBomInfoBySerial(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, serial string) (*fleetdbapi.Bom, *fleetdbapi.ServerResponse, error) {
+			for i := range boms {
+				if boms[i].SerialNum == serial {
+					bom := boms[i]
+					return &bom, &fleetdbapi.ServerResponse{Record: &bom}, nil
+				}
+			}
+
+			return nil, nil, store.ErrBomNotFound
+		}).
+		AnyTimes()
+}
+
+func TestBoms(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := []fleetdbapi.Bom{
+		{SerialNum: "serial-c", AocMacAddress: "aa:aa:aa:aa:aa:03", Metro: "am6"},
+		{SerialNum: "serial-a", AocMacAddress: "AA:AA:AA:AA:AA:01, aa:aa:aa:aa:aa:02", BmcMacAddress: "bb:bb:bb:bb:bb:01", Metro: "dc13"},
+		{SerialNum: "serial-b", Metro: "dc13"},
+	}
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		ListBoms(gomock.Any(), gomock.Any()).
+		Return(stored, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+	expectSerialLookups(repository, stored)
+
+	g := mockserver(t, repository)
+
+	t.Run("list pages", func(t *testing.T) {
+		w := request(t, g, "admin", http.MethodGet, "/api/v2/boms?page_size=2", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		list := decode[BomList](t, w)
+		assert.Equal(t, []string{"serial-a", "serial-b"}, []string{list.Items[0].SerialNum, list.Items[1].SerialNum})
+		assert.Equal(t, Pagination{Page: 1, PageSize: 2, TotalItems: 3, TotalPages: 2, Next: "/api/v2/boms?page=2&page_size=2"}, list.Pagination)
+
+		w = request(t, g, "admin", http.MethodGet, list.Pagination.Next, nil, nil)
+		list = decode[BomList](t, w)
+		assert.Equal(t, "serial-c", list.Items[0].SerialNum)
+		assert.Equal(t, "/api/v2/boms?page=1&page_size=2", list.Pagination.Previous)
+		assert.Empty(t, list.Pagination.Next)
+	})
+
+	t.Run("metro filter", func(t *testing.T) {
+		list := decode[BomList](t, request(t, g, "admin", http.MethodGet, "/api/v2/boms?metro=am6", nil, nil))
+		assert.Len(t, list.Items, 1)
+		assert.Equal(t, "serial-c", list.Items[0].SerialNum)
+	})
+
+	t.Run("metro scopes", func(t *testing.T) {
+		list := decode[BomList](t, request(t, g, "dc13-team", http.MethodGet, "/api/v2/boms", nil, nil))
+		assert.Equal(t, 2, list.Pagination.TotalItems)
+
+		w := request(t, g, "dc13-team", http.MethodGet, "/api/v2/boms/serial-c", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w := request(t, g, "admin", http.MethodGet, "/api/v2/boms/serial-a", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		expected := &Bom{
+			SerialNum:       "serial-a",
+			AOCMacAddresses: []string{"aa:aa:aa:aa:aa:01", "aa:aa:aa:aa:aa:02"},
+			BMCMacAddresses: []string{"bb:bb:bb:bb:bb:01"},
+			Metro:           "dc13",
+		}
+		assert.Equal(t, expected, decode[Bom](t, w))
+
+		// the entity tag is revalidated.
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		w = request(t, g, "admin", http.MethodGet, "/api/v2/boms/serial-a", nil, http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("macs", func(t *testing.T) {
+		w := request(t, g, "admin", http.MethodGet, "/api/v2/boms/serial-a/macs", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		expected := &MacAddressList{Items: []MacAddress{
+			{Address: "aa:aa:aa:aa:aa:01", Role: MacRoleAOC},
+			{Address: "aa:aa:aa:aa:aa:02", Role: MacRoleAOC},
+			{Address: "bb:bb:bb:bb:bb:01", Role: MacRoleBMC},
+		}}
+		assert.Equal(t, expected, decode[MacAddressList](t, w))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		w := request(t, g, "admin", http.MethodGet, "/api/v2/boms/unknown", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, v1routes.CodeNotFound, decode[ErrorResponse](t, w).Error.Code)
+
+		w = request(t, g, "admin", http.MethodGet, "/api/v2/boms?page_size=0", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, v1routes.CodeInvalidRequest, decode[ErrorResponse](t, w).Error.Code)
+	})
+}
+
+func TestBomsListUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+	repository.EXPECT().
+		ListBoms(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrListUnsupported).
+		Times(1)
+
+	g := mockserver(t, repository)
+
+	w := request(t, g, "admin", http.MethodGet, "/api/v2/boms", nil, nil)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+	assert.Equal(t, v1routes.CodeNotImplemented, decode[ErrorResponse](t, w).Error.Code)
+}
+
+func TestUploads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := mockstore.NewMockRepository(ctrl)
+
+	// the BMC address FakeMac3 of the uploaded test-serial-2 is stored as the AOC address of another serial.
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), "FakeMac3").
+		Return(&fleetdbapi.Bom{SerialNum: "other-serial"}, &fleetdbapi.ServerResponse{}, nil).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByAOCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	repository.EXPECT().
+		GetBomInfoByBMCMacAddr(gomock.Any(), gomock.Any()).
+		Return(nil, nil, store.ErrBomNotFound).
+		AnyTimes()
+	expectSerialLookups(repository, nil)
+
+	g := mockserver(t, repository)
+
+	data, err := os.ReadFile("../../../../internal/parse/testdata/test_valid_multiple_boms.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the collision rejects the upload, which is recorded.
+	w := request(t, g, "admin", http.MethodPost, "/api/v2/uploads", data, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, v1routes.CodeMacCollision, decode[ErrorResponse](t, w).Error.Code)
+
+	failed := decode[Upload](t, request(t, g, "admin", http.MethodGet, w.Header().Get("Location"), nil, nil))
+	assert.Equal(t, UploadFailed, failed.Status)
+	assert.Equal(t, v1routes.CodeMacCollision, failed.Error.Code)
+	assert.Equal(t, []string{"fakemac3"}, []string{failed.MacCollisions[0].MacAddress})
+
+	repository.EXPECT().
+		BillOfMaterialsBatchUpload(gomock.Any(), gomock.Len(2)).
+		Return(&fleetdbapi.ServerResponse{}, nil).
+		Times(1)
+
+	w = request(t, g, "dc13-team", http.MethodPost, "/api/v2/uploads?mac_collisions=flag", data, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	created := decode[Upload](t, w)
+	assert.Equal(t, UploadSucceeded, created.Status)
+	assert.Equal(t, "dc13", created.Metro)
+	assert.Equal(t, "dc13-team", created.UploadedBy)
+	assert.ElementsMatch(t, []string{"test-serial-1", "test-serial-2"}, created.Serials)
+	assert.Equal(t, "/api/v2/uploads/"+created.ID, w.Header().Get("Location"))
+
+	got := decode[Upload](t, request(t, g, "dc13-team", http.MethodGet, "/api/v2/uploads/"+created.ID, nil, nil))
+	assert.Equal(t, created.ID, got.ID)
+
+	list := decode[UploadList](t, request(t, g, "admin", http.MethodGet, "/api/v2/uploads", nil, nil))
+	assert.Equal(t, []string{created.ID, failed.ID}, []string{list.Items[0].ID, list.Items[1].ID})
+
+	// the upload without a metro is hidden from the metro scoped client.
+	list = decode[UploadList](t, request(t, g, "dc13-team", http.MethodGet, "/api/v2/uploads", nil, nil))
+	assert.Len(t, list.Items, 1)
+
+	w = request(t, g, "dc13-team", http.MethodGet, "/api/v2/uploads/"+failed.ID, nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
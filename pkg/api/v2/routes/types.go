@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/metal-toolbox/bomservice/internal/enroll"
+	"github.com/metal-toolbox/bomservice/internal/lookup"
+	"github.com/metal-toolbox/bomservice/internal/model"
+	v1routes "github.com/metal-toolbox/bomservice/pkg/api/v1/routes"
+	fleetdbapi "github.com/metal-toolbox/fleetdb/pkg/api/v1"
+)
+
+// Bom is the bill of materials of a server, identified by its serial number.
+type Bom struct {
+	SerialNum       string   `json:"serial_num"`
+	AOCMacAddresses []string `json:"aoc_mac_addresses"`
+	BMCMacAddresses []string `json:"bmc_mac_addresses"`
+	NumDefiPmi      string   `json:"num_defi_pmi"`
+	NumDefPWD       string   `json:"num_def_pwd"`
+	Metro           string   `json:"metro,omitempty"`
+}
+
+// MacRole is the role a MAC address plays in a BOM.
+type MacRole string
+
+const (
+	MacRoleAOC MacRole = "aoc"
+	MacRoleBMC MacRole = "bmc"
+)
+
+// MacAddress is a MAC address listed in a BOM.
+type MacAddress struct {
+	Address string  `json:"address"`
+	Role    MacRole `json:"role"`
+}
+
+// MacAddressList lists the MAC addresses of a BOM.
+type MacAddressList struct {
+	Items []MacAddress `json:"items"`
+}
+
+// Pagination describes the page of a list response, the links are relative to the server address.
+type Pagination struct {
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalItems int    `json:"total_items"`
+	TotalPages int    `json:"total_pages"`
+	Next       string `json:"next,omitempty"`
+	Previous   string `json:"previous,omitempty"`
+}
+
+// BomList is a page of BOMs ordered by serial number.
+type BomList struct {
+	Items      []Bom      `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// UploadStatus is the outcome of an upload.
+type UploadStatus string
+
+const (
+	UploadSucceeded UploadStatus = "succeeded"
+	UploadFailed    UploadStatus = "failed"
+)
+
+// MacCollision is a MAC address claimed by more than one serial number.
+type MacCollision struct {
+	MacAddress string `json:"mac_address"`
+	// Serials are the serial numbers claiming the address in the upload.
+	Serials []string `json:"serials"`
+	// ExistingSerials are the other serial numbers claiming the address in the stored BOMs.
+	ExistingSerials []string `json:"existing_serials,omitempty"`
+	// SameRole is true when two of the serial numbers claim the address in the same role.
+	SameRole bool `json:"same_role,omitempty"`
+}
+
+// EnrolledServer is a fleetdb server record for an uploaded BOM.
+type EnrolledServer struct {
+	SerialNum  string `json:"serial_num"`
+	ServerUUID string `json:"server_uuid,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Enrollment lists the fleetdb server records for the uploaded BOMs.
+type Enrollment struct {
+	Created  []EnrolledServer `json:"created"`
+	Existing []EnrolledServer `json:"existing"`
+	Failed   []EnrolledServer `json:"failed"`
+}
+
+// Upload is an xlsx file upload, recorded once the file is parsed.
+type Upload struct {
+	ID     string       `json:"id"`
+	Status UploadStatus `json:"status"`
+	// Metro is the metro the uploaded BOMs are tagged with.
+	Metro string `json:"metro,omitempty"`
+	// UploadedBy is the subject of the principal that made the upload.
+	UploadedBy    string         `json:"uploaded_by,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Serials       []string       `json:"serials"`
+	MacCollisions []MacCollision `json:"mac_collisions,omitempty"`
+	Enrolled      *Enrollment    `json:"enrolled,omitempty"`
+	// Error is set for failed uploads.
+	Error *Error `json:"error,omitempty"`
+}
+
+// UploadList is a page of uploads, the most recent first.
+type UploadList struct {
+	Items      []Upload   `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Error describes why a request failed, clients should match on the code rather than the message.
+type Error struct {
+	Code    v1routes.ErrorCode `json:"code"`
+	Message string             `json:"message"`
+	// RequestID is the X-Request-ID of the request, to quote when reporting the error.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ErrorResponse is the response body of failed requests.
+type ErrorResponse struct {
+	Error *Error `json:"error"`
+}
+
+// newBom returns the BOM for the stored record.
+func newBom(bom *fleetdbapi.Bom) Bom {
+	return Bom{
+		SerialNum:       bom.SerialNum,
+		AOCMacAddresses: nonNil(model.SplitMacAddrs(bom.AocMacAddress)),
+		BMCMacAddresses: nonNil(model.SplitMacAddrs(bom.BmcMacAddress)),
+		NumDefiPmi:      bom.NumDefiPmi,
+		NumDefPWD:       bom.NumDefPWD,
+		Metro:           bom.Metro,
+	}
+}
+
+// newMacAddressList returns the MAC addresses of the BOM, AOC addresses first.
+func newMacAddressList(bom *Bom) *MacAddressList {
+	list := &MacAddressList{Items: make([]MacAddress, 0, len(bom.AOCMacAddresses)+len(bom.BMCMacAddresses))}
+
+	for _, addr := range bom.AOCMacAddresses {
+		list.Items = append(list.Items, MacAddress{Address: addr, Role: MacRoleAOC})
+	}
+
+	for _, addr := range bom.BMCMacAddresses {
+		list.Items = append(list.Items, MacAddress{Address: addr, Role: MacRoleBMC})
+	}
+
+	return list
+}
+
+// newMacCollisions returns the upload collisions.
+func newMacCollisions(collisions []lookup.Collision) []MacCollision {
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	converted := make([]MacCollision, 0, len(collisions))
+	for _, c := range collisions {
+		converted = append(converted, MacCollision(c))
+	}
+
+	return converted
+}
+
+// newEnrollment returns the enrollment result of an upload, nil when enrollment is disabled.
+func newEnrollment(result *enroll.Result) *Enrollment {
+	if result == nil {
+		return nil
+	}
+
+	servers := func(enrolled []enroll.Server) []EnrolledServer {
+		converted := make([]EnrolledServer, 0, len(enrolled))
+		for _, s := range enrolled {
+			converted = append(converted, EnrolledServer(s))
+		}
+
+		return converted
+	}
+
+	return &Enrollment{
+		Created:  servers(result.Created),
+		Existing: servers(result.Existing),
+		Failed:   servers(result.Failed),
+	}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"sync"
+)
+
+// DefaultUploadHistory is the number of uploads kept for the uploads resource.
+const DefaultUploadHistory = 1000
+
+// uploadLog keeps the most recent uploads in memory, so each instance lists the uploads it served.
+type uploadLog struct {
+	mu    sync.RWMutex
+	size  int
+	items []*Upload
+	byID  map[string]*Upload
+}
+
+func newUploadLog(size int) *uploadLog {
+	if size <= 0 {
+		size = DefaultUploadHistory
+	}
+
+	return &uploadLog{size: size, byID: map[string]*Upload{}}
+}
+
+// add records the upload, dropping the oldest one when the log is full.
+func (l *uploadLog) add(upload *Upload) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.items) == l.size {
+		delete(l.byID, l.items[0].ID)
+		l.items = l.items[1:]
+	}
+
+	l.items = append(l.items, upload)
+	l.byID[upload.ID] = upload
+}
+
+// get returns the upload with the ID, nil when it is not recorded.
+func (l *uploadLog) get(id string) *Upload {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.byID[id]
+}
+
+// list returns the recorded uploads matching the filter, the most recent first.
+func (l *uploadLog) list(match func(*Upload) bool) []Upload {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	uploads := []Upload{}
+
+	for i := len(l.items) - 1; i >= 0; i-- {
+		if match(l.items[i]) {
+			uploads = append(uploads, *l.items[i])
+		}
+	}
+
+	return uploads
+}